@@ -0,0 +1,321 @@
+package anilist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jmcvetta/napping"
+	"github.com/op/go-logging"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/util/ident"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+const (
+	// APIURL ...
+	APIURL = "https://graphql.anilist.co"
+	// AuthorizeURL ...
+	AuthorizeURL = "https://anilist.co/api/v2/oauth/authorize?client_id=%s&response_type=token"
+)
+
+// Media list statuses
+const (
+	StatusCurrent   = "CURRENT"
+	StatusPlanning  = "PLANNING"
+	StatusCompleted = "COMPLETED"
+	StatusDropped   = "DROPPED"
+	StatusPaused    = "PAUSED"
+	StatusRepeating = "REPEATING"
+)
+
+// FormatMovie is a media format of anime movies
+const FormatMovie = "MOVIE"
+
+var log = logging.MustGetLogger("anilist")
+
+var (
+	// ErrNotAuthorized is returned when there is no AniList token
+	ErrNotAuthorized = errors.New("AniList is not authorized")
+	// ErrNoClientID is returned when AniList client ID is not set in settings
+	ErrNoClientID = errors.New("AniList client ID is not set")
+)
+
+// Title ...
+type Title struct {
+	Romaji  string `json:"romaji"`
+	English string `json:"english"`
+}
+
+// Date ...
+type Date struct {
+	Year int `json:"year"`
+}
+
+// Media is an anime
+type Media struct {
+	ID        int    `json:"id"`
+	IDMal     int    `json:"idMal"`
+	Format    string `json:"format"`
+	Episodes  int    `json:"episodes"`
+	Title     Title  `json:"title"`
+	StartDate Date   `json:"startDate"`
+}
+
+// Entry is an anime in the user's list
+type Entry struct {
+	Status    string `json:"status"`
+	Progress  int    `json:"progress"`
+	UpdatedAt int64  `json:"updatedAt"`
+	Media     *Media `json:"media"`
+}
+
+// Viewer is authorized user
+type Viewer struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+const (
+	viewerQuery = `query { Viewer { id name } }`
+
+	listQuery = `query ($userId: Int) {
+  MediaListCollection(userId: $userId, type: ANIME) {
+    lists { entries { status progress updatedAt media { id idMal format episodes title { romaji english } startDate { year } } } }
+  }
+}`
+
+	searchQuery = `query ($search: String, $year: Int) {
+  Page(perPage: 5) {
+    media(search: $search, seasonYear: $year, type: ANIME) { id idMal format episodes title { romaji english } startDate { year } }
+  }
+}`
+
+	entryQuery = `query ($id: Int) {
+  Media(id: $id, type: ANIME) {
+    id idMal format episodes title { romaji english } startDate { year }
+    mediaListEntry { status progress updatedAt }
+  }
+}`
+
+	saveEntryMutation = `mutation ($mediaId: Int, $progress: Int, $status: MediaListStatus) {
+  SaveMediaListEntry(mediaId: $mediaId, progress: $progress, status: $status) { id status progress }
+}`
+)
+
+// Authorized checks that AniList token is set
+func Authorized() error {
+	if config.Get().AniListToken == "" {
+		return ErrNotAuthorized
+	}
+	return nil
+}
+
+// Query sends GraphQL query and decodes "data" field of the response into ret
+func Query(query string, variables map[string]interface{}, withAuth bool, ret interface{}) error {
+	b, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{
+		"Content-type": []string{"application/json"},
+		"Accept":       []string{"application/json"},
+		"User-Agent":   []string{ident.DefaultUserAgent()},
+	}
+	if withAuth {
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", config.Get().AniListToken))
+	}
+
+	req := napping.Request{
+		Url:        APIURL,
+		Method:     "POST",
+		RawPayload: true,
+		Payload:    bytes.NewBuffer(b),
+		Header:     &header,
+	}
+	resp, err := napping.Send(&req)
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}{}
+	if err := resp.Unmarshal(&result); err != nil {
+		return err
+	} else if len(result.Errors) > 0 {
+		return fmt.Errorf("AniList error: %s", result.Errors[0].Message)
+	} else if resp.Status() != 200 {
+		return fmt.Errorf("Bad status from AniList: %d", resp.Status())
+	} else if ret == nil || len(result.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(result.Data, ret)
+}
+
+// GetViewer returns authorized user
+func GetViewer() (*Viewer, error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	ret := struct {
+		Viewer *Viewer `json:"Viewer"`
+	}{}
+	if err := Query(viewerQuery, nil, true, &ret); err != nil {
+		return nil, err
+	} else if ret.Viewer == nil {
+		return nil, errors.New("Empty AniList user")
+	}
+	return ret.Viewer, nil
+}
+
+// UserEntries returns all anime from user's lists
+func UserEntries() ([]*Entry, error) {
+	viewer, err := GetViewer()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := struct {
+		MediaListCollection struct {
+			Lists []struct {
+				Entries []*Entry `json:"entries"`
+			} `json:"lists"`
+		} `json:"MediaListCollection"`
+	}{}
+	if err := Query(listQuery, map[string]interface{}{"userId": viewer.ID}, true, &ret); err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+	for _, list := range ret.MediaListCollection.Lists {
+		entries = append(entries, list.Entries...)
+	}
+	return entries, nil
+}
+
+// SearchAnime looks for anime by title, year is optional
+func SearchAnime(title string, year int) ([]*Media, error) {
+	variables := map[string]interface{}{"search": title}
+	if year > 0 {
+		variables["year"] = year
+	}
+
+	ret := struct {
+		Page struct {
+			Media []*Media `json:"media"`
+		} `json:"Page"`
+	}{}
+	if err := Query(searchQuery, variables, false, &ret); err != nil {
+		return nil, err
+	}
+	return ret.Page.Media, nil
+}
+
+// GetEntry returns anime from user's lists, or nil if it is not in the lists
+func GetEntry(mediaID int) (*Entry, error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	ret := struct {
+		Media *struct {
+			Media
+			MediaListEntry *Entry `json:"mediaListEntry"`
+		} `json:"Media"`
+	}{}
+	if err := Query(entryQuery, map[string]interface{}{"id": mediaID}, true, &ret); err != nil {
+		return nil, err
+	} else if ret.Media == nil || ret.Media.MediaListEntry == nil {
+		return nil, nil
+	}
+
+	entry := ret.Media.MediaListEntry
+	entry.Media = &ret.Media.Media
+	return entry, nil
+}
+
+// SaveProgress sets number of watched episodes and list status of the anime
+func SaveProgress(mediaID, progress int, status string) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	log.Infof("Saving AniList progress for %d: %d episodes, %s", mediaID, progress, status)
+	return Query(saveEntryMutation, map[string]interface{}{
+		"mediaId":  mediaID,
+		"progress": progress,
+		"status":   status,
+	}, true, nil)
+}
+
+// Authorize asks user to open AniList authorization page and to paste access token.
+// AniList has no device code flow, so implicit grant token is entered manually.
+func Authorize() error {
+	if config.Get().AniListClientID == "" {
+		return ErrNoClientID
+	}
+
+	xbmcHost, err := xbmc.GetLocalXBMCHost()
+	if err != nil || xbmcHost == nil {
+		return errors.New("Kodi is not available")
+	}
+
+	authURL := fmt.Sprintf(AuthorizeURL, config.Get().AniListClientID)
+	log.Noticef("AniList authorization page: %s", authURL)
+	if !xbmcHost.Dialog("AniList", fmt.Sprintf(xbmcHost.GetLocalizedString(30702), authURL)) {
+		return errors.New("Authentication canceled")
+	}
+
+	token := strings.TrimSpace(xbmcHost.Keyboard("", "AniList"))
+	if token == "" {
+		return errors.New("Authentication canceled")
+	}
+
+	config.Get().AniListToken = token
+	viewer, err := GetViewer()
+	if err != nil {
+		config.Get().AniListToken = ""
+		xbmcHost.Notify("Elementum", "LOCALIZE[30757]", config.AddonIcon())
+		return err
+	}
+
+	xbmcHost.SetSetting("anilist_token", token)
+	xbmcHost.SetSetting("anilist_username", viewer.Name)
+	config.Reload()
+
+	xbmcHost.Notify("Elementum", "LOCALIZE[30756]", config.AddonIcon())
+	return nil
+}
+
+// Deauthorize removes AniList token
+func Deauthorize() error {
+	if config.Get().AniListToken == "" {
+		return ErrNotAuthorized
+	}
+
+	if xbmcHost, err := xbmc.GetLocalXBMCHost(); err == nil && xbmcHost != nil {
+		xbmcHost.SetSetting("anilist_token", "")
+		xbmcHost.SetSetting("anilist_username", "")
+		xbmcHost.Notify("Elementum", "LOCALIZE[30758]", config.AddonIcon())
+	}
+	config.Reload()
+	return nil
+}
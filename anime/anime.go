@@ -0,0 +1,153 @@
+package anime
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/anacrolix/sync"
+	"github.com/op/go-logging"
+
+	"github.com/elgatito/elementum/config"
+)
+
+// MappingFileName is the name of offline AniDB/TVDB/TMDB mapping file, in anime-lists XML format.
+// The file from the profile folder, if present, is preferred over the shipped one.
+const MappingFileName = "anime-list.xml"
+
+// AbsoluteSeason is a season of TVDB show, that means absolute episode numbering
+const AbsoluteSeason = -1
+
+var log = logging.MustGetLogger("anime")
+
+// Mapping is a range of AniDB episodes mapped to TVDB season
+type Mapping struct {
+	AniDBSeason int `xml:"anidbseason,attr"`
+	TVDBSeason  int `xml:"tvdbseason,attr"`
+	Start       int `xml:"start,attr"`
+	End         int `xml:"end,attr"`
+	Offset      int `xml:"offset,attr"`
+}
+
+// Entry is AniDB anime with cross references to TVDB, TMDB and IMDb
+type Entry struct {
+	AniDBID  int       `xml:"anidbid,attr"`
+	TVDBID   int       `xml:"-"`
+	TMDBID   int       `xml:"-"`
+	IMDBID   string    `xml:"imdbid,attr"`
+	Name     string    `xml:"name"`
+	Mappings []Mapping `xml:"mapping-list>mapping"`
+
+	// DefaultSeason is TVDB season of the anime, or AbsoluteSeason
+	DefaultSeason int `xml:"-"`
+	EpisodeOffset int `xml:"-"`
+
+	RawTVDBID        string `xml:"tvdbid,attr"`
+	RawTMDBID        string `xml:"tmdbid,attr"`
+	RawDefaultSeason string `xml:"defaulttvdbseason,attr"`
+	RawEpisodeOffset string `xml:"episodeoffset,attr"`
+}
+
+type mappingList struct {
+	Entries []*Entry `xml:"anime"`
+}
+
+var (
+	entriesMu sync.RWMutex
+	loaded    bool
+	byTVDB    map[int][]*Entry
+)
+
+// mappingPath returns path to the mapping file, profile copy is used if it exists
+func mappingPath() string {
+	if path := filepath.Join(config.Get().Info.Profile, MappingFileName); isFile(path) {
+		return path
+	}
+	return filepath.Join(config.Get().Info.Path, "resources", MappingFileName)
+}
+
+func isFile(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+// Reload reads the mapping file again
+func Reload() error {
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+
+	loaded = true
+	byTVDB = map[int][]*Entry{}
+
+	path := mappingPath()
+	f, err := os.Open(path)
+	if err != nil {
+		log.Debugf("Anime mapping file is not available: %s", err)
+		return err
+	}
+	defer f.Close()
+
+	list := &mappingList{}
+	if err := xml.NewDecoder(f).Decode(list); err != nil {
+		log.Warningf("Could not parse anime mapping file %s: %s", path, err)
+		return err
+	}
+
+	for _, e := range list.Entries {
+		e.TVDBID, _ = strconv.Atoi(e.RawTVDBID)
+		e.TMDBID, _ = strconv.Atoi(e.RawTMDBID)
+		e.EpisodeOffset, _ = strconv.Atoi(e.RawEpisodeOffset)
+		if e.RawDefaultSeason == "a" {
+			e.DefaultSeason = AbsoluteSeason
+		} else {
+			e.DefaultSeason, _ = strconv.Atoi(e.RawDefaultSeason)
+		}
+
+		if e.TVDBID != 0 {
+			byTVDB[e.TVDBID] = append(byTVDB[e.TVDBID], e)
+		}
+	}
+
+	log.Infof("Loaded %d anime mappings from %s", len(list.Entries), path)
+	return nil
+}
+
+func ensureLoaded() {
+	entriesMu.RLock()
+	isLoaded := loaded
+	entriesMu.RUnlock()
+
+	if !isLoaded {
+		Reload()
+	}
+}
+
+// GetByTVDB returns AniDB entries of the TVDB show
+func GetByTVDB(tvdbID int) []*Entry {
+	ensureLoaded()
+
+	entriesMu.RLock()
+	defer entriesMu.RUnlock()
+	return byTVDB[tvdbID]
+}
+
+// FromTVDB converts TVDB season and episode to AniDB episode number of the entry,
+// returns 0 if the episode does not belong to the entry.
+func (e *Entry) FromTVDB(season, number int) int {
+	for _, m := range e.Mappings {
+		if m.AniDBSeason == 1 && m.TVDBSeason == season && m.Start > 0 {
+			if episode := number - m.Offset; episode >= m.Start && episode <= m.End {
+				return episode
+			}
+		}
+	}
+
+	if e.DefaultSeason != season {
+		return 0
+	}
+	if episode := number - e.EpisodeOffset; episode > 0 {
+		return episode
+	}
+	return 0
+}
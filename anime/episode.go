@@ -0,0 +1,197 @@
+package anime
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/tvdb"
+	"github.com/elgatito/elementum/util"
+)
+
+// Batch ranges, like "Show - 01-12 [1080p]", "Show 01 ~ 12" or "Show E01-E12".
+// Hyphen should not have spaces around, as "Show 3 - 07" is an episode of the third season.
+var rangeRegex = regexp.MustCompile(`(?i)(?:^|[\s_\-\[(.])(?:EP?)?(\d{1,4})(?:-|\s*~\s*|\s+to\s+)(?:EP?)?(\d{1,4})(?:v\d)?(?:$|[\s_\])\.])`)
+
+// ParseRange returns first and last episode of the batch range in the name, or zeros
+func ParseRange(name string) (from, to int) {
+	for _, m := range rangeRegex.FindAllStringSubmatch(name, -1) {
+		from, _ = strconv.Atoi(m[1])
+		to, _ = strconv.Atoi(m[2])
+
+		// Ranges should go forward and should not look like years or resolutions
+		if from > 0 && to > from && to-from < 2000 && to < 1900 {
+			return from, to
+		}
+	}
+	return 0, 0
+}
+
+// InRange checks whether the name has batch range, that contains any of the numbers
+func InRange(name string, numbers ...int) bool {
+	from, to := ParseRange(name)
+	if from == 0 {
+		return false
+	}
+
+	for _, n := range numbers {
+		if n >= from && n <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOverride returns user defined episode mapping of the show
+func GetOverride(showID int) *database.AnimeOverride {
+	return database.GetStorm().GetAnimeOverride(showID)
+}
+
+// SaveOverride stores user defined episode mapping of the show
+func SaveOverride(o *database.AnimeOverride) error {
+	return database.GetStorm().SaveAnimeOverride(o)
+}
+
+// DeleteOverride removes user defined episode mapping of the show
+func DeleteOverride(showID int) error {
+	return database.GetStorm().DeleteAnimeOverride(showID)
+}
+
+// GetTVDBShow returns TVDB show of the TMDB show, used for absolute numbers
+func GetTVDBShow(show *tmdb.Show, language string) *tvdb.Show {
+	if show == nil || show.ExternalIDs == nil {
+		return nil
+	}
+
+	tvdbID := util.StrInterfaceToInt(show.ExternalIDs.TVDBID)
+	if tvdbID == 0 {
+		return nil
+	}
+
+	tvdbShow, _ := tvdb.GetShow(tvdbID, language)
+	return tvdbShow
+}
+
+// ToAbsolute converts season and episode to absolute episode number.
+// Per-show override is used first, then TVDB absolute numbers, then TMDB seasons sizes.
+func ToAbsolute(show *tmdb.Show, tvdbShow *tvdb.Show, season, episode int) int {
+	if show == nil || season <= 0 || episode <= 0 {
+		return 0
+	}
+
+	o := GetOverride(show.ID)
+	if o != nil {
+		if start, ok := o.SeasonStarts[season]; ok {
+			return start + episode - 1 + o.Offset
+		}
+	}
+
+	absolute := 0
+	if tvdbShow != nil {
+		if tvdbSeason := tvdbShow.GetSeason(season); tvdbSeason != nil {
+			if tvdbEpisode := tvdbSeason.GetEpisode(episode); tvdbEpisode != nil && tvdbEpisode.AbsoluteNumber > 0 {
+				absolute = tvdbEpisode.AbsoluteNumber
+			}
+		}
+	}
+
+	if absolute == 0 {
+		absolute = episode
+		for _, s := range show.Seasons {
+			if s != nil && s.Season > 0 && s.Season < season {
+				absolute += s.EpisodeCount
+			}
+		}
+	}
+
+	if o != nil {
+		absolute += o.Offset
+	}
+	return absolute
+}
+
+// FromAbsolute converts absolute episode number to season and episode of the show
+func FromAbsolute(show *tmdb.Show, tvdbShow *tvdb.Show, absolute int) (season, episode int) {
+	if show == nil || absolute <= 0 {
+		return 0, 0
+	}
+
+	if o := GetOverride(show.ID); o != nil {
+		absolute -= o.Offset
+
+		bestSeason, bestStart := 0, 0
+		for s, start := range o.SeasonStarts {
+			if start <= absolute && start > bestStart {
+				bestSeason, bestStart = s, start
+			}
+		}
+		if bestSeason > 0 {
+			return bestSeason, absolute - bestStart + 1
+		}
+	}
+
+	if tvdbShow != nil {
+		for _, s := range tvdbShow.Seasons {
+			if s == nil || s.Season == 0 {
+				continue
+			}
+			for _, e := range s.Episodes {
+				if e != nil && e.AbsoluteNumber == absolute {
+					return s.Season, e.EpisodeNumber
+				}
+			}
+		}
+	}
+
+	left := absolute
+	for _, s := range show.Seasons {
+		if s == nil || s.Season == 0 || s.EpisodeCount == 0 {
+			continue
+		}
+		if left <= s.EpisodeCount {
+			return s.Season, left
+		}
+		left -= s.EpisodeCount
+	}
+	return 0, 0
+}
+
+// EpisodeNumbers returns numbers, that release groups could use for the episode, in order of preference:
+// absolute number and number inside AniDB entry (for shows, that split seasons into separate anime).
+func EpisodeNumbers(show *tmdb.Show, tvdbShow *tvdb.Show, season, episode int) []int {
+	ret := []int{}
+	add := func(n int) {
+		if n <= 0 {
+			return
+		}
+		for _, e := range ret {
+			if e == n {
+				return
+			}
+		}
+		ret = append(ret, n)
+	}
+
+	absolute := ToAbsolute(show, tvdbShow, season, episode)
+	add(absolute)
+
+	if show != nil && show.ExternalIDs != nil {
+		// Entries of the same season are cours, the one with the biggest fitting offset owns the episode
+		best, bestOffset := 0, -1
+		for _, e := range GetByTVDB(util.StrInterfaceToInt(show.ExternalIDs.TVDBID)) {
+			n := 0
+			if e.DefaultSeason == AbsoluteSeason {
+				n = e.FromTVDB(AbsoluteSeason, absolute)
+			} else {
+				n = e.FromTVDB(season, episode)
+			}
+			if n > 0 && e.EpisodeOffset > bestOffset {
+				best, bestOffset = n, e.EpisodeOffset
+			}
+		}
+		add(best)
+	}
+
+	return ret
+}
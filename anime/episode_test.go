@@ -0,0 +1,51 @@
+package anime
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+	}{
+		{name: "[Group] Show - 01-12 [1080p].mkv", from: 1, to: 12},
+		{name: "[Group] Show - 01 ~ 12 [1080p]", from: 1, to: 12},
+		{name: "[Group] Show 01~12 (BD)", from: 1, to: 12},
+		{name: "Show E01-E12", from: 1, to: 12},
+		{name: "Show ep01-ep24v2 [720p]", from: 1, to: 24},
+		{name: "Show 13 to 24", from: 13, to: 24},
+		{name: "[Group] Show 2 - 13-24 [1080p]", from: 13, to: 24},
+		{name: "[Group] Attack on Titan 3 - 07 [1080p].mkv"},
+		{name: "Boku no Hero Academia 4 - 10"},
+		{name: "[Group] Show - 07 [1080p].mkv"},
+		{name: "Show 12-01"},
+		{name: "Show 2019-2020"},
+		{name: "Show 1080-1920"},
+		{name: "Show x264-GROUP"},
+	}
+
+	for _, tt := range tests {
+		if from, to := ParseRange(tt.name); from != tt.from || to != tt.to {
+			t.Errorf("ParseRange(%q) = %d, %d, want %d, %d", tt.name, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestInRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []int
+		want    bool
+	}{
+		{name: "[Group] Show - 01-12 [1080p]", numbers: []int{5}, want: true},
+		{name: "[Group] Show - 01-12 [1080p]", numbers: []int{13, 12}, want: true},
+		{name: "[Group] Show - 01-12 [1080p]", numbers: []int{13}, want: false},
+		{name: "[Group] Attack on Titan 3 - 07 [1080p]", numbers: []int{5}, want: false},
+		{name: "[Group] Show - 05 [1080p]", numbers: []int{5}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := InRange(tt.name, tt.numbers...); got != tt.want {
+			t.Errorf("InRange(%q, %v) = %v, want %v", tt.name, tt.numbers, got, tt.want)
+		}
+	}
+}
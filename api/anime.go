@@ -0,0 +1,109 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/anime"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/tmdb"
+)
+
+// AnimeOverride returns episode numbering override of the anime show
+func AnimeOverride(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("showId"))
+	ctx.JSON(200, anime.GetOverride(showID))
+}
+
+// SetAnimeOverride saves episode numbering override of the anime show,
+// "offset" is added to absolute numbers, "starts" are absolute numbers of seasons starts, like "1:1,2:13".
+func SetAnimeOverride(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("showId"))
+	if showID == 0 {
+		ctx.String(400, "Wrong show ID")
+		return
+	}
+
+	o := &database.AnimeOverride{
+		ShowID:       showID,
+		SeasonStarts: map[int]int{},
+	}
+	o.Offset, _ = strconv.Atoi(ctx.Query("offset"))
+
+	for _, pair := range strings.Split(ctx.Query("starts"), ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			continue
+		}
+
+		season, err1 := strconv.Atoi(parts[0])
+		start, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil || season <= 0 || start <= 0 {
+			ctx.String(400, "Wrong season start: "+pair)
+			return
+		}
+		o.SeasonStarts[season] = start
+	}
+
+	if err := anime.SaveOverride(o); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+	ctx.JSON(200, o)
+}
+
+// DeleteAnimeOverride removes episode numbering override of the anime show
+func DeleteAnimeOverride(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("showId"))
+	if err := anime.DeleteOverride(showID); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+	ctx.String(200, "")
+}
+
+// AnimeEpisodeNumbers returns numbers, that are used to find the episode in anime releases
+func AnimeEpisodeNumbers(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("showId"))
+	season, _ := strconv.Atoi(ctx.Params.ByName("season"))
+	episode, _ := strconv.Atoi(ctx.Params.ByName("episode"))
+
+	show := tmdb.GetShow(showID, config.Get().Language)
+	if show == nil {
+		ctx.String(404, "Show not found")
+		return
+	}
+
+	ctx.JSON(200, anime.EpisodeNumbers(show, anime.GetTVDBShow(show, config.Get().Language), season, episode))
+}
+
+// AnimeAbsoluteEpisode returns season and episode of the anime show by absolute episode number
+func AnimeAbsoluteEpisode(ctx *gin.Context) {
+	showID, _ := strconv.Atoi(ctx.Params.ByName("showId"))
+	absolute, _ := strconv.Atoi(ctx.Params.ByName("number"))
+
+	show := tmdb.GetShow(showID, config.Get().Language)
+	if show == nil {
+		ctx.String(404, "Show not found")
+		return
+	}
+
+	season, episode := anime.FromAbsolute(show, anime.GetTVDBShow(show, config.Get().Language), absolute)
+	if season == 0 {
+		ctx.String(404, "Episode not found")
+		return
+	}
+	ctx.JSON(200, map[string]int{"season": season, "episode": episode})
+}
+
+// ReloadAnimeMapping reads anime mapping file again
+func ReloadAnimeMapping(ctx *gin.Context) {
+	if err := anime.Reload(); err != nil {
+		ctx.String(500, err.Error())
+		return
+	}
+	ctx.String(200, "")
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/bittorrent"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// BulkResult is a result of bulk action for a single torrent
+type BulkResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+var (
+	errBulkNotFound = errors.New("Torrent not found")
+	errBulkMemory   = errors.New("Action is not supported for memory storage")
+)
+
+// bulkActions apply an action to a single torrent, remove action is handled separately
+var bulkActions = map[string]func(s *bittorrent.Service, t *bittorrent.Torrent) error{
+	"pause": func(s *bittorrent.Service, t *bittorrent.Torrent) error {
+		t.Pause()
+		return nil
+	},
+	"resume": func(s *bittorrent.Service, t *bittorrent.Torrent) error {
+		t.Resume()
+		return nil
+	},
+	"recheck": func(s *bittorrent.Service, t *bittorrent.Torrent) error {
+		if t.IsMemoryStorage() {
+			return errBulkMemory
+		}
+		t.ForceRecheck()
+		return nil
+	},
+	"reannounce": func(s *bittorrent.Service, t *bittorrent.Torrent) error {
+		t.ForceReannounce()
+		if !config.Get().DisableDHT {
+			t.ForceDHTAnnounce()
+		}
+		return nil
+	},
+	"move": func(s *bittorrent.Service, t *bittorrent.Torrent) error {
+		if t.IsMemoryStorage() {
+			return errBulkMemory
+		}
+		s.MarkToMove(t)
+		return nil
+	},
+}
+
+// BulkTorrents applies action to torrents, selected with comma-separated "hashes",
+// or with "filter" expression like "state=seeding&ratio>1".
+// Remove action deletes data with "files=true". Kodi dialogs are not shown for bulk actions.
+func BulkTorrents(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		action := ctx.Params.ByName("action")
+		deleteFiles := ctx.DefaultQuery("files", "false") == "true"
+
+		if _, ok := bulkActions[action]; !ok && action != "remove" {
+			ctx.String(400, "Unknown action")
+			return
+		}
+
+		hashes := []string{}
+		for _, h := range append(ctx.QueryArray("hashes"), ctx.PostFormArray("hashes")...) {
+			for _, hash := range strings.Split(h, ",") {
+				if hash = strings.ToLower(strings.TrimSpace(hash)); hash != "" {
+					hashes = append(hashes, hash)
+				}
+			}
+		}
+		expr := ctx.Request.FormValue("filter")
+
+		if len(hashes) == 0 && expr == "" {
+			ctx.String(400, "Torrents are not selected")
+			return
+		}
+
+		results := []*BulkResult{}
+		torrents := []*bittorrent.Torrent{}
+		if len(hashes) > 0 {
+			for _, hash := range hashes {
+				if t := s.GetTorrentByHash(hash); t != nil {
+					torrents = append(torrents, t)
+				} else {
+					results = append(results, &BulkResult{ID: hash, Error: errBulkNotFound.Error()})
+				}
+			}
+		}
+		if expr != "" {
+			filter, err := bittorrent.ParseTorrentFilter(expr)
+			if err != nil {
+				ctx.String(400, err.Error())
+				return
+			}
+
+			if len(hashes) == 0 {
+				torrents = s.FindTorrents(filter)
+			} else {
+				matched := []*bittorrent.Torrent{}
+				for _, t := range torrents {
+					if filter.Match(t) {
+						matched = append(matched, t)
+					}
+				}
+				torrents = matched
+			}
+		}
+
+		results = append(results, runBulkAction(s, action, deleteFiles, torrents)...)
+
+		if xbmcHost != nil && len(torrents) > 0 {
+			xbmcHost.Refresh()
+		}
+		ctx.JSON(200, results)
+	}
+}
+
+// runBulkAction applies known action to each torrent and collects results
+func runBulkAction(s *bittorrent.Service, action string, deleteFiles bool, torrents []*bittorrent.Torrent) []*BulkResult {
+	results := make([]*BulkResult, 0, len(torrents))
+	for _, t := range torrents {
+		r := &BulkResult{ID: t.InfoHash(), Name: t.Name(), Success: true}
+
+		var err error
+		if action == "remove" {
+			if !s.RemoveTorrent(nil, t, true, deleteFiles, false) {
+				err = errBulkNotFound
+			}
+		} else {
+			err = bulkActions[action](s, t)
+		}
+
+		if err != nil {
+			r.Success = false
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+
+	log.Infof("Applied %s to %d torrents", action, len(torrents))
+	return results
+}
@@ -209,6 +209,7 @@ func ClearDatabaseTorrentHistory(ctx *gin.Context) {
 	log.Debug("Removing torrent history from database")
 
 	database.GetStormDB().Drop(&database.TorrentAssignMetadata{})
+	database.GetStormDB().Drop(&database.ResolvedMetadata{})
 	database.GetStormDB().Drop(&database.TorrentAssignItem{})
 	database.GetStormDB().Drop(&database.TorrentHistory{})
 
@@ -244,6 +245,7 @@ func ClearDatabase(ctx *gin.Context) {
 	database.GetStormDB().Drop(&database.BTItem{})
 	database.GetStormDB().Drop(&database.TorrentHistory{})
 	database.GetStormDB().Drop(&database.TorrentAssignMetadata{})
+	database.GetStormDB().Drop(&database.ResolvedMetadata{})
 	database.GetStormDB().Drop(&database.TorrentAssignItem{})
 	database.GetStormDB().Drop(&database.QueryHistory{})
 
@@ -114,6 +114,7 @@ func InfoLabelsEpisode(s *bittorrent.Service) gin.HandlerFunc {
 		episodeNumber, _ := strconv.Atoi(ctx.Params.ByName("episode"))
 
 		if item, err := GetEpisodeLabels(showID, seasonNumber, episodeNumber); err == nil {
+			item.StreamInfo = s.GetPlayerStreamInfo(0, showID, seasonNumber, episodeNumber)
 			saveEncoded(xbmcHost, encodeItem(item))
 			ctx.JSON(200, item)
 		} else {
@@ -132,6 +133,9 @@ func InfoLabelsMovie(s *bittorrent.Service) gin.HandlerFunc {
 		tmdbID := ctx.Params.ByName("tmdbId")
 
 		if item, err := GetMovieLabels(tmdbID); err == nil {
+			if id, _ := strconv.Atoi(tmdbID); id != 0 {
+				item.StreamInfo = s.GetPlayerStreamInfo(id, 0, 0, 0)
+			}
 			saveEncoded(xbmcHost, encodeItem(item))
 			ctx.JSON(200, item)
 		} else {
@@ -223,10 +227,12 @@ func GetSearchLabels(s *bittorrent.Service, tmdbID string, idx string) (item *xb
 	// Collecting downloaded file names into string to show in a subtitle
 	chosenFiles := map[string]bool{}
 	chosenFileNames := []string{}
+	var streamFile *bittorrent.File
 
 	if idxNum, errNum := strconv.Atoi(idx); errNum == nil && idxNum >= 0 {
 		if f := torrent.GetCandidateFileForIndex(idxNum); f != nil {
 			chosenFiles[filepath.Base(f.Path)] = true
+			streamFile = torrent.GetFileByIndex(f.Index)
 		}
 	}
 
@@ -234,6 +240,9 @@ func GetSearchLabels(s *bittorrent.Service, tmdbID string, idx string) (item *xb
 		for _, f := range torrent.ChosenFiles {
 			chosenFiles[filepath.Base(f.Path)] = true
 		}
+		if len(torrent.ChosenFiles) > 0 {
+			streamFile = torrent.ChosenFiles[0]
+		}
 	}
 
 	for k := range chosenFiles {
@@ -253,7 +262,8 @@ func GetSearchLabels(s *bittorrent.Service, tmdbID string, idx string) (item *xb
 			DBTYPE:        "episode",
 			Mediatype:     "episode",
 		},
-		Art: &xbmc.ListItemArt{},
+		Art:        &xbmc.ListItemArt{},
+		StreamInfo: torrent.GetStreamInfo(streamFile),
 	}
 
 	return
@@ -90,7 +90,56 @@ func AddMoviesList(ctx *gin.Context) {
 		updating = true
 	}
 
+	library.BeginSyncRun()
 	library.SyncMoviesList(listID, updating, updating)
+	notifySyncPlan(ctx)
+}
+
+// AddMovieCollection adds released movies of TMDB collection to the library,
+// and follows the collection to get new parts, if user agrees or follow is passed.
+func AddMovieCollection(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	collectionID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	follow := ctx.Query("follow") == trueType
+	if _, ok := ctx.GetQuery("follow"); !ok && xbmcHost != nil {
+		follow = xbmcHost.DialogConfirmNonTimed("Elementum", "LOCALIZE[30726]")
+	}
+
+	if follow {
+		collection, err := library.FollowCollection(collectionID)
+		if err != nil {
+			log.Warningf("Could not follow collection %d: %s", collectionID, err)
+			ctx.String(200, err.Error())
+			return
+		}
+		if xbmcHost != nil {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30716];;%s", collection.Name), config.AddonIcon())
+		}
+		ctx.String(200, "")
+		return
+	}
+
+	collection, added, err := library.AddCollection(collectionID)
+	if err != nil {
+		log.Warningf("Could not add collection %d: %s", collectionID, err)
+		ctx.String(200, err.Error())
+		return
+	}
+	if xbmcHost == nil {
+		ctx.String(200, "")
+		return
+	}
+
+	xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30727];;%d;;%s", added, collection.Name), config.AddonIcon())
+	if added > 0 && (config.Get().LibraryUpdate == 0 || (config.Get().LibraryUpdate == 1 && xbmcHost.DialogConfirmFocused("Elementum", fmt.Sprintf("LOCALIZE[30277];;%s", collection.Name)))) {
+		xbmcHost.VideoLibraryScanDirectory(library.MoviesLibraryPath(), true)
+	} else {
+		library.ClearPageCache(xbmcHost)
+	}
+	ctx.String(200, "")
 }
 
 // RemoveMovie ...
@@ -185,7 +234,9 @@ func AddShowsList(ctx *gin.Context) {
 		updating = true
 	}
 
+	library.BeginSyncRun()
 	library.SyncShowsList(listID, updating, updating)
+	notifySyncPlan(ctx)
 }
 
 // RemoveShow ...
@@ -245,6 +296,130 @@ func UpdateTrakt(ctx *gin.Context) {
 	}()
 }
 
+// ImportHistory imports watch history from Letterboxd, IMDb or Trakt export file.
+// Without dry_run=false only the report is returned, without backend nothing is pushed to sync backends.
+func ImportHistory(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	path := ctx.Query("path")
+	if path == "" && xbmcHost != nil {
+		path = xbmcHost.DialogBrowseSingle(1, "LOCALIZE[30703]", "files", ".csv|.json", false, false, "")
+	}
+	if path == "" {
+		ctx.String(200, "")
+		return
+	}
+
+	dryRun := ctx.DefaultQuery("dry_run", trueType) != falseType
+	report, err := library.ImportHistoryFile(path, ctx.Query("format"), dryRun, ctx.Query("backend"))
+	if err != nil {
+		log.Warningf("Could not import history from %s: %s", path, err)
+		if xbmcHost != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		}
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost != nil {
+		text := fmt.Sprintf(xbmcHost.GetLocalizedString(30704), report.Matched, report.Total, report.Marked, report.NotInLibrary, len(report.Unmatched))
+		for _, row := range report.Unmatched {
+			text += fmt.Sprintf("\n%d: %s (%d) - %s", row.Line, row.Title, row.Year, row.Reason)
+		}
+		xbmcHost.DialogText("Elementum", text)
+	}
+
+	ctx.JSON(200, report)
+}
+
+// SyncPlan shows library changes, waiting for confirmation, as a diff and applies them if confirmed
+func SyncPlan(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	plan := library.GetSyncPlan()
+	if xbmcHost == nil {
+		ctx.JSON(200, plan)
+		return
+	} else if len(plan.Actions) == 0 {
+		xbmcHost.Notify("Elementum", "LOCALIZE[30706]", config.AddonIcon())
+		ctx.JSON(200, plan)
+		return
+	}
+
+	xbmcHost.DialogText("Elementum", plan.Diff())
+	if !xbmcHost.DialogConfirmNonTimed("Elementum", fmt.Sprintf("LOCALIZE[30707];;%d", len(plan.Actions))) {
+		ctx.JSON(200, plan)
+		return
+	}
+
+	ApplySyncPlan(ctx)
+}
+
+// ApplySyncPlan applies library changes, waiting for confirmation
+func ApplySyncPlan(ctx *gin.Context) {
+	journal, err := library.ApplySyncPlan()
+	if err != nil {
+		log.Warningf("Could not apply library sync plan: %s", err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	ctx.JSON(200, journal)
+}
+
+// DiscardSyncPlan removes library changes, waiting for confirmation
+func DiscardSyncPlan(ctx *gin.Context) {
+	library.DiscardSyncPlan()
+	ctx.String(200, "")
+}
+
+// SyncJournal returns library changes, applied in the last run
+func SyncJournal(ctx *gin.Context) {
+	ctx.JSON(200, library.GetSyncJournal())
+}
+
+// UndoSync reverts library changes, applied in the last run
+func UndoSync(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	if xbmcHost != nil {
+		journal := library.GetSyncJournal()
+		if len(journal.Actions) == 0 {
+			xbmcHost.Notify("Elementum", "LOCALIZE[30706]", config.AddonIcon())
+			ctx.String(200, "")
+			return
+		} else if !xbmcHost.DialogConfirmNonTimed("Elementum", fmt.Sprintf("LOCALIZE[30708];;%d", len(journal.Actions))) {
+			ctx.String(200, "")
+			return
+		}
+	}
+
+	journal, err := library.UndoSyncRun()
+	if err != nil {
+		log.Warningf("Could not undo library sync: %s", err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost != nil {
+		xbmcHost.Notify("Elementum", "LOCALIZE[30709]", config.AddonIcon())
+	}
+	ctx.JSON(200, journal)
+}
+
+// notifySyncPlan tells user, that list sync changes are only planned
+func notifySyncPlan(ctx *gin.Context) {
+	if !library.IsSyncPlanning() {
+		return
+	}
+
+	if xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx); xbmcHost != nil {
+		if planned := len(library.GetSyncPlan().Actions); planned > 0 {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30705];;%d", planned), config.AddonIcon())
+		}
+	}
+}
+
 // PlayMovie ...
 func PlayMovie(s *bittorrent.Service) gin.HandlerFunc {
 	if config.Get().ChooseStreamAutoMovie {
@@ -18,6 +18,7 @@ import (
 	"github.com/elgatito/elementum/library"
 	"github.com/elgatito/elementum/proxy"
 	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/trakt"
 	"github.com/elgatito/elementum/util/ident"
 	iputil "github.com/elgatito/elementum/util/ip"
 	"github.com/elgatito/elementum/xbmc"
@@ -96,6 +97,10 @@ func Status(ctx *gin.Context) {
     [B]LOCALIZE[30405]:[/B] %d
     [B]LOCALIZE[30458]:[/B] %d
     [B]LOCALIZE[30459]:[/B] %d
+
+[COLOR pink][B]Trakt:[/B][/COLOR]
+    [B]LOCALIZE[30697]:[/B] %d
+    [B]LOCALIZE[30698]:[/B] %d
 `
 
 	ip := "127.0.0.1"
@@ -116,6 +121,7 @@ func Status(ctx *gin.Context) {
 	queriesCount, _ := database.GetStormDB().Count(&database.QueryHistory{})
 	deletedMoviesCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.MovieType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
 	deletedShowsCount, _ := database.GetStormDB().Select(q.Eq("MediaType", library.ShowType), q.Eq("State", library.StateDeleted)).Count(&database.LibraryItem{})
+	outboxPending, outboxFailed := trakt.OutboxStats()
 
 	text = fmt.Sprintf(text,
 		ident.GetVersion(),
@@ -135,6 +141,9 @@ func Status(ctx *gin.Context) {
 		queriesCount,
 		deletedMoviesCount,
 		deletedShowsCount,
+
+		outboxPending,
+		outboxFailed,
 	)
 
 	xbmcHost.DialogText(title, string(text))
@@ -63,7 +63,11 @@ func MoviesIndex(ctx *gin.Context) {
 
 	items := xbmc.ListItems{
 		{Label: "LOCALIZE[30209]", Path: URLForXBMC("/movies/search"), Thumbnail: config.AddonResource("img", "search.png")},
+		{Label: "LOCALIZE[30729]", Path: URLForXBMC("/person/search"), Thumbnail: config.AddonResource("img", "search.png")},
+		{Label: "LOCALIZE[30730]", Path: URLForXBMC("/person/favorites"), Thumbnail: config.AddonResource("img", "movies.png")},
+		{Label: "LOCALIZE[30731]", Path: URLForXBMC("/person/releases/movies"), Thumbnail: config.AddonResource("img", "fresh.png")},
 		{Label: "LOCALIZE[30263]", Path: URLForXBMC("/movies/trakt/lists/"), Thumbnail: config.AddonResource("img", "trakt.png"), TraktAuth: true},
+		{Label: "LOCALIZE[30723]", Path: URLForXBMC("/library/subscriptions"), Thumbnail: config.AddonResource("img", "movies.png"), ContextMenu: [][]string{subscribeTMDBListAction()}},
 		{Label: "LOCALIZE[30254]", Path: URLForXBMC("/movies/trakt/watchlist"), Thumbnail: config.AddonResource("img", "trakt.png"), ContextMenu: [][]string{{"LOCALIZE[30252]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/library/movie/list/add/watchlist"))}}, TraktAuth: true},
 		{Label: "LOCALIZE[30257]", Path: URLForXBMC("/movies/trakt/collection"), Thumbnail: config.AddonResource("img", "trakt.png"), ContextMenu: [][]string{{"LOCALIZE[30252]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/library/movie/list/add/collection"))}}, TraktAuth: true},
 		{Label: "LOCALIZE[30290]", Path: URLForXBMC("/movies/trakt/calendars/"), Thumbnail: config.AddonResource("img", "most_anticipated.png"), TraktAuth: true},
@@ -272,6 +276,7 @@ func TopTraktLists(ctx *gin.Context) {
 			Thumbnail: config.AddonResource("img", "trakt.png"),
 			ContextMenu: [][]string{
 				menuItem,
+				subscribeTraktListAction(list.List),
 			},
 		}
 		items = append(items, item)
@@ -315,6 +320,7 @@ func MoviesTraktLists(ctx *gin.Context) {
 			Thumbnail: config.AddonResource("img", "trakt.png"),
 			ContextMenu: [][]string{
 				menuItem,
+				subscribeTraktListAction(list),
 			},
 		}
 		items = append(items, item)
@@ -415,6 +421,13 @@ func renderMovies(ctx *gin.Context, movies tmdb.Movies, page int, total int, que
 				{"LOCALIZE[30034]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/setviewmode/movies"))},
 			}
 			item.ContextMenu = append(libraryActions, item.ContextMenu...)
+			item.ContextMenu = append(item.ContextMenu, traktMovieActions(movie.ID, false)...)
+			item.ContextMenu = append(item.ContextMenu, collectionActions(movie)...)
+			if movie.Credits != nil {
+				if director := movie.Credits.GetDirector(); director != nil {
+					item.ContextMenu = append(item.ContextMenu, []string{fmt.Sprintf("LOCALIZE[30728];;%s", director.Name), fmt.Sprintf("Container.Update(%s)", URLForXBMC("/person/%d/movies/%s/%s", director.ID, tmdb.PersonSortDate, tmdb.DepartmentDirecting))})
+				}
+			}
 
 			if config.Get().Platform.Kodi < 17 {
 				item.ContextMenu = append(item.ContextMenu,
@@ -541,6 +554,39 @@ func IMDBTop250(ctx *gin.Context) {
 	renderMovies(ctx, movies, page, total, "", false)
 }
 
+// MovieCollection shows movies of TMDB collection, in order of release
+func MovieCollection(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	collectionID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	collection := tmdb.GetCollection(collectionID, config.Get().Language)
+	if collection == nil {
+		ctx.String(404, "")
+		return
+	}
+
+	parts := make([]*tmdb.Entity, 0, len(collection.Parts))
+	for _, p := range collection.Parts {
+		if p != nil {
+			parts = append(parts, p)
+		}
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		if parts[i].ReleaseDate == "" {
+			return false
+		} else if parts[j].ReleaseDate == "" {
+			return true
+		}
+		return parts[i].ReleaseDate < parts[j].ReleaseDate
+	})
+
+	ids := make([]int, 0, len(parts))
+	for _, p := range parts {
+		ids = append(ids, p.ID)
+	}
+	renderMovies(ctx, tmdb.GetMovies(ids, config.Get().Language), -1, len(ids), "", false)
+}
+
 // MoviesMostVoted ...
 func MoviesMostVoted(ctx *gin.Context) {
 	defer perf.ScopeTimer()()
@@ -732,3 +778,21 @@ func MovieLinks(action string, s *bittorrent.Service) gin.HandlerFunc {
 		}
 	}
 }
+
+// collectionActions returns context menu actions of TMDB collection, that has the movie
+func collectionActions(movie *tmdb.Movie) [][]string {
+	if movie.BelongsToCollection == nil || movie.BelongsToCollection.ID == 0 {
+		return nil
+	}
+
+	collectionID := movie.BelongsToCollection.ID
+	addAction := []string{"LOCALIZE[30725]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/library/movie/collection/add/%d", collectionID))}
+	if library.IsCollectionFollowed(collectionID) {
+		addAction = []string{"LOCALIZE[30718]", fmt.Sprintf("RunPlugin(%s)", URLQuery(URLForXBMC("/library/subscriptions/remove"), "id", library.CollectionSubscriptionID(collectionID)))}
+	}
+
+	return [][]string{
+		{fmt.Sprintf("LOCALIZE[30724];;%s", movie.BelongsToCollection.Name), fmt.Sprintf("Container.Update(%s)", URLForXBMC("/movies/collection/%d", collectionID))},
+		addAction,
+	}
+}
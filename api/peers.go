@@ -0,0 +1,23 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/bittorrent"
+)
+
+// PiecesTorrent returns pieces bitfield, availability and reader windows of the torrent
+func PiecesTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.JSON(200, torrent.PiecesHealth())
+	}
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/util"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Period, in which credits of favorite people are shown as new releases
+const personReleasesPeriod = 180 * 24 * time.Hour
+
+// PersonIndex shows movies and shows of the person, split by departments
+func PersonIndex(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	personID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	person := tmdb.GetPerson(personID, config.Get().Language)
+	credits := tmdb.GetPersonCredits(personID, config.Get().Language)
+	if person == nil || credits == nil {
+		ctx.String(404, "")
+		return
+	}
+
+	items := xbmc.ListItems{}
+	for _, media := range []string{"movies", "shows"} {
+		mediaType, label := "movie", "LOCALIZE[30214]"
+		if media == "shows" {
+			mediaType, label = "tv", "LOCALIZE[30215]"
+		}
+
+		total := len(credits.Filter(mediaType, "", ""))
+		if total == 0 {
+			continue
+		}
+
+		items = append(items, personCreditsItem(person, media, fmt.Sprintf("%s [COLOR gray](%d)[/COLOR]", label, total), ""))
+		for _, department := range credits.Departments(mediaType) {
+			count := len(credits.Filter(mediaType, department, ""))
+			items = append(items, personCreditsItem(person, media, fmt.Sprintf("%s > %s [COLOR gray](%d)[/COLOR]", label, department, count), department))
+		}
+	}
+
+	ctx.JSON(200, xbmc.NewView("", filterListItems(items)))
+}
+
+// PersonCredits shows movies or shows of the person, optionally in the department
+func PersonCredits(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	personID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	media := ctx.Params.ByName("media")
+	credits := tmdb.GetPersonCredits(personID, config.Get().Language)
+	if credits == nil {
+		ctx.String(404, "")
+		return
+	}
+
+	mediaType := "movie"
+	if media == "shows" {
+		mediaType = "tv"
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	ids := tmdb.PersonCreditIDs(credits.Filter(mediaType, ctx.Params.ByName("department"), ctx.Params.ByName("sort")))
+	renderPersonCredits(ctx, media, ids, page)
+}
+
+// SearchPeople ...
+func SearchPeople(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	query := ctx.Query("q")
+	keyboard := ctx.Query("keyboard")
+	historyType := "person"
+
+	if len(query) == 0 {
+		searchHistoryProcess(ctx, historyType, keyboard)
+		return
+	}
+
+	// Update query last use date to show it on the top
+	database.GetStorm().AddSearchHistory(historyType, query)
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	people, total := tmdb.SearchPeople(query, config.Get().Language, page)
+
+	items := xbmc.ListItems{}
+	for _, p := range people {
+		if p == nil {
+			continue
+		}
+		items = append(items, personItem(p.ID, p.Name, p.KnownForDepartment, p.ProfilePath))
+	}
+	if page*20 < total {
+		items = append(items, &xbmc.ListItem{
+			Label:      "LOCALIZE[30415];;" + strconv.Itoa(page+1),
+			Path:       URLQuery(URLForXBMC("/person/search"), "q", query, "page", strconv.Itoa(page+1)),
+			Thumbnail:  config.AddonResource("img", "nextpage.png"),
+			Properties: &xbmc.ListItemProperties{SpecialSort: "bottom"},
+		})
+	}
+
+	ctx.JSON(200, xbmc.NewView("", filterListItems(items)))
+}
+
+// FavoritePeople shows favorite people
+func FavoritePeople(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	people := database.GetStorm().GetFavoritePeople()
+	sort.Slice(people, func(i, j int) bool {
+		return people[i].Name < people[j].Name
+	})
+
+	items := xbmc.ListItems{
+		{Label: "LOCALIZE[30731] > LOCALIZE[30214]", Path: URLForXBMC("/person/releases/movies"), Thumbnail: config.AddonResource("img", "fresh.png")},
+		{Label: "LOCALIZE[30731] > LOCALIZE[30215]", Path: URLForXBMC("/person/releases/shows"), Thumbnail: config.AddonResource("img", "fresh.png")},
+	}
+	for _, p := range people {
+		items = append(items, personItem(p.ID, p.Name, p.Department, p.ProfilePath))
+	}
+
+	ctx.JSON(200, xbmc.NewView("", filterListItems(items)))
+}
+
+// FavoritePeopleReleases shows recently released movies or shows of favorite people, newest first
+func FavoritePeopleReleases(ctx *gin.Context) {
+	defer perf.ScopeTimer()()
+
+	media := ctx.Params.ByName("media")
+	mediaType := "movie"
+	if media == "shows" {
+		mediaType = "tv"
+	}
+
+	since := util.UTCBod().Add(-personReleasesPeriod)
+	releases := []*tmdb.PersonCredit{}
+	seen := map[int]bool{}
+	for _, p := range database.GetStorm().GetFavoritePeople() {
+		credits := tmdb.GetPersonCredits(p.ID, config.Get().Language)
+		if credits == nil {
+			continue
+		}
+
+		// Actors are followed by their roles, crew members by the department they were added with
+		department := p.Department
+		if department == "" {
+			department = tmdb.DepartmentActing
+		}
+		for _, c := range credits.Filter(mediaType, department, tmdb.PersonSortDate) {
+			if c.Date() == "" || seen[c.ID] {
+				continue
+			}
+
+			released, isExpired := util.AirDateWithExpireCheck(c.Date(), config.Get().ShowEpisodesOnReleaseDay)
+			if isExpired {
+				continue
+			} else if released.Before(since) {
+				break
+			}
+
+			seen[c.ID] = true
+			releases = append(releases, c)
+		}
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].Date() > releases[j].Date()
+	})
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	renderPersonCredits(ctx, media, tmdb.PersonCreditIDs(releases), page)
+}
+
+// AddFavoritePerson adds person to favorites, to follow new releases
+func AddFavoritePerson(ctx *gin.Context) {
+	personID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	person := tmdb.GetPerson(personID, config.Get().Language)
+	if person == nil {
+		ctx.String(404, "")
+		return
+	}
+
+	department := ctx.DefaultQuery("department", person.KnownForDepartment)
+	if err := database.GetStorm().SaveFavoritePerson(&database.FavoritePerson{
+		ID:          person.ID,
+		Name:        person.Name,
+		Department:  department,
+		ProfilePath: person.ProfilePath,
+		CreatedAt:   time.Now().UTC(),
+	}); err != nil {
+		log.Warningf("Could not add %s to favorite people: %s", person.Name, err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx); xbmcHost != nil {
+		xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30736];;%s", person.Name), config.AddonIcon())
+		xbmcHost.Refresh()
+	}
+	ctx.String(200, "")
+}
+
+// RemoveFavoritePerson removes person from favorites
+func RemoveFavoritePerson(ctx *gin.Context) {
+	personID, _ := strconv.Atoi(ctx.Params.ByName("id"))
+	if err := database.GetStorm().DeleteFavoritePerson(personID); err != nil {
+		log.Warningf("Could not remove person %d from favorite people: %s", personID, err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx); xbmcHost != nil {
+		xbmcHost.Refresh()
+	}
+	ctx.String(200, "")
+}
+
+// renderPersonCredits renders page of movies or shows by TMDB IDs
+func renderPersonCredits(ctx *gin.Context, media string, ids []int, page int) {
+	if page < 1 {
+		page = 1
+	}
+
+	total := len(ids)
+	perPage := config.Get().ResultsPerPage
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	if media == "shows" {
+		renderShows(ctx, tmdb.GetShows(ids[start:end], config.Get().Language), page, total, "", false)
+	} else {
+		renderMovies(ctx, tmdb.GetMovies(ids[start:end], config.Get().Language), page, total, "", false)
+	}
+}
+
+// personItem returns list item of the person, that leads to person page
+func personItem(id int, name, department, profilePath string) *xbmc.ListItem {
+	label := name
+	if department != "" {
+		label = fmt.Sprintf("%s [COLOR gray](%s)[/COLOR]", name, department)
+	}
+
+	return &xbmc.ListItem{
+		Label:       label,
+		Path:        URLForXBMC("/person/%d", id),
+		Thumbnail:   tmdb.ImageURL(profilePath, "w500"),
+		ContextMenu: [][]string{favoritePersonAction(id, department)},
+	}
+}
+
+// personCreditsItem returns list item of person credits, sorted by popularity, with action to sort by date
+func personCreditsItem(person *tmdb.Person, media, label, department string) *xbmc.ListItem {
+	link := func(sortBy string) string {
+		if department == "" {
+			return URLForXBMC("/person/%d/%s/%s", person.ID, media, sortBy)
+		}
+		return URLForXBMC("/person/%d/%s/%s/%s", person.ID, media, sortBy, url.PathEscape(department))
+	}
+
+	return &xbmc.ListItem{
+		Label:     label,
+		Path:      link(tmdb.PersonSortPopularity),
+		Thumbnail: tmdb.ImageURL(person.ProfilePath, "w500"),
+		Info:      &xbmc.ListItemInfo{Plot: person.Biography},
+		ContextMenu: [][]string{
+			{"LOCALIZE[30734]", fmt.Sprintf("Container.Update(%s)", link(tmdb.PersonSortDate))},
+			favoritePersonAction(person.ID, department),
+		},
+	}
+}
+
+// favoritePersonAction returns context menu action to add or remove the person from favorites
+func favoritePersonAction(id int, department string) []string {
+	if database.GetStorm().GetFavoritePerson(id) != nil {
+		return []string{"LOCALIZE[30733]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/person/%d/favorite/remove", id))}
+	}
+
+	link := URLForXBMC("/person/%d/favorite/add", id)
+	if department != "" {
+		link = URLQuery(link, "department", department)
+	}
+	return []string{"LOCALIZE[30732]", fmt.Sprintf("RunPlugin(%s)", link)}
+}
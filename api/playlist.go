@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/bittorrent"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// PlaylistTorrent plays all video files of the torrent as Kodi playlist,
+// episodes of season packs are ordered and labeled with TMDB metadata.
+func PlaylistTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to play torrent with index %s", torrentID))
+			return
+		}
+
+		showID := strToInt(ctx.Query("show"), 0)
+		if showID == 0 && torrent.DBItem != nil && (torrent.DBItem.Type == showType || torrent.DBItem.Type == episodeType) {
+			showID = torrent.DBItem.ShowID
+		}
+
+		items := torrent.BuildPlaylist(showID)
+		if ctx.Query("unwatched") == "true" {
+			unwatched := make([]*bittorrent.PlaylistItem, 0, len(items))
+			for _, i := range items {
+				if !i.Watched {
+					unwatched = append(unwatched, i)
+				}
+			}
+			items = unwatched
+		}
+		if len(items) == 0 {
+			ctx.Error(fmt.Errorf("No files to play in torrent %s", torrentID))
+			return
+		}
+
+		log.Infof("Playing %d files of %s as a playlist", len(items), torrent.Name())
+		torrent.SetPlaylist(items)
+
+		if xbmcHost != nil {
+			xbmcHost.VideoPlaylistClear()
+			for _, i := range items {
+				xbmcHost.VideoPlaylistAdd(torrent.GetPlaylistItemURL(i))
+			}
+			xbmcHost.VideoPlaylistPlay(0)
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// PlaylistTorrentList returns playback queue of the torrent
+func PlaylistTorrentList(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.JSON(200, torrent.GetPlaylist())
+	}
+}
+
+// PlaylistTorrentSkip switches Kodi player to the next queued item
+func PlaylistTorrentSkip(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+		if xbmcHost == nil {
+			return
+		}
+
+		if playerID := xbmcHost.PlayerGetActive(); playerID >= 0 {
+			xbmcHost.PlayerGoTo(playerID, "next")
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// PlaylistTorrentMove moves queued item to another position, both in the queue and in Kodi playlist
+func PlaylistTorrentMove(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		from, errFrom := strconv.Atoi(ctx.Query("from"))
+		to, errTo := strconv.Atoi(ctx.Query("to"))
+		if errFrom != nil || errTo != nil {
+			ctx.String(400, "Positions are not defined")
+			return
+		}
+
+		if err := torrent.MovePlaylistItem(from, to); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		if xbmcHost != nil {
+			item := torrent.GetPlaylist()[to]
+			xbmcHost.VideoPlaylistRemove(from)
+			xbmcHost.VideoPlaylistInsert(to, torrent.GetPlaylistItemURL(item))
+		}
+
+		ctx.JSON(200, torrent.GetPlaylist())
+	}
+}
+
+// PlaylistTorrentRemove removes item from the queue and from Kodi playlist
+func PlaylistTorrentRemove(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		position, err := strconv.Atoi(ctx.Query("position"))
+		if err != nil {
+			ctx.String(400, "Position is not defined")
+			return
+		}
+
+		if err := torrent.RemovePlaylistItem(position); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		if xbmcHost != nil {
+			xbmcHost.VideoPlaylistRemove(position)
+		}
+
+		ctx.JSON(200, torrent.GetPlaylist())
+	}
+}
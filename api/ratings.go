@@ -0,0 +1,176 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library"
+	"github.com/elgatito/elementum/trakt"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// traktMovieActions returns context menu actions for rating and checking in a movie
+func traktMovieActions(tmdbID int, isRecommendation bool) [][]string {
+	if config.Get().TraktToken == "" {
+		return nil
+	}
+
+	actions := [][]string{
+		{"LOCALIZE[30699]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/movie/%d/rate", tmdbID))},
+		{"LOCALIZE[30701]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/movie/%d/checkin", tmdbID))},
+	}
+	if isRecommendation {
+		actions = append(actions, []string{"LOCALIZE[30700]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/movie/%d/recommendation/hide", tmdbID))})
+	}
+	return actions
+}
+
+// traktShowActions returns context menu actions for rating a show
+func traktShowActions(tmdbID int, isRecommendation bool) [][]string {
+	if config.Get().TraktToken == "" {
+		return nil
+	}
+
+	actions := [][]string{
+		{"LOCALIZE[30699]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/show/%d/rate", tmdbID))},
+	}
+	if isRecommendation {
+		actions = append(actions, []string{"LOCALIZE[30700]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/show/%d/recommendation/hide", tmdbID))})
+	}
+	return actions
+}
+
+// traktEpisodeActions returns context menu actions for rating and checking in an episode
+func traktEpisodeActions(showID, season, episode int) [][]string {
+	if config.Get().TraktToken == "" {
+		return nil
+	}
+
+	return [][]string{
+		{"LOCALIZE[30699]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/show/%d/season/%d/episode/%d/rate", showID, season, episode))},
+		{"LOCALIZE[30701]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/show/%d/season/%d/episode/%d/checkin", showID, season, episode))},
+	}
+}
+
+func ratedItemFromParams(ctx *gin.Context, media string) *trakt.RatedItem {
+	item := &trakt.RatedItem{MediaType: media}
+	if media == movieType {
+		item.Movie, _ = strconv.Atoi(ctx.Params.ByName("tmdbId"))
+		return item
+	}
+
+	item.Show, _ = strconv.Atoi(ctx.Params.ByName("showId"))
+	item.Season, _ = strconv.Atoi(ctx.Params.ByName("season"))
+	item.Episode, _ = strconv.Atoi(ctx.Params.ByName("episode"))
+	return item
+}
+
+// RateTraktItem sets Trakt rating from "rating" parameter, or asks user to choose it
+func RateTraktItem(media string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+		ctx.String(200, "")
+
+		rating, _ := strconv.Atoi(ctx.Query("rating"))
+		if rating == 0 {
+			if rating = trakt.SelectRating(xbmcHost, "LOCALIZE[30699]"); rating == 0 {
+				return
+			}
+		}
+
+		resp, err := trakt.RateItem(ratedItemFromParams(ctx, media), rating)
+		if err != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		} else if resp.Status() != 201 {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("Failed with %d status code", resp.Status()), config.AddonIcon())
+		} else {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30747];;%d", rating), config.AddonIcon())
+		}
+	}
+}
+
+// RemoveTraktRating removes Trakt rating of the item
+func RemoveTraktRating(media string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+		ctx.String(200, "")
+
+		resp, err := trakt.RemoveRating(ratedItemFromParams(ctx, media))
+		if err != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		} else if resp.Status() != 200 {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("Failed with %d status code", resp.Status()), config.AddonIcon())
+		} else {
+			xbmcHost.Notify("Elementum", "LOCALIZE[30748]", config.AddonIcon())
+		}
+	}
+}
+
+// CheckinTraktItem checks in movie or episode on Trakt
+func CheckinTraktItem(media string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+		ctx.String(200, "")
+
+		resp, err := trakt.Checkin(ratedItemFromParams(ctx, media))
+		if err != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		} else if resp.Status() != 201 {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("Failed with %d status code", resp.Status()), config.AddonIcon())
+		} else {
+			xbmcHost.Notify("Elementum", "LOCALIZE[30749]", config.AddonIcon())
+		}
+	}
+}
+
+// CancelTraktCheckin removes active Trakt check-in
+func CancelTraktCheckin(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+	ctx.String(200, "")
+
+	resp, err := trakt.CancelCheckin()
+	if err != nil {
+		xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+	} else if resp.Status() != 204 {
+		xbmcHost.Notify("Elementum", fmt.Sprintf("Failed with %d status code", resp.Status()), config.AddonIcon())
+	} else {
+		xbmcHost.Notify("Elementum", "LOCALIZE[30750]", config.AddonIcon())
+	}
+}
+
+// HideTraktRecommendation hides movie or show from Trakt recommendations
+func HideTraktRecommendation(itemType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		tmdbID := ctx.Params.ByName("tmdbId")
+		if itemType == "shows" {
+			tmdbID = ctx.Params.ByName("showId")
+		}
+
+		resp, err := trakt.HideRecommendation(itemType, tmdbID)
+		if err != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		} else if resp.Status() != 204 {
+			xbmcHost.Notify("Elementum", fmt.Sprintf("Failed with %d status code", resp.Status()), config.AddonIcon())
+		} else {
+			xbmcHost.Notify("Elementum", "LOCALIZE[30751]", config.AddonIcon())
+			if ctx != nil {
+				ctx.Abort()
+			}
+			library.ClearPageCache(xbmcHost)
+		}
+	}
+}
@@ -68,6 +68,7 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 
 	r.Any("/debug/all", bittorrent.DebugAll(s))
 	r.Any("/debug/bundle", bittorrent.DebugBundle(s))
+	r.Any("/debug/trackers", bittorrent.DebugTrackers(s))
 
 	r.Any("/reload", Reload(s))
 	r.Any("/notification", Notification(s))
@@ -107,10 +108,13 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 	{
 		torrents.GET("/", ListTorrents(s))
 		torrents.Any("/add", AddTorrent(s))
+		torrents.Any("/metadata", TorrentMetadata(s))
 		torrents.GET("/pause", PauseSession(s))
 		torrents.GET("/resume", ResumeSession(s))
 		torrents.GET("/move/:torrentId", MoveTorrent(s))
 		torrents.GET("/pause/:torrentId", PauseTorrent(s))
+		torrents.GET("/queue/:torrentId/:direction", QueueTorrent(s))
+		torrents.GET("/priority/:torrentId/:priority", PriorityTorrent(s))
 		torrents.GET("/resume/:torrentId", ResumeTorrent(s))
 		torrents.GET("/delete/:torrentId", RemoveTorrent(s))
 		torrents.GET("/downloadall/:torrentId", DownloadAllTorrent(s))
@@ -118,6 +122,26 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		torrents.GET("/selectfile/:torrentId", SelectFileTorrent(s, true))
 		torrents.GET("/downloadfile/:torrentId", SelectFileTorrent(s, false))
 		torrents.GET("/assign/:torrentId/:tmdbId", AssignTorrent(s))
+		torrents.GET("/playlist/:torrentId", PlaylistTorrent(s))
+		torrents.GET("/playlist/:torrentId/list", PlaylistTorrentList(s))
+		torrents.GET("/playlist/:torrentId/skip", PlaylistTorrentSkip(s))
+		torrents.GET("/playlist/:torrentId/move", PlaylistTorrentMove(s))
+		torrents.GET("/playlist/:torrentId/remove", PlaylistTorrentRemove(s))
+		torrents.GET("/tags", ListTags(s))
+		torrents.GET("/tags/:torrentId", TagsTorrent(s))
+		torrents.GET("/tagged/:tag/:action", TaggedTorrentsAction(s))
+		torrents.Any("/bulk/:action", BulkTorrents(s))
+		torrents.GET("/recheck/:torrentId", RecheckTorrent(s))
+		torrents.GET("/reannounce/:torrentId", ReannounceTorrent(s))
+		torrents.GET("/trackers/:torrentId", TrackersTorrent(s))
+		torrents.Any("/trackers/:torrentId/add", AddTrackersTorrent(s))
+		torrents.Any("/trackers/:torrentId/set", SetTrackersTorrent(s))
+		torrents.GET("/trackers/:torrentId/remove", RemoveTrackerTorrent(s))
+		torrents.GET("/pieces/:torrentId", PiecesTorrent(s))
+		torrents.GET("/category/:torrentId", CategoryTorrent(s))
+		torrents.GET("/categories", ListCategories(s))
+		torrents.Any("/categories/save", SaveCategory(s))
+		torrents.GET("/categories/delete/:name", DeleteCategory(s))
 
 		// Web UI json
 		torrents.GET("/list", ListTorrentsWeb(s))
@@ -139,6 +163,7 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		movies.GET("/top", TopRatedMovies)
 		movies.GET("/imdb250", IMDBTop250)
 		movies.GET("/mostvoted", MoviesMostVoted)
+		movies.GET("/collection/:id", MovieCollection)
 		movies.GET("/genres", MovieGenres)
 		movies.GET("/languages", MovieLanguages)
 		movies.GET("/countries", MovieCountries)
@@ -176,6 +201,18 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 			}
 		}
 	}
+	person := r.Group("/person")
+	{
+		person.GET("/search", SearchPeople)
+		person.GET("/favorites", FavoritePeople)
+		person.GET("/releases/:media", FavoritePeopleReleases)
+		person.GET("/:id", PersonIndex)
+		person.GET("/:id/favorite/add", AddFavoritePerson)
+		person.GET("/:id/favorite/remove", RemoveFavoritePerson)
+		person.GET("/:id/:media/:sort", PersonCredits)
+		person.GET("/:id/:media/:sort/:department", PersonCredits)
+	}
+
 	movie := r.Group("/movie")
 	{
 		movie.GET("/:tmdbId/infolabels", InfoLabelsMovie(s))
@@ -197,6 +234,10 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		movie.GET("/:tmdbId/watched/*ident", ToggleWatched("movie", true))
 		movie.GET("/:tmdbId/unwatched", ToggleWatched("movie", false))
 		movie.GET("/:tmdbId/unwatched/*ident", ToggleWatched("movie", false))
+		movie.GET("/:tmdbId/rate", RateTraktItem("movie"))
+		movie.GET("/:tmdbId/rate/remove", RemoveTraktRating("movie"))
+		movie.GET("/:tmdbId/checkin", CheckinTraktItem("movie"))
+		movie.GET("/:tmdbId/recommendation/hide", HideTraktRecommendation("movies"))
 	}
 
 	shows := r.Group("/shows")
@@ -296,6 +337,14 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		show.GET("/:showId/watchlist/remove", RemoveShowFromWatchlist)
 		show.GET("/:showId/collection/add", AddShowToCollection)
 		show.GET("/:showId/collection/remove", RemoveShowFromCollection)
+		show.GET("/:showId/rate", RateTraktItem("show"))
+		show.GET("/:showId/rate/remove", RemoveTraktRating("show"))
+		show.GET("/:showId/recommendation/hide", HideTraktRecommendation("shows"))
+		show.GET("/:showId/season/:season/rate", RateTraktItem("season"))
+		show.GET("/:showId/season/:season/rate/remove", RemoveTraktRating("season"))
+		show.GET("/:showId/season/:season/episode/:episode/rate", RateTraktItem("episode"))
+		show.GET("/:showId/season/:season/episode/:episode/rate/remove", RemoveTraktRating("episode"))
+		show.GET("/:showId/season/:season/episode/:episode/checkin", CheckinTraktItem("episode"))
 	}
 	// TODO
 	// episode := r.Group("/episode")
@@ -308,6 +357,7 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		library.GET("/movie/add/:tmdbId", AddMovie)
 		library.GET("/movie/remove/:tmdbId", RemoveMovie)
 		library.GET("/movie/list/add/:listId", AddMoviesList)
+		library.GET("/movie/collection/add/:id", AddMovieCollection)
 		library.GET("/movie/play/:tmdbId", PlayMovie(s))
 		library.GET("/show/add/:tmdbId", AddShow)
 		library.GET("/show/remove/:tmdbId", RemoveShow)
@@ -316,6 +366,17 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 
 		library.GET("/update", UpdateLibrary)
 		library.GET("/unduplicate", UnduplicateLibrary)
+		library.GET("/import", ImportHistory)
+		library.GET("/sync/plan", SyncPlan)
+		library.GET("/sync/plan/apply", ApplySyncPlan)
+		library.GET("/sync/plan/discard", DiscardSyncPlan)
+		library.GET("/sync/journal", SyncJournal)
+		library.GET("/sync/undo", UndoSync)
+		library.GET("/subscriptions", Subscriptions)
+		library.GET("/subscriptions/items", SubscriptionItems)
+		library.GET("/subscriptions/add", SubscribeList)
+		library.GET("/subscriptions/sync", SyncSubscription)
+		library.GET("/subscriptions/remove", UnsubscribeList)
 
 		// DEPRECATED
 		library.GET("/play/movie/:tmdbId", PlayMovie(s))
@@ -367,6 +428,12 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 		trakt.GET("/deauthorize", DeauthorizeTrakt)
 		trakt.GET("/select_list/:action/:media", SelectTraktUserList)
 		trakt.GET("/update", UpdateTrakt)
+		trakt.GET("/outbox", TraktOutbox)
+		trakt.GET("/outbox/retry", TraktOutboxRetry)
+		trakt.GET("/outbox/clear", TraktOutboxClear)
+		trakt.GET("/playback/conflicts", TraktPlaybackConflicts)
+		trakt.GET("/playback/conflicts/clear", TraktPlaybackConflictsClear)
+		trakt.GET("/checkin/cancel", CancelTraktCheckin)
 	}
 
 	r.GET("/setviewmode/:content_type", SetViewMode)
@@ -387,6 +454,30 @@ func Routes(s *bittorrent.Service, shutdown func(code int)) *gin.Engine {
 
 	r.GET("/versions", Versions(s))
 
+	animeGroup := r.Group("/anime")
+	{
+		animeGroup.GET("/reload", ReloadAnimeMapping)
+		animeGroup.GET("/:showId/override", AnimeOverride)
+		animeGroup.GET("/:showId/override/set", SetAnimeOverride)
+		animeGroup.GET("/:showId/override/delete", DeleteAnimeOverride)
+		animeGroup.GET("/:showId/season/:season/episode/:episode", AnimeEpisodeNumbers)
+		animeGroup.GET("/:showId/absolute/:number", AnimeAbsoluteEpisode)
+	}
+
+	simkl := r.Group("/simkl")
+	{
+		simkl.GET("/authorize", AuthorizeSyncBackend("Simkl"))
+		simkl.GET("/deauthorize", DeauthorizeSyncBackend("Simkl"))
+	}
+
+	anilist := r.Group("/anilist")
+	{
+		anilist.GET("/authorize", AuthorizeSyncBackend("AniList"))
+		anilist.GET("/deauthorize", DeauthorizeSyncBackend("AniList"))
+	}
+
+	r.GET("/sync/refresh", RefreshSyncBackends)
+
 	cmd := r.Group("/cmd")
 	{
 		cmd.GET("/clear_cache_key/:key", ClearCache)
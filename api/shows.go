@@ -31,9 +31,13 @@ func TVIndex(ctx *gin.Context) {
 
 	items := xbmc.ListItems{
 		{Label: "LOCALIZE[30209]", Path: URLForXBMC("/shows/search"), Thumbnail: config.AddonResource("img", "search.png")},
+		{Label: "LOCALIZE[30729]", Path: URLForXBMC("/person/search"), Thumbnail: config.AddonResource("img", "search.png")},
+		{Label: "LOCALIZE[30730]", Path: URLForXBMC("/person/favorites"), Thumbnail: config.AddonResource("img", "tv.png")},
+		{Label: "LOCALIZE[30731]", Path: URLForXBMC("/person/releases/shows"), Thumbnail: config.AddonResource("img", "fresh.png")},
 
 		{Label: "Trakt > LOCALIZE[30360]", Path: URLForXBMC("/shows/trakt/progress"), Thumbnail: config.AddonResource("img", "trakt.png"), TraktAuth: true},
 		{Label: "Trakt > LOCALIZE[30263]", Path: URLForXBMC("/shows/trakt/lists/"), Thumbnail: config.AddonResource("img", "trakt.png"), TraktAuth: true},
+		{Label: "LOCALIZE[30723]", Path: URLForXBMC("/library/subscriptions"), Thumbnail: config.AddonResource("img", "tv.png"), ContextMenu: [][]string{subscribeTMDBListAction()}},
 		{Label: "Trakt > LOCALIZE[30254]", Path: URLForXBMC("/shows/trakt/watchlist"), Thumbnail: config.AddonResource("img", "trakt.png"), ContextMenu: [][]string{{"LOCALIZE[30252]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/library/show/list/add/watchlist"))}}, TraktAuth: true},
 		{Label: "Trakt > LOCALIZE[30257]", Path: URLForXBMC("/shows/trakt/collection"), Thumbnail: config.AddonResource("img", "trakt.png"), ContextMenu: [][]string{{"LOCALIZE[30252]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/library/show/list/add/collection"))}}, TraktAuth: true},
 		{Label: "Trakt > LOCALIZE[30290]", Path: URLForXBMC("/shows/trakt/calendars/"), Thumbnail: config.AddonResource("img", "most_anticipated.png"), TraktAuth: true},
@@ -250,6 +254,7 @@ func TVTraktLists(ctx *gin.Context) {
 			Thumbnail: config.AddonResource("img", "trakt.png"),
 			ContextMenu: [][]string{
 				menuItem,
+				subscribeTraktListAction(list),
 			},
 		}
 		items = append(items, item)
@@ -338,6 +343,7 @@ func renderShows(ctx *gin.Context, shows tmdb.Shows, page int, total int, query
 				{"LOCALIZE[30035]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/setviewmode/tvshows"))},
 			}
 			item.ContextMenu = append(libraryActions, item.ContextMenu...)
+			item.ContextMenu = append(item.ContextMenu, traktShowActions(show.ID, false)...)
 
 			if config.Get().Platform.Kodi < 17 {
 				item.ContextMenu = append(item.ContextMenu,
@@ -635,6 +641,7 @@ func ShowEpisodes(ctx *gin.Context) {
 				{"LOCALIZE[30037]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/setviewmode/episodes"))},
 			}
 			item.ContextMenu = append(libraryActions, item.ContextMenu...)
+			item.ContextMenu = append(item.ContextMenu, traktEpisodeActions(show.ID, seasonNumber, item.Info.Episode)...)
 
 			if config.Get().Platform.Kodi < 17 {
 				item.ContextMenu = append(item.ContextMenu,
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/trakt"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+var subscriptionPolicies = []string{library.RemovalKeep, library.RemovalRemove, library.RemovalConfirm}
+
+// Subscriptions shows lists, attached to the library
+func Subscriptions(ctx *gin.Context) {
+	items := xbmc.ListItems{}
+	for _, s := range library.GetSubscriptions() {
+		label := fmt.Sprintf("%s [COLOR gray](%d / %d)[/COLOR]", s.Name, len(s.Movies), len(s.Shows))
+		if s.LastError != "" {
+			label = fmt.Sprintf("%s [COLOR red]%s[/COLOR]", label, s.LastError)
+		}
+
+		thumbnail := config.AddonResource("img", "trakt.png")
+		if s.Source != library.SubscriptionTrakt {
+			thumbnail = config.AddonResource("img", "movies.png")
+		}
+
+		media := "movies"
+		if len(s.Movies) == 0 && len(s.Shows) > 0 {
+			media = "shows"
+		}
+
+		item := &xbmc.ListItem{
+			Label:     label,
+			Path:      URLQuery(URLForXBMC("/library/subscriptions/items"), "id", s.ID, "media", media),
+			Thumbnail: thumbnail,
+			ContextMenu: [][]string{
+				{"LOCALIZE[30717]", fmt.Sprintf("RunPlugin(%s)", URLQuery(URLForXBMC("/library/subscriptions/sync"), "id", s.ID))},
+				{"LOCALIZE[30718]", fmt.Sprintf("RunPlugin(%s)", URLQuery(URLForXBMC("/library/subscriptions/remove"), "id", s.ID))},
+				subscribeTMDBListAction(),
+			},
+		}
+		if media == "movies" && len(s.Shows) > 0 {
+			item.ContextMenu = append(item.ContextMenu, []string{"LOCALIZE[30721]", fmt.Sprintf("Container.Update(%s)", URLQuery(URLForXBMC("/library/subscriptions/items"), "id", s.ID, "media", "shows"))})
+		}
+		items = append(items, item)
+	}
+
+	ctx.JSON(200, xbmc.NewView("", filterListItems(items)))
+}
+
+// SubscriptionItems shows movies or shows of the list, attached to the library
+func SubscriptionItems(ctx *gin.Context) {
+	s := library.GetSubscription(ctx.Query("id"))
+	if s == nil {
+		ctx.String(404, "")
+		return
+	}
+
+	if ctx.Query("media") == "shows" {
+		renderShows(ctx, tmdb.GetShows(s.Shows, config.Get().Language), 0, len(s.Shows), "", false)
+	} else {
+		renderMovies(ctx, tmdb.GetMovies(s.Movies, config.Get().Language), 0, len(s.Movies), "", false)
+	}
+}
+
+// SubscribeList attaches Trakt or TMDB list to the library,
+// removal policy and set mode are asked, if not passed.
+func SubscribeList(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	source := ctx.DefaultQuery("source", library.SubscriptionTrakt)
+	listID := ctx.Query("list")
+	name := ctx.Query("name")
+	policy := ctx.Query("policy")
+	asSet := ctx.Query("as_set") == trueType
+
+	if xbmcHost != nil {
+		if source == library.SubscriptionTMDBList && listID == "" {
+			if listID = tmdbListID(xbmcHost.Keyboard("", "LOCALIZE[30760]")); listID == "" {
+				ctx.String(200, "")
+				return
+			}
+			if list := tmdb.GetUserList(listID, config.Get().Language); list != nil && name == "" {
+				name = list.Name
+			}
+		}
+		if policy == "" {
+			choice := xbmcHost.ListDialog("LOCALIZE[30711]", "LOCALIZE[30712]", "LOCALIZE[30713]", "LOCALIZE[30714]")
+			if choice < 0 || choice >= len(subscriptionPolicies) {
+				ctx.String(200, "")
+				return
+			}
+			policy = subscriptionPolicies[choice]
+		}
+		if _, ok := ctx.GetQuery("as_set"); !ok {
+			asSet = xbmcHost.DialogConfirmNonTimed("Elementum", fmt.Sprintf("LOCALIZE[30715];;%s", name))
+		}
+	}
+
+	s, err := library.Subscribe(source, ctx.Query("user"), listID, name, asSet, policy)
+	if err != nil {
+		log.Warningf("Could not subscribe to list: %s", err)
+		if xbmcHost != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		}
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost != nil {
+		xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30716];;%s", s.Name), config.AddonIcon())
+	}
+	ctx.JSON(200, s)
+}
+
+// SyncSubscription syncs the list, attached to the library, or all of them
+func SyncSubscription(ctx *gin.Context) {
+	id := ctx.Query("id")
+	if id == "" {
+		go library.SyncSubscriptions()
+		ctx.String(200, "")
+		return
+	}
+
+	added, removed, err := library.SyncSubscription(id)
+	if err != nil {
+		log.Warningf("Could not sync list %s: %s", id, err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx); xbmcHost != nil {
+		xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30719];;%d;;%d", added, removed), config.AddonIcon())
+	}
+	ctx.String(200, "")
+}
+
+// UnsubscribeList detaches the list from the library
+func UnsubscribeList(ctx *gin.Context) {
+	xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+	id := ctx.Query("id")
+	if xbmcHost != nil && !xbmcHost.DialogConfirmNonTimed("Elementum", "LOCALIZE[30722]") {
+		ctx.String(200, "")
+		return
+	}
+
+	if err := library.Unsubscribe(id); err != nil {
+		log.Warningf("Could not unsubscribe from list %s: %s", id, err)
+		ctx.String(200, err.Error())
+		return
+	}
+
+	if xbmcHost != nil {
+		xbmcHost.Refresh()
+	}
+	ctx.String(200, "")
+}
+
+// subscribeTraktListAction returns context menu action to attach Trakt list to the library
+func subscribeTraktListAction(list *trakt.List) []string {
+	user := ""
+	if list.User != nil {
+		user = list.User.Ids.Slug
+	}
+
+	link := URLQuery(URLForXBMC("/library/subscriptions/add"),
+		"source", library.SubscriptionTrakt,
+		"user", user,
+		"list", strconv.Itoa(list.IDs.Trakt),
+		"name", list.Name)
+	return []string{"LOCALIZE[30710]", fmt.Sprintf("RunPlugin(%s)", link)}
+}
+
+// subscribeTMDBListAction returns context menu action to attach TMDB list to the library, list ID is asked
+func subscribeTMDBListAction() []string {
+	link := URLQuery(URLForXBMC("/library/subscriptions/add"), "source", library.SubscriptionTMDBList)
+	return []string{"LOCALIZE[30759]", fmt.Sprintf("RunPlugin(%s)", link)}
+}
+
+// tmdbListID returns TMDB list ID from the ID or list link, like "https://www.themoviedb.org/list/8136-name"
+func tmdbListID(input string) string {
+	input = strings.TrimSpace(input)
+	if idx := strings.Index(input, "/list/"); idx != -1 {
+		input = input[idx+len("/list/"):]
+	}
+
+	end := 0
+	for end < len(input) && input[end] >= '0' && input[end] <= '9' {
+		end++
+	}
+	return input[:end]
+}
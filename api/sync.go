@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// AuthorizeSyncBackend starts authorization of the watch state sync backend
+func AuthorizeSyncBackend(name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		b := library.GetSyncBackend(name)
+		if b == nil {
+			ctx.String(404, "")
+			return
+		}
+
+		if err := b.Authorize(); err != nil && xbmcHost != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		}
+		ctx.String(200, "")
+	}
+}
+
+// DeauthorizeSyncBackend removes authorization of the watch state sync backend
+func DeauthorizeSyncBackend(name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		b := library.GetSyncBackend(name)
+		if b == nil {
+			ctx.String(404, "")
+			return
+		}
+
+		if err := b.Deauthorize(); err != nil && xbmcHost != nil {
+			xbmcHost.Notify("Elementum", err.Error(), config.AddonIcon())
+		}
+		ctx.String(200, "")
+	}
+}
+
+// RefreshSyncBackends pulls watch state from all enabled sync backends
+func RefreshSyncBackends(ctx *gin.Context) {
+	go library.RefreshSyncBackends()
+	ctx.String(200, "")
+}
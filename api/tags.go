@@ -0,0 +1,201 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/bittorrent"
+	"github.com/elgatito/elementum/bittorrent/tags"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// TagsResult is a response with tags and category of the torrent
+type TagsResult struct {
+	ID       string   `json:"id"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+}
+
+// ListTags returns tags, used by all torrents
+func ListTags(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(200, s.GetAllTags())
+	}
+}
+
+// TagsTorrent changes tags of the torrent with "set", "add" or "remove" comma-separated lists,
+// without parameters it asks for tags with Kodi keyboard.
+func TagsTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		set, isSet := ctx.GetQuery("set")
+		add, isAdd := ctx.GetQuery("add")
+		remove, isRemove := ctx.GetQuery("remove")
+
+		if !isSet && !isAdd && !isRemove && xbmcHost != nil {
+			current := strings.Join(torrent.Tags(), ", ")
+			set = xbmcHost.Keyboard(current, "LOCALIZE[30692]")
+			isSet = set != current
+		}
+
+		switch {
+		case isSet:
+			err = torrent.SetTags(tags.Parse(set))
+		case isAdd:
+			err = torrent.AddTags(tags.Parse(add))
+		case isRemove:
+			err = torrent.RemoveTags(tags.Parse(remove))
+		}
+		if err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		if xbmcHost != nil {
+			xbmcHost.Refresh()
+		}
+		ctx.JSON(200, TagsResult{ID: torrent.InfoHash(), Category: torrent.Category(), Tags: torrent.Tags()})
+	}
+}
+
+// CategoryTorrent sets category of the torrent from "name" parameter, empty name removes category,
+// without parameter it asks to select a category in Kodi.
+func CategoryTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		name, ok := ctx.GetQuery("name")
+		if !ok {
+			if xbmcHost == nil {
+				ctx.String(400, "Category is not defined")
+				return
+			}
+
+			categories := database.GetStorm().GetTorrentCategories()
+			choices := []string{"LOCALIZE[30694]"}
+			for _, c := range categories {
+				choices = append(choices, c.Name)
+			}
+
+			choice := xbmcHost.ListDialog("LOCALIZE[30693]", choices...)
+			if choice < 0 {
+				return
+			} else if choice > 0 {
+				name = categories[choice-1].Name
+			}
+		}
+
+		if err := torrent.SetCategory(name); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		if xbmcHost != nil {
+			xbmcHost.Refresh()
+		}
+		ctx.JSON(200, TagsResult{ID: torrent.InfoHash(), Category: torrent.Category(), Tags: torrent.Tags()})
+	}
+}
+
+// ListCategories returns all torrent categories
+func ListCategories(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(200, database.GetStorm().GetTorrentCategories())
+	}
+}
+
+// SaveCategory creates category or updates its paths
+func SaveCategory(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := strings.TrimSpace(ctx.Request.FormValue("name"))
+		if name == "" {
+			ctx.String(400, "Category name is empty")
+			return
+		}
+
+		c := &database.TorrentCategory{
+			Name:          name,
+			DownloadPath:  strings.TrimSpace(ctx.Request.FormValue("download_path")),
+			CompletedPath: strings.TrimSpace(ctx.Request.FormValue("completed_path")),
+		}
+		if err := database.GetStorm().SaveTorrentCategory(c); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		ctx.JSON(200, c)
+	}
+}
+
+// DeleteCategory removes category and unassigns it from torrents
+func DeleteCategory(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := ctx.Params.ByName("name")
+		if database.GetStorm().GetTorrentCategory(name) == nil {
+			ctx.String(404, "Category not found")
+			return
+		}
+
+		for _, t := range s.FilterTorrents(name, "") {
+			if err := t.SetCategory(""); err != nil {
+				log.Warningf("Could not remove category from %s: %s", t.Name(), err)
+			}
+		}
+
+		if err := database.GetStorm().DeleteTorrentCategory(name); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// TaggedTorrentsAction runs pause, resume, delete or move action on all torrents with a tag
+func TaggedTorrentsAction(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		action := ctx.Params.ByName("action")
+		if action == "delete" {
+			action = "remove"
+		}
+		if _, ok := bulkActions[action]; !ok && action != "remove" {
+			ctx.String(400, "Unknown action")
+			return
+		}
+
+		torrents := s.FilterTorrents(ctx.Query("category"), ctx.Params.ByName("tag"))
+		results := runBulkAction(s, action, ctx.DefaultQuery("files", "false") == "true", torrents)
+
+		if xbmcHost != nil && len(torrents) > 0 {
+			xbmcHost.Refresh()
+		}
+		ctx.JSON(200, results)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/op/go-logging"
 
 	"github.com/elgatito/elementum/bittorrent"
+	"github.com/elgatito/elementum/bittorrent/tags"
 	"github.com/elgatito/elementum/config"
 	"github.com/elgatito/elementum/database"
 	"github.com/elgatito/elementum/util/ident"
@@ -29,27 +30,32 @@ var (
 
 // TorrentsWeb ...
 type TorrentsWeb struct {
-	ID            string  `json:"id"`
-	Name          string  `json:"name"`
-	AddedTime     int64   `json:"added_time"`
-	Size          string  `json:"size"`
-	SizeBytes     int64   `json:"size_bytes"`
-	Status        string  `json:"status"`
-	StatusCode    int     `json:"status_code"`
-	Progress      float64 `json:"progress"`
-	Ratio         float64 `json:"ratio"`
-	TimeRatio     float64 `json:"time_ratio"`
-	SeedingTime   string  `json:"seeding_time"`
-	SeedTime      float64 `json:"seed_time"`
-	SeedTimeLimit int     `json:"seed_time_limit"`
-	DownloadRate  float64 `json:"download_rate"`
-	UploadRate    float64 `json:"upload_rate"`
-	TotalDownload float64 `json:"total_download"`
-	TotalUpload   float64 `json:"total_upload"`
-	Seeders       int     `json:"seeders"`
-	SeedersTotal  int     `json:"seeders_total"`
-	Peers         int     `json:"peers"`
-	PeersTotal    int     `json:"peers_total"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	AddedTime     int64    `json:"added_time"`
+	Size          string   `json:"size"`
+	SizeBytes     int64    `json:"size_bytes"`
+	Status        string   `json:"status"`
+	StatusCode    int      `json:"status_code"`
+	Progress      float64  `json:"progress"`
+	Ratio         float64  `json:"ratio"`
+	TimeRatio     float64  `json:"time_ratio"`
+	SeedingTime   string   `json:"seeding_time"`
+	SeedTime      float64  `json:"seed_time"`
+	SeedTimeLimit int      `json:"seed_time_limit"`
+	DownloadRate  float64  `json:"download_rate"`
+	UploadRate    float64  `json:"upload_rate"`
+	TotalDownload float64  `json:"total_download"`
+	TotalUpload   float64  `json:"total_upload"`
+	Seeders       int      `json:"seeders"`
+	SeedersTotal  int      `json:"seeders_total"`
+	Peers         int      `json:"peers"`
+	PeersTotal    int      `json:"peers_total"`
+	QueuePosition int      `json:"queue_position"`
+	Priority      int      `json:"priority"`
+	Queued        bool     `json:"queued"`
+	Category      string   `json:"category"`
+	Tags          []string `json:"tags"`
 }
 
 // AddToTorrentsMap ...
@@ -221,13 +227,14 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 
 		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
 
-		items := make(xbmc.ListItems, 0, len(s.GetTorrents()))
-		if len(s.GetTorrents()) == 0 {
+		torrents := s.FilterTorrents(ctx.Query("category"), ctx.Query("tag"))
+		items := make(xbmc.ListItems, 0, len(torrents))
+		if len(torrents) == 0 {
 			ctx.JSON(200, xbmc.NewView("", items))
 			return
 		}
 
-		for _, t := range s.GetTorrents() {
+		for _, t := range torrents {
 			if t == nil || t.Closer.IsSet() || s.Closer.IsSet() {
 				continue
 			}
@@ -291,6 +298,13 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 				item.ContextMenu = append(item.ContextMenu, []string{"LOCALIZE[30573]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/selectfile/%s", t.InfoHash()))})
 				item.ContextMenu = append(item.ContextMenu, []string{"LOCALIZE[30612]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/downloadfile/%s", t.InfoHash()))})
 
+				item.ContextMenu = append(item.ContextMenu,
+					[]string{"LOCALIZE[30695]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/recheck/%s", t.InfoHash()))},
+					[]string{"LOCALIZE[30689]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/queue/%s/%s", t.InfoHash(), bittorrent.QueueMoveUp))},
+					[]string{"LOCALIZE[30690]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/queue/%s/%s", t.InfoHash(), bittorrent.QueueMoveDown))},
+					[]string{"LOCALIZE[30691]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/queue/%s/%s", t.InfoHash(), bittorrent.QueueMoveTop))},
+				)
+
 				if t.HasAvailableFiles() {
 					item.ContextMenu = append(item.ContextMenu, []string{"LOCALIZE[30531]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/downloadall/%s", t.InfoHash()))})
 				} else {
@@ -298,6 +312,13 @@ func ListTorrents(s *bittorrent.Service) gin.HandlerFunc {
 				}
 			}
 
+			item.ContextMenu = append(item.ContextMenu,
+				[]string{"LOCALIZE[30696]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/reannounce/%s", t.InfoHash()))},
+				[]string{"LOCALIZE[30688]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/playlist/%s", t.InfoHash()))},
+				[]string{"LOCALIZE[30692]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/tags/%s", t.InfoHash()))},
+				[]string{"LOCALIZE[30693]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/torrents/category/%s", t.InfoHash()))},
+			)
+
 			item.IsPlayable = true
 			items = append(items, &item)
 		}
@@ -317,15 +338,16 @@ func ListTorrentsWeb(s *bittorrent.Service) gin.HandlerFunc {
 
 		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
 
-		items := make([]*TorrentsWeb, 0, len(s.GetTorrents()))
-		if len(s.GetTorrents()) == 0 {
+		torrents := s.FilterTorrents(ctx.Query("category"), ctx.Query("tag"))
+		items := make([]*TorrentsWeb, 0, len(torrents))
+		if len(torrents) == 0 {
 			ctx.JSON(200, items)
 			return
 		}
 
 		seedTimeLimit := config.Get().SeedTimeLimit
 
-		for _, t := range s.GetTorrents() {
+		for _, t := range torrents {
 			th := t.GetHandle()
 			if th == nil || !th.IsValid() || !t.HasMetadata() || t.Closer.IsSet() || s.Closer.IsSet() {
 				continue
@@ -390,6 +412,11 @@ func ListTorrentsWeb(s *bittorrent.Service) gin.HandlerFunc {
 				SeedersTotal:  seedersTotal,
 				Peers:         peers,
 				PeersTotal:    peersTotal,
+				QueuePosition: s.GetQueue().Position(t),
+				Priority:      t.QueuePriority,
+				Queued:        t.IsQueued,
+				Category:      t.Category(),
+				Tags:          t.Tags(),
 			}
 			items = append(items, ti)
 		}
@@ -426,6 +453,34 @@ func ResumeSession(s *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// TorrentMetadata fetches files list of the magnet from "uri" parameter without starting a download,
+// "timeout" in seconds overrides magnet resolve timeout.
+func TorrentMetadata(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		uri := ctx.Request.FormValue("uri")
+		if uri == "" {
+			ctx.String(400, "Missing torrent URI")
+			return
+		}
+
+		timeout := config.Get().MagnetResolveTimeout
+		if t, err := strconv.Atoi(ctx.Query("timeout")); err == nil && t > 0 {
+			timeout = t
+		}
+
+		m, err := s.FetchMetadata(uri, time.Duration(timeout)*time.Second)
+		if err != nil {
+			torrentsLog.Warningf("Could not fetch metadata for %s: %s", uri, err)
+			ctx.String(404, err.Error())
+			return
+		}
+
+		ctx.JSON(200, m)
+	}
+}
+
 // AddTorrent ...
 func AddTorrent(s *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -476,6 +531,18 @@ func AddTorrent(s *bittorrent.Service) gin.HandlerFunc {
 
 		// Create initial BTItem entry
 		database.GetStorm().UpdateBTItem(t.InfoHash(), 0, "", []string{}, t.Name(), 0, 0, 0)
+		t.FetchDBItem()
+
+		if list := tags.Parse(ctx.Request.FormValue("tags")); len(list) > 0 {
+			if err := t.AddTags(list); err != nil {
+				torrentsLog.Warningf("Could not set tags: %s", err)
+			}
+		}
+		if category := ctx.Request.FormValue("category"); category != "" {
+			if err := t.SetCategory(category); err != nil {
+				torrentsLog.Warningf("Could not set category %s: %s", category, err)
+			}
+		}
 
 		torrentsLog.Infof("Downloading %s", uri)
 		if allFiles == "1" {
@@ -534,7 +601,61 @@ func MoveTorrent(s *bittorrent.Service) gin.HandlerFunc {
 		}
 
 		torrentsLog.Infof("Marking %s to be moved...", torrent.Name())
-		s.MarkedToMove = torrent.InfoHash()
+		s.MarkToMove(torrent)
+
+		xbmcHost.Refresh()
+		ctx.String(200, "")
+	}
+}
+
+// QueueTorrent moves torrent in the download queue
+func QueueTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to move torrent with index %s", torrentID))
+			return
+		}
+
+		if err := s.GetQueue().Move(torrent, ctx.Params.ByName("direction")); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		xbmcHost.Refresh()
+		ctx.String(200, "")
+	}
+}
+
+// PriorityTorrent sets torrent priority in the download queue
+func PriorityTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to set priority for torrent with index %s", torrentID))
+			return
+		}
+
+		priority, err := strconv.Atoi(ctx.Params.ByName("priority"))
+		if err != nil {
+			ctx.String(400, "Priority should be a number")
+			return
+		}
+
+		if err := s.GetQueue().SetPriority(torrent, priority); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
 
 		xbmcHost.Refresh()
 		ctx.String(200, "")
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/bittorrent"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// RecheckTorrent forces hash check of torrent data
+func RecheckTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		xbmcHost, _ := xbmc.GetXBMCHostWithContext(ctx)
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to recheck torrent with index %s", torrentID))
+			return
+		}
+		if torrent.IsMemoryStorage() {
+			ctx.String(400, "Memory storage can not be rechecked")
+			return
+		}
+
+		torrent.ForceRecheck()
+
+		if xbmcHost != nil {
+			xbmcHost.Refresh()
+		}
+		ctx.String(200, "")
+	}
+}
+
+// ReannounceTorrent announces torrent to all trackers and DHT,
+// or to a single tracker, if "tracker" index is set.
+func ReannounceTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer perf.ScopeTimer()()
+
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to reannounce torrent with index %s", torrentID))
+			return
+		}
+
+		if index := ctx.Query("tracker"); index != "" {
+			i, err := strconv.Atoi(index)
+			if err == nil {
+				err = torrent.ReannounceTracker(i)
+			}
+			if err != nil {
+				ctx.String(400, err.Error())
+				return
+			}
+		} else {
+			torrent.ForceReannounce()
+			if !config.Get().DisableDHT {
+				torrent.ForceDHTAnnounce()
+			}
+		}
+
+		ctx.String(200, "")
+	}
+}
+
+// TrackersTorrent returns trackers of the torrent with their status
+func TrackersTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		ctx.JSON(200, torrent.Trackers())
+	}
+}
+
+// AddTrackersTorrent adds trackers from "url" parameters, each can contain several urls, separated by new lines
+func AddTrackersTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		urls := trackerURLsFromRequest(ctx)
+		if len(urls) == 0 {
+			ctx.String(400, "Trackers are not defined")
+			return
+		}
+
+		torrent.AddTrackers(urls)
+		ctx.JSON(200, torrent.Trackers())
+	}
+}
+
+// SetTrackersTorrent replaces all trackers with urls from "url" parameters,
+// all trackers are removed only with explicit "clear" parameter.
+func SetTrackersTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		urls := trackerURLsFromRequest(ctx)
+		if len(urls) == 0 && ctx.Query("clear") != "true" {
+			ctx.String(400, "Trackers are not defined, use clear=true to remove all trackers")
+			return
+		}
+
+		torrent.SetTrackers(urls)
+		ctx.JSON(200, torrent.Trackers())
+	}
+}
+
+// RemoveTrackerTorrent removes tracker by "index" parameter
+func RemoveTrackerTorrent(s *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		torrentID := ctx.Params.ByName("torrentId")
+		torrent, err := GetTorrentFromParam(s, torrentID)
+		if err != nil {
+			ctx.Error(fmt.Errorf("Unable to find torrent with index %s", torrentID))
+			return
+		}
+
+		index, err := strconv.Atoi(ctx.Query("index"))
+		if err != nil {
+			ctx.String(400, "Index is not defined")
+			return
+		}
+		if err := torrent.RemoveTracker(index); err != nil {
+			ctx.String(400, err.Error())
+			return
+		}
+
+		ctx.JSON(200, torrent.Trackers())
+	}
+}
+
+func trackerURLsFromRequest(ctx *gin.Context) []string {
+	ctx.Request.ParseForm()
+
+	ret := []string{}
+	for _, v := range ctx.Request.Form["url"] {
+		for _, u := range strings.Split(v, "\n") {
+			if u = strings.TrimSpace(u); u != "" {
+				ret = append(ret, u)
+			}
+		}
+	}
+	return ret
+}
@@ -142,6 +142,34 @@ func DeauthorizeTrakt(ctx *gin.Context) {
 	}
 }
 
+// TraktOutbox returns Trakt requests, waiting to be sent
+func TraktOutbox(ctx *gin.Context) {
+	ctx.JSON(200, trakt.GetOutbox())
+}
+
+// TraktOutboxRetry returns failed Trakt requests to the queue
+func TraktOutboxRetry(ctx *gin.Context) {
+	trakt.RetryOutbox()
+	ctx.JSON(200, trakt.GetOutbox())
+}
+
+// TraktOutboxClear removes failed Trakt requests, or all requests with "all=true"
+func TraktOutboxClear(ctx *gin.Context) {
+	trakt.ClearOutbox(ctx.DefaultQuery("all", falseType) != falseType)
+	ctx.JSON(200, trakt.GetOutbox())
+}
+
+// TraktPlaybackConflicts returns recent resume point conflicts between Kodi and Trakt
+func TraktPlaybackConflicts(ctx *gin.Context) {
+	ctx.JSON(200, library.PlaybackConflicts())
+}
+
+// TraktPlaybackConflictsClear removes the conflict log
+func TraktPlaybackConflictsClear(ctx *gin.Context) {
+	library.ClearPlaybackConflicts()
+	ctx.JSON(200, library.PlaybackConflicts())
+}
+
 //
 // Main lists
 //
@@ -467,6 +495,7 @@ func renderTraktMovies(ctx *gin.Context, movies []*trakt.Movies, total int, page
 		}
 	}
 
+	isRecommendations := strings.HasSuffix(ctx.Request.URL.Path, "/recommendations")
 	items := make(xbmc.ListItems, len(movies))
 	wg := sync.WaitGroup{}
 	for idx := 0; idx < len(movies); idx++ {
@@ -530,6 +559,7 @@ func renderTraktMovies(ctx *gin.Context, movies []*trakt.Movies, total int, page
 				{"LOCALIZE[30034]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/setviewmode/movies"))},
 			}
 			item.ContextMenu = append(libraryActions, item.ContextMenu...)
+			item.ContextMenu = append(item.ContextMenu, traktMovieActions(movieListing.Movie.IDs.TMDB, isRecommendations)...)
 
 			if config.Get().Platform.Kodi < 17 {
 				item.ContextMenu = append(item.ContextMenu,
@@ -796,6 +826,7 @@ func renderTraktShows(ctx *gin.Context, shows []*trakt.Shows, total int, page in
 		}
 	}
 
+	isRecommendations := strings.HasSuffix(ctx.Request.URL.Path, "/recommendations")
 	items := make(xbmc.ListItems, 0, len(shows)+hasNextPage)
 
 	for _, showListing := range shows {
@@ -839,6 +870,7 @@ func renderTraktShows(ctx *gin.Context, shows []*trakt.Shows, total int, page in
 			{"LOCALIZE[30035]", fmt.Sprintf("RunPlugin(%s)", URLForXBMC("/setviewmode/tvshows"))},
 		}
 		item.ContextMenu = append(libraryActions, item.ContextMenu...)
+		item.ContextMenu = append(item.ContextMenu, traktShowActions(showListing.Show.IDs.TMDB, isRecommendations)...)
 
 		if config.Get().Platform.Kodi < 17 {
 			item.ContextMenu = append(item.ContextMenu,
@@ -1827,9 +1859,20 @@ func ToggleWatched(media string, setWatched bool) gin.HandlerFunc {
 			}
 		}
 
-		if config.Get().TraktToken != "" && watched != nil {
-			log.Debugf("Set Trakt watched to %t for: %#v", setWatched, watched)
-			go trakt.SetWatched(watched)
+		if watched != nil {
+			item := &library.SyncItem{
+				MediaType: watched.MediaType,
+				TMDB:      watched.Show,
+				Season:    watched.Season,
+				Episode:   watched.Episode,
+				Watched:   watched.Watched,
+			}
+			if watched.MediaType == movieType {
+				item.TMDB = watched.Movie
+			}
+
+			log.Debugf("Set sync backends watched to %t for: %#v", setWatched, item)
+			go library.SetWatched([]*library.SyncItem{item})
 		}
 
 		if !foundInLibrary {
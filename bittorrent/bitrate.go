@@ -0,0 +1,256 @@
+package bittorrent
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/tmdb"
+)
+
+const (
+	// bitrateSafetyMargin is applied to estimated bitrate to be safe with VBR streams.
+	bitrateSafetyMargin = 1.25
+	// bitrateMinBufferSeconds defines how many seconds of playback should be in the pre-buffer.
+	bitrateMinBufferSeconds = 20
+	// bitrateReadaheadSeconds defines how many seconds of playback readers should look ahead.
+	bitrateReadaheadSeconds = 120
+	// bitrateWarmupDuration is a time we wait before trusting measured download rate.
+	bitrateWarmupDuration = 5 * time.Second
+	// bitrateRateSmoothing is a weight of the latest measurement in the average download rate.
+	bitrateRateSmoothing = 0.2
+
+	maxAdaptiveBufferSize = 400 * 1024 * 1024
+	maxAdaptiveReadahead  = 400 * 1024 * 1024
+)
+
+// SetStreamDuration sets expected playback duration for the file that is going to be streamed.
+// Duration can come from TMDB runtime or from the player, when Kodi knows the real duration.
+func (t *Torrent) SetStreamDuration(duration time.Duration) {
+	t.muBitrate.Lock()
+	defer t.muBitrate.Unlock()
+
+	if duration <= 0 || t.streamDuration == duration {
+		return
+	}
+
+	log.Infof("Setting stream duration to %s", duration)
+	t.streamDuration = duration
+}
+
+// GetStreamDuration returns expected playback duration of the streamed file.
+func (t *Torrent) GetStreamDuration() time.Duration {
+	t.muBitrate.RLock()
+	defer t.muBitrate.RUnlock()
+
+	return t.streamDuration
+}
+
+// GetStreamBitrate returns estimated bitrate of a file, in bytes per second,
+// or 0 if we don't know the duration of the stream.
+func (t *Torrent) GetStreamBitrate(file *File) int64 {
+	duration := t.GetStreamDuration()
+	if file == nil || file.Size <= 0 || duration <= 0 {
+		return 0
+	}
+
+	return int64(float64(file.Size) / duration.Seconds())
+}
+
+// GetAverageDownloadRate returns smoothed download rate, in bytes per second.
+func (t *Torrent) GetAverageDownloadRate() int64 {
+	t.muBitrate.RLock()
+	defer t.muBitrate.RUnlock()
+
+	return int64(t.averageDownloadRate)
+}
+
+func (t *Torrent) updateAverageDownloadRate() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	rate, _ := t.GetSpeeds()
+
+	t.muBitrate.Lock()
+	defer t.muBitrate.Unlock()
+
+	if t.averageDownloadRate == 0 {
+		t.averageDownloadRate = float64(rate)
+	} else {
+		t.averageDownloadRate = bitrateRateSmoothing*float64(rate) + (1-bitrateRateSmoothing)*t.averageDownloadRate
+	}
+}
+
+// getAdaptiveBufferSize returns pre-buffer size that should be enough to start the playback
+// without stalls, considering file bitrate and measured download rate.
+// If bitrate is not known, or the feature is disabled - initial size is returned.
+func (t *Torrent) getAdaptiveBufferSize(file *File, size int64) int64 {
+	bitrate := float64(t.GetStreamBitrate(file)) * bitrateSafetyMargin
+	if !config.Get().AutoAdjustBufferSize || bitrate <= 0 {
+		return size
+	}
+
+	initial := size
+	if minSize := int64(bitrate * bitrateMinBufferSeconds); minSize > size {
+		size = minSize
+	}
+
+	// If we download slower than we play, then the player would catch up with the download
+	// at some point. To avoid that we need to have the difference downloaded before playback starts.
+	rate := float64(t.GetAverageDownloadRate())
+	if rate > 0 && time.Since(t.bufferStartedAt) > bitrateWarmupDuration && rate < bitrate {
+		if deficit := int64(float64(file.Size) * (1 - rate/bitrate)); deficit > size {
+			size = deficit
+		}
+	}
+
+	maxSize := int64(maxAdaptiveBufferSize)
+	if t.IsMemoryStorage() && t.MemorySize > 0 {
+		maxSize = t.MemorySize / 2
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	if size < initial {
+		size = initial
+	}
+	if file.Size > 0 && size > file.Size {
+		size = file.Size
+	}
+
+	return size
+}
+
+// getAdaptiveReadahead returns readahead for file storage readers, to have enough
+// pieces in the download queue to keep up with the bitrate of the stream.
+func (t *Torrent) getAdaptiveReadahead(file *File, size int64) int64 {
+	bitrate := float64(t.GetStreamBitrate(file)) * bitrateSafetyMargin
+	if !config.Get().AutoAdjustBufferSize || bitrate <= 0 {
+		return size
+	}
+
+	ra := bitrate * bitrateReadaheadSeconds
+	// Look further ahead when we download slower than we play
+	if rate := float64(t.GetAverageDownloadRate()); rate > 0 && rate < bitrate {
+		ra = ra * bitrate / rate
+	}
+
+	ret := int64(ra)
+	if ret < size {
+		ret = size
+	}
+	if ret > maxAdaptiveReadahead {
+		ret = maxAdaptiveReadahead
+	}
+
+	// Round up to pieces, to avoid changing readahead on each rate fluctuation
+	if t.pieceLength > 0 {
+		ret = (ret/t.pieceLength + 1) * t.pieceLength
+	}
+
+	return ret
+}
+
+// adjustBuffer extends the pre-buffer, while buffering, if measured download rate
+// is too low to play the file without stalls.
+func (t *Torrent) adjustBuffer() {
+	t.muBuffer.RLock()
+	file := t.bufferFile
+	preEnd := t.bufferPreEnd
+	t.muBuffer.RUnlock()
+
+	if file == nil || !t.IsBuffering || t.Closer.IsSet() || t.pieceLength <= 0 {
+		return
+	}
+
+	size := t.getAdaptiveBufferSize(file, t.Service.GetBufferSize())
+	_, end, _, _ := t.getBufferSize(file.Offset, 0, size)
+	if end <= preEnd {
+		return
+	}
+
+	log.Infof("Download rate %s/s is too low for bitrate %s/s, extending buffer to %s",
+		humanize.Bytes(uint64(t.GetAverageDownloadRate())),
+		humanize.Bytes(uint64(t.GetStreamBitrate(file))),
+		humanize.Bytes(uint64(size)))
+
+	if t.IsMemoryStorage() && size+int64(config.Get().EndBufferSize) > t.MemorySize {
+		t.AdjustMemorySize(size + int64(config.Get().EndBufferSize) + t.pieceLength)
+	}
+
+	t.extendBuffer(preEnd+1, end)
+
+	t.muBuffer.Lock()
+	if t.bufferFile == file && end > t.bufferPreEnd {
+		t.bufferPreEnd = end
+	}
+	t.muBuffer.Unlock()
+}
+
+// extendBuffer adds pieces range to the buffer, while buffering is in progress.
+func (t *Torrent) extendBuffer(start, end int) {
+	t.muBuffer.Lock()
+	defer t.muBuffer.Unlock()
+
+	t.muDemandPieces.Lock()
+	defer t.muDemandPieces.Unlock()
+
+	for piece := start; piece <= end; piece++ {
+		if _, ok := t.BufferPiecesProgress[piece]; ok {
+			continue
+		}
+
+		t.BufferPiecesProgress[piece] = 0
+		t.BufferPiecesLength += t.pieceLength
+		t.BufferLength += t.pieceLength
+
+		if t.bufferIsStartup {
+			t.th.PiecePriority(piece, 7)
+		} else {
+			t.demandPieces.AddInt(piece)
+			t.th.PiecePriority(piece, 3)
+		}
+		if !t.IsMemoryStorage() {
+			t.th.SetPieceDeadline(piece, 0, 0)
+		}
+	}
+}
+
+// GetBufferETA returns estimated time until the buffer is complete,
+// or -1 if it can't be estimated yet.
+func (t *Torrent) GetBufferETA() time.Duration {
+	rate := t.GetAverageDownloadRate()
+	if !t.IsBuffering || rate <= 0 || t.BufferLength <= 0 {
+		return -1
+	}
+
+	left := float64(t.BufferLength) * (1 - t.BufferProgress/100)
+	if left <= 0 {
+		return 0
+	}
+
+	return time.Duration(left/float64(rate)) * time.Second
+}
+
+// expectedDuration returns playback duration for the chosen item, taken from TMDB runtime.
+func (btp *Player) expectedDuration() time.Duration {
+	runtime := 0
+
+	if btp.p.ContentType == movieType && btp.p.TMDBId != 0 {
+		if movie := tmdb.GetMovie(btp.p.TMDBId, config.Get().Language); movie != nil {
+			runtime = movie.Runtime
+		}
+	} else if btp.p.ShowID != 0 {
+		if show := tmdb.GetShow(btp.p.ShowID, config.Get().Language); show != nil && len(show.EpisodeRunTime) > 0 {
+			sum := 0
+			for _, r := range show.EpisodeRunTime {
+				sum += r
+			}
+			runtime = sum / len(show.EpisodeRunTime)
+		}
+	}
+
+	return time.Duration(runtime) * time.Minute
+}
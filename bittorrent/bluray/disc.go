@@ -0,0 +1,32 @@
+package bluray
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	// StreamDir contains stream files of a disc
+	StreamDir = "BDMV/STREAM/"
+	// PlaylistDir contains MPLS playlists of a disc
+	PlaylistDir = "BDMV/PLAYLIST/"
+)
+
+// DiscDir returns disc directory for a file inside BDMV structure.
+// Directory is empty for a disc, stored at the torrent root.
+func DiscDir(p string) (string, bool) {
+	p = strings.Replace(p, "\\", "/", -1)
+	for _, dir := range []string{StreamDir, PlaylistDir} {
+		if strings.HasPrefix(p, dir) {
+			return "", true
+		} else if idx := strings.Index(p, "/"+dir); idx >= 0 {
+			return p[:idx], true
+		}
+	}
+	return "", false
+}
+
+// DiscPath returns path of a file inside the disc directory
+func DiscPath(dir, p string) string {
+	return path.Join(dir, p)
+}
@@ -0,0 +1,44 @@
+package bluray
+
+import "testing"
+
+func TestDiscDir(t *testing.T) {
+	tests := []struct {
+		path string
+		dir  string
+		ok   bool
+	}{
+		{path: "Movie.2020.COMPLETE.BLURAY/BDMV/STREAM/00001.m2ts", dir: "Movie.2020.COMPLETE.BLURAY", ok: true},
+		{path: "Movie/Disc 1/BDMV/PLAYLIST/00800.mpls", dir: "Movie/Disc 1", ok: true},
+		{path: "Movie\\BDMV\\STREAM\\00001.m2ts", dir: "Movie", ok: true},
+		{path: "BDMV/STREAM/00001.m2ts", dir: "", ok: true},
+		{path: "BDMV/PLAYLIST/00000.mpls", dir: "", ok: true},
+		{path: "Movie/BDMV/index.bdmv", ok: false},
+		{path: "Movie/XBDMV/STREAM/00001.m2ts", ok: false},
+		{path: "Movie/Movie.2020.1080p.mkv", ok: false},
+	}
+
+	for _, tt := range tests {
+		dir, ok := DiscDir(tt.path)
+		if dir != tt.dir || ok != tt.ok {
+			t.Errorf("DiscDir(%q) = %q, %v, want %q, %v", tt.path, dir, ok, tt.dir, tt.ok)
+		}
+	}
+}
+
+func TestDiscPath(t *testing.T) {
+	tests := []struct {
+		dir  string
+		path string
+		want string
+	}{
+		{dir: "Movie", path: PlaylistDir + "00800.m2ts", want: "Movie/BDMV/PLAYLIST/00800.m2ts"},
+		{dir: "", path: PlaylistDir + "00800.m2ts", want: "BDMV/PLAYLIST/00800.m2ts"},
+	}
+
+	for _, tt := range tests {
+		if got := DiscPath(tt.dir, tt.path); got != tt.want {
+			t.Errorf("DiscPath(%q, %q) = %q, want %q", tt.dir, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package bluray
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// mplsClockRate is a clock of MPLS timestamps
+const mplsClockRate = 45000
+
+const (
+	markTypeEntry = 1
+)
+
+var (
+	// ErrInvalidPlaylist is returned when data does not look like MPLS playlist
+	ErrInvalidPlaylist = errors.New("Invalid MPLS playlist")
+)
+
+// Playlist describes Blu-ray movie playlist
+type Playlist struct {
+	Name     string
+	Clips    []*Clip
+	Chapters int
+}
+
+// Clip is a reference to a stream file, played in the playlist
+type Clip struct {
+	Name    string // Stream file name without extension, like 00001
+	InTime  uint32
+	OutTime uint32
+}
+
+// Duration returns clip duration
+func (c *Clip) Duration() time.Duration {
+	if c.OutTime <= c.InTime {
+		return 0
+	}
+	return time.Duration(c.OutTime-c.InTime) * time.Second / mplsClockRate
+}
+
+// Duration returns playlist duration
+func (p *Playlist) Duration() (ret time.Duration) {
+	for _, c := range p.Clips {
+		ret += c.Duration()
+	}
+	return
+}
+
+// HasRepeatedClips checks whether playlist plays the same clip more than once,
+// which is used in obfuscated discs to create fake long playlists.
+func (p *Playlist) HasRepeatedClips() bool {
+	seen := map[string]bool{}
+	for _, c := range p.Clips {
+		if seen[c.Name] {
+			return true
+		}
+		seen[c.Name] = true
+	}
+	return false
+}
+
+// ParsePlaylist parses MPLS file contents
+func ParsePlaylist(name string, data []byte) (*Playlist, error) {
+	if len(data) < 20 || string(data[0:4]) != "MPLS" {
+		return nil, ErrInvalidPlaylist
+	}
+
+	playlistStart := int(binary.BigEndian.Uint32(data[8:]))
+	marksStart := int(binary.BigEndian.Uint32(data[12:]))
+
+	p := &Playlist{Name: name}
+
+	// PlayList: length(4) reserved(2) number_of_PlayItems(2) number_of_SubPaths(2)
+	if playlistStart+10 > len(data) {
+		return nil, ErrInvalidPlaylist
+	}
+	items := int(binary.BigEndian.Uint16(data[playlistStart+6:]))
+	pos := playlistStart + 10
+
+	for i := 0; i < items; i++ {
+		// PlayItem: length(2) clip_name(5) codec(4) flags(2) stc_id(1) in_time(4) out_time(4)
+		if pos+22 > len(data) {
+			return nil, ErrInvalidPlaylist
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+
+		p.Clips = append(p.Clips, &Clip{
+			Name:    string(data[pos+2 : pos+7]),
+			InTime:  binary.BigEndian.Uint32(data[pos+14:]),
+			OutTime: binary.BigEndian.Uint32(data[pos+18:]),
+		})
+
+		pos += 2 + length
+	}
+
+	// PlayListMark: length(4) number_of_marks(2), each mark is 14 bytes
+	if marksStart > 0 && marksStart+6 <= len(data) {
+		marks := int(binary.BigEndian.Uint16(data[marksStart+4:]))
+		for i := 0; i < marks; i++ {
+			mark := marksStart + 6 + i*14
+			if mark+14 > len(data) {
+				break
+			}
+			if data[mark+1] == markTypeEntry {
+				p.Chapters++
+			}
+		}
+	}
+
+	if len(p.Clips) == 0 {
+		return nil, ErrInvalidPlaylist
+	}
+
+	return p, nil
+}
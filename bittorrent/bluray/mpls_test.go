@@ -0,0 +1,114 @@
+package bluray
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+type testClip struct {
+	name    string
+	in, out uint32
+}
+
+// buildPlaylist builds MPLS file with play items and entry marks
+func buildPlaylist(clips []testClip, chapters int) []byte {
+	data := make([]byte, 20)
+	copy(data, "MPLS0200")
+
+	playlist := make([]byte, 10)
+	binary.BigEndian.PutUint16(playlist[6:], uint16(len(clips)))
+	for _, c := range clips {
+		item := make([]byte, 22)
+		binary.BigEndian.PutUint16(item, 20)
+		copy(item[2:], c.name)
+		copy(item[7:], "M2TS")
+		binary.BigEndian.PutUint32(item[14:], c.in)
+		binary.BigEndian.PutUint32(item[18:], c.out)
+		playlist = append(playlist, item...)
+	}
+
+	binary.BigEndian.PutUint32(data[8:], uint32(len(data)))
+	data = append(data, playlist...)
+
+	marks := make([]byte, 6)
+	binary.BigEndian.PutUint16(marks[4:], uint16(chapters+1))
+	for i := 0; i < chapters; i++ {
+		mark := make([]byte, 14)
+		mark[1] = markTypeEntry
+		marks = append(marks, mark...)
+	}
+	// Link point marks are not chapters
+	marks = append(marks, make([]byte, 14)...)
+	marks[len(marks)-13] = 2
+
+	binary.BigEndian.PutUint32(data[12:], uint32(len(data)))
+	return append(data, marks...)
+}
+
+func TestParsePlaylist(t *testing.T) {
+	minute := uint32(60 * mplsClockRate)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		err      error
+		clips    []string
+		chapters int
+		duration time.Duration
+		repeated bool
+	}{
+		{
+			name:     "main title",
+			data:     buildPlaylist([]testClip{{"00001", 0, 50 * minute}, {"00002", minute, 61 * minute}}, 12),
+			clips:    []string{"00001", "00002"},
+			chapters: 12,
+			duration: 110 * time.Minute,
+		},
+		{
+			name:     "obfuscated playlist",
+			data:     buildPlaylist([]testClip{{"00010", 0, minute}, {"00011", 0, minute}, {"00010", 0, minute}}, 0),
+			clips:    []string{"00010", "00011", "00010"},
+			duration: 3 * time.Minute,
+			repeated: true,
+		},
+		{
+			name:  "reversed times",
+			data:  buildPlaylist([]testClip{{"00003", minute, 0}}, 1),
+			clips: []string{"00003"}, chapters: 1,
+		},
+		{name: "no clips", data: buildPlaylist(nil, 0), err: ErrInvalidPlaylist},
+		{name: "not mpls", data: []byte("MOBJ0200............"), err: ErrInvalidPlaylist},
+		{name: "truncated", data: buildPlaylist([]testClip{{"00001", 0, minute}}, 0)[:30], err: ErrInvalidPlaylist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePlaylist("00800", tt.data)
+			if err != tt.err {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			if len(p.Clips) != len(tt.clips) {
+				t.Fatalf("clips = %d, want %d", len(p.Clips), len(tt.clips))
+			}
+			for i, c := range p.Clips {
+				if c.Name != tt.clips[i] {
+					t.Errorf("clip %d = %q, want %q", i, c.Name, tt.clips[i])
+				}
+			}
+			if p.Chapters != tt.chapters {
+				t.Errorf("chapters = %d, want %d", p.Chapters, tt.chapters)
+			}
+			if p.Duration() != tt.duration {
+				t.Errorf("duration = %s, want %s", p.Duration(), tt.duration)
+			}
+			if p.HasRepeatedClips() != tt.repeated {
+				t.Errorf("repeated clips = %v, want %v", p.HasRepeatedClips(), tt.repeated)
+			}
+		})
+	}
+}
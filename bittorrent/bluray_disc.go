@@ -0,0 +1,174 @@
+package bittorrent
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elgatito/elementum/bittorrent/bluray"
+)
+
+const (
+	// blurayPlaylistTimeout limits the time we wait for all playlists of a disc
+	blurayPlaylistTimeout = 1 * time.Minute
+	// blurayMaxPlaylistSize limits the size of playlist file we read into memory
+	blurayMaxPlaylistSize = 1024 * 1024
+)
+
+// isBluRayFile checks whether the file is a part of BDMV structure
+func isBluRayFile(path string) bool {
+	_, ok := bluray.DiscDir(path)
+	return ok
+}
+
+// OpenBluRay reads MPLS playlists of a disc and returns virtual file, that concatenates
+// stream files of the main title, in the order they are referenced by the playlist.
+func (t *Torrent) OpenBluRay(dir string) (*File, error) {
+	t.muVirtualFiles.Lock()
+	for _, vf := range t.virtualFiles {
+		if strings.HasPrefix(filepath.ToSlash(vf.Path), bluray.DiscPath(dir, bluray.PlaylistDir)) {
+			t.muVirtualFiles.Unlock()
+			return vf, nil
+		}
+	}
+	t.muVirtualFiles.Unlock()
+
+	playlists := []*File{}
+	streams := map[string]*File{}
+	for _, f := range t.files {
+		path := filepath.ToSlash(f.Path)
+		if fileDir, ok := bluray.DiscDir(path); !ok || fileDir != dir {
+			continue
+		}
+
+		name := strings.ToLower(filepath.Base(path))
+		if strings.Contains(path, bluray.PlaylistDir) && strings.HasSuffix(name, ".mpls") && f.Size <= blurayMaxPlaylistSize {
+			playlists = append(playlists, f)
+		} else if strings.Contains(path, bluray.StreamDir) && strings.HasSuffix(name, ".m2ts") {
+			streams[strings.TrimSuffix(name, ".m2ts")] = f
+		}
+	}
+	if len(playlists) == 0 {
+		return nil, errors.New("No playlists found")
+	}
+
+	defer func(now time.Time) {
+		log.Infof("Reading %d Blu-ray playlists took %s", len(playlists), time.Since(now))
+	}(time.Now())
+
+	// Requesting all playlists at once, as they are usually stored in a few pieces
+	for _, f := range playlists {
+		for piece := f.PieceStart; piece <= f.PieceEnd; piece++ {
+			t.th.PiecePriority(piece, 7)
+			t.th.SetPieceDeadline(piece, 0, 0)
+		}
+	}
+
+	var main *bluray.Playlist
+	var mainSize int64
+	deadline := time.Now().Add(blurayPlaylistTimeout)
+	for _, f := range playlists {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			log.Warningf("Timeout reading Blu-ray playlists")
+			break
+		}
+
+		var data []byte
+		err := t.withFileReader(f, timeout, func(r *TorrentFSEntry) error {
+			if _, err := r.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			data = make([]byte, f.Size)
+			_, err := io.ReadFull(r, data)
+			return err
+		})
+		if err != nil {
+			log.Warningf("Unable to read playlist %s: %s", f.Path, err)
+			continue
+		}
+
+		p, err := bluray.ParsePlaylist(strings.TrimSuffix(filepath.Base(f.Path), filepath.Ext(f.Path)), data)
+		if err != nil {
+			log.Warningf("Unable to parse playlist %s: %s", f.Path, err)
+			continue
+		} else if p.HasRepeatedClips() {
+			log.Debugf("Skipping playlist %s with repeated clips", f.Path)
+			continue
+		}
+
+		size := int64(0)
+		complete := true
+		for _, c := range p.Clips {
+			if s, ok := streams[strings.ToLower(c.Name)]; ok {
+				size += s.Size
+			} else {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		log.Debugf("Playlist %s: %d clips, %d chapters, %s, %s", p.Name, len(p.Clips), p.Chapters, p.Duration(), humanize.Bytes(uint64(size)))
+
+		// Main title is the longest one, chapters and size are used for playlists of the same length
+		if main == nil || isBetterPlaylist(p, size, main, mainSize) {
+			main = p
+			mainSize = size
+		}
+	}
+
+	if main == nil {
+		return nil, errors.New("No playable playlists found")
+	}
+
+	segments := make([]*FileSegment, 0, len(main.Clips))
+	for _, c := range main.Clips {
+		s := streams[strings.ToLower(c.Name)]
+		segments = append(segments, &FileSegment{
+			File: s,
+			Size: s.Size,
+		})
+	}
+
+	vf := &File{
+		Index:    -1,
+		Name:     main.Name + ".m2ts",
+		Size:     mainSize,
+		Path:     filepath.FromSlash(bluray.DiscPath(dir, bluray.PlaylistDir+main.Name+".m2ts")),
+		Segments: segments,
+	}
+	vf.Offset = vf.TorrentOffset(0)
+	vf.PieceStart = int(vf.Offset / t.pieceLength)
+	vf.PieceEnd = int(vf.TorrentOffset(mainSize-1) / t.pieceLength)
+
+	log.Infof("Found Blu-ray main title %s with %d clips and %d chapters, duration %s, size %s",
+		main.Name, len(main.Clips), main.Chapters, main.Duration(), humanize.Bytes(uint64(mainSize)))
+
+	t.muVirtualFiles.Lock()
+	t.virtualFiles = append(t.virtualFiles, vf)
+	t.muVirtualFiles.Unlock()
+
+	return vf, nil
+}
+
+func isBetterPlaylist(p *bluray.Playlist, size int64, than *bluray.Playlist, thanSize int64) bool {
+	// Durations within a second are considered equal
+	diff := p.Duration() - than.Duration()
+	if diff > time.Second {
+		return true
+	} else if diff < -time.Second {
+		return false
+	}
+
+	if p.Chapters != than.Chapters {
+		return p.Chapters > than.Chapters
+	}
+	return size > thanSize
+}
@@ -37,6 +37,9 @@ func DebugAll(s *Service) gin.HandlerFunc {
 
 		writeHeader(ctx.Writer, "Debug Vars")
 		writeResponse(ctx.Writer, "/debug/vars")
+
+		writeHeader(ctx.Writer, "Debug Trackers")
+		writeResponse(ctx.Writer, "/debug/trackers")
 	}
 }
 
@@ -82,6 +85,9 @@ func DebugBundle(s *Service) gin.HandlerFunc {
 		writeHeader(ctx.Writer, "Debug Vars")
 		writeResponse(ctx.Writer, "/debug/vars")
 
+		writeHeader(ctx.Writer, "Debug Trackers")
+		writeResponse(ctx.Writer, "/debug/trackers")
+
 		writeHeader(ctx.Writer, "kodi.log")
 		io.Copy(ctx.Writer, logFile)
 	}
@@ -10,4 +10,70 @@ type File struct {
 	Offset     int64
 	PieceStart int
 	PieceEnd   int
+
+	// Segments are set for virtual files, which data is stored inside other torrent files,
+	// like files stored without compression in RAR volumes.
+	Segments []*FileSegment
+}
+
+// FileSegment is a part of a virtual file, stored in a torrent file
+type FileSegment struct {
+	File   *File
+	Offset int64 // offset inside the torrent file
+	Size   int64
+}
+
+// IsVirtual ...
+func (f *File) IsVirtual() bool {
+	return len(f.Segments) > 0
+}
+
+// TorrentOffset converts position in the file into offset in the torrent
+func (f *File) TorrentOffset(pos int64) int64 {
+	if pos < 0 {
+		pos = 0
+	}
+	if !f.IsVirtual() {
+		return f.Offset + pos
+	}
+
+	for _, s := range f.Segments {
+		if pos < s.Size {
+			return s.File.Offset + s.Offset + pos
+		}
+		pos -= s.Size
+	}
+
+	last := f.Segments[len(f.Segments)-1]
+	return last.File.Offset + last.Offset + last.Size + pos
+}
+
+// segmentLeft returns how many bytes can be read continuously from the position
+func (f *File) segmentLeft(pos int64) int64 {
+	if !f.IsVirtual() {
+		return f.Size - pos
+	}
+
+	for _, s := range f.Segments {
+		if pos < s.Size {
+			return s.Size - pos
+		}
+		pos -= s.Size
+	}
+	return 0
+}
+
+// SourceFiles returns torrent files that contain the data of the file
+func (f *File) SourceFiles() []*File {
+	if !f.IsVirtual() {
+		return []*File{f}
+	}
+
+	ret := []*File{}
+	for _, s := range f.Segments {
+		if len(ret) == 0 || ret[len(ret)-1] != s.File {
+			ret = append(ret, s.File)
+		}
+	}
+	return ret
 }
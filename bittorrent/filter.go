@@ -0,0 +1,253 @@
+package bittorrent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Filter operators, longer ones go first, so that ">=" is not taken as ">" at the same position
+var filterOperators = []string{">=", "<=", "!=", "=", ">", "<", "~"}
+
+// StateNames maps torrent states to names, used in filter expressions
+var StateNames = map[string]int{
+	"queued":      StatusQueued,
+	"checking":    StatusChecking,
+	"finding":     StatusFinding,
+	"downloading": StatusDownloading,
+	"finished":    StatusFinished,
+	"seeding":     StatusSeeding,
+	"allocating":  StatusAllocating,
+	"stalled":     StatusStalled,
+	"paused":      StatusPaused,
+	"buffering":   StatusBuffering,
+	"playing":     StatusPlaying,
+}
+
+// TorrentFilter is a set of condition groups, torrent matches, if all conditions of any group match
+type TorrentFilter struct {
+	groups [][]filterCondition
+}
+
+type filterCondition struct {
+	field    string
+	operator string
+	value    string
+	number   float64
+}
+
+// ParseTorrentFilter parses expression like "state=seeding&ratio>1|tag=tv", where "&" binds stronger than "|".
+// Supported fields are state, name, category, tag, ratio, progress, size, seeds, peers and seeding_time,
+// "~" operator checks that text field contains the value.
+// Size can have units, like "700MB" or "1.5GiB", ratio and progress can be in percents, like "150%",
+// and seeding time can be a duration, like "48h".
+func ParseTorrentFilter(expr string) (*TorrentFilter, error) {
+	f := &TorrentFilter{}
+
+	for _, group := range strings.Split(expr, "|") {
+		conditions := []filterCondition{}
+		for _, part := range strings.Split(group, "&") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			c, err := parseFilterCondition(part)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+
+		if len(conditions) > 0 {
+			f.groups = append(f.groups, conditions)
+		}
+	}
+
+	return f, nil
+}
+
+func parseFilterCondition(part string) (c filterCondition, err error) {
+	// Field is followed by the first operator, value can contain operator characters
+	pos := -1
+	for _, op := range filterOperators {
+		if idx := strings.Index(part, op); idx > 0 && (pos < 0 || idx < pos) {
+			pos = idx
+			c.field = strings.ToLower(strings.TrimSpace(part[:idx]))
+			c.operator = op
+			c.value = strings.TrimSpace(part[idx+len(op):])
+		}
+	}
+	if c.operator == "" {
+		return c, fmt.Errorf("Wrong filter condition: %s", part)
+	}
+
+	switch c.field {
+	case "state":
+		if _, ok := StateNames[strings.ToLower(c.value)]; !ok {
+			return c, fmt.Errorf("Unknown state: %s", c.value)
+		}
+		fallthrough
+	case "name", "category", "tag":
+		if c.operator != "=" && c.operator != "!=" && c.operator != "~" {
+			return c, fmt.Errorf("Operator %s is not supported for %s", c.operator, c.field)
+		}
+	case "ratio", "progress", "size", "seeds", "peers", "seeding_time":
+		if c.operator == "~" {
+			return c, fmt.Errorf("Operator %s is not supported for %s", c.operator, c.field)
+		}
+		if c.number, err = parseFilterNumber(c.field, c.value); err != nil {
+			return c, fmt.Errorf("Value of %s should be a number: %s", c.field, c.value)
+		}
+	default:
+		return c, fmt.Errorf("Unknown filter field: %s", c.field)
+	}
+
+	return c, nil
+}
+
+// parseFilterNumber parses value of numeric field, with units of the field
+func parseFilterNumber(field, value string) (float64, error) {
+	switch field {
+	case "size":
+		size, err := humanize.ParseBytes(value)
+		return float64(size), err
+	case "ratio", "progress":
+		if strings.HasSuffix(value, "%") {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+			if field == "ratio" {
+				n /= 100
+			}
+			return n, err
+		}
+	case "seeding_time":
+		if d, err := time.ParseDuration(value); err == nil {
+			return d.Seconds(), nil
+		}
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// Match checks torrent against condition groups
+func (f *TorrentFilter) Match(t *Torrent) bool {
+	if len(f.groups) == 0 {
+		return true
+	}
+
+	for _, conditions := range f.groups {
+		matched := true
+		for _, c := range conditions {
+			if !c.match(t) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c filterCondition) match(t *Torrent) bool {
+	switch c.field {
+	case "state":
+		return c.compareBool(t.GetSmartState() == StateNames[strings.ToLower(c.value)])
+	case "name":
+		return c.compareText(t.Name())
+	case "category":
+		return c.compareText(t.Category())
+	case "tag":
+		if c.operator == "~" {
+			for _, tag := range t.Tags() {
+				if strings.Contains(strings.ToLower(tag), strings.ToLower(c.value)) {
+					return true
+				}
+			}
+			return false
+		}
+		return c.compareBool(t.HasTag(c.value))
+	}
+
+	return c.compareNumber(t.filterValue(c.field))
+}
+
+func (c filterCondition) compareBool(equal bool) bool {
+	if c.operator == "!=" {
+		return !equal
+	}
+	return equal
+}
+
+func (c filterCondition) compareText(s string) bool {
+	if c.operator == "~" {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(c.value))
+	}
+	return c.compareBool(strings.EqualFold(s, c.value))
+}
+
+func (c filterCondition) compareNumber(n float64) bool {
+	switch c.operator {
+	case ">=":
+		return n >= c.number
+	case "<=":
+		return n <= c.number
+	case ">":
+		return n > c.number
+	case "<":
+		return n < c.number
+	case "!=":
+		return n != c.number
+	default:
+		return n == c.number
+	}
+}
+
+// filterValue returns numeric value of the torrent for filter field
+func (t *Torrent) filterValue(field string) float64 {
+	switch field {
+	case "progress":
+		return t.GetProgress()
+	case "size":
+		return float64(t.GetSelectedSize())
+	case "seeds":
+		seeds, _, _, _ := t.GetConnections()
+		return float64(seeds)
+	case "peers":
+		_, _, peers, _ := t.GetConnections()
+		return float64(peers)
+	}
+
+	if t.th == nil || !t.th.IsValid() {
+		return 0
+	}
+	status := t.GetLastStatus(false)
+
+	switch field {
+	case "ratio":
+		if download := float64(status.GetAllTimeDownload()); download > 0 {
+			return float64(status.GetAllTimeUpload()) / download
+		}
+	case "seeding_time":
+		return float64(status.GetSeedingTime())
+	}
+	return 0
+}
+
+// FindTorrents returns torrents, matching the filter
+func (s *Service) FindTorrents(f *TorrentFilter) []*Torrent {
+	ret := []*Torrent{}
+	for _, t := range s.q.All() {
+		if t.Closer.IsSet() {
+			continue
+		}
+		if f == nil || f.Match(t) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
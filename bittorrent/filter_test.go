@@ -0,0 +1,127 @@
+package bittorrent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTorrentFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want [][]filterCondition
+	}{
+		{
+			expr: "state=seeding",
+			want: [][]filterCondition{
+				{{field: "state", operator: "=", value: "seeding"}},
+			},
+		},
+		{
+			expr: " Name ~ Ubuntu & category != movies & tag=tv ",
+			want: [][]filterCondition{{
+				{field: "name", operator: "~", value: "Ubuntu"},
+				{field: "category", operator: "!=", value: "movies"},
+				{field: "tag", operator: "=", value: "tv"},
+			}},
+		},
+		{
+			expr: "seeds>=5&peers<=10&progress<100&seeding_time>3600&ratio!=0",
+			want: [][]filterCondition{{
+				{field: "seeds", operator: ">=", value: "5", number: 5},
+				{field: "peers", operator: "<=", value: "10", number: 10},
+				{field: "progress", operator: "<", value: "100", number: 100},
+				{field: "seeding_time", operator: ">", value: "3600", number: 3600},
+				{field: "ratio", operator: "!=", value: "0", number: 0},
+			}},
+		},
+		{
+			expr: "state=seeding&ratio>1|tag=tv",
+			want: [][]filterCondition{
+				{
+					{field: "state", operator: "=", value: "seeding"},
+					{field: "ratio", operator: ">", value: "1", number: 1},
+				},
+				{{field: "tag", operator: "=", value: "tv"}},
+			},
+		},
+		{
+			expr: "tag=tv|state=paused&size<1GB",
+			want: [][]filterCondition{
+				{{field: "tag", operator: "=", value: "tv"}},
+				{
+					{field: "state", operator: "=", value: "paused"},
+					{field: "size", operator: "<", value: "1GB", number: 1000000000},
+				},
+			},
+		},
+		{
+			expr: "size>=700MB&size<1.5GiB&size>100",
+			want: [][]filterCondition{{
+				{field: "size", operator: ">=", value: "700MB", number: 700000000},
+				{field: "size", operator: "<", value: "1.5GiB", number: 1610612736},
+				{field: "size", operator: ">", value: "100", number: 100},
+			}},
+		},
+		{
+			expr: "ratio>=150%&ratio<2.5&progress>50%&seeding_time>48h",
+			want: [][]filterCondition{{
+				{field: "ratio", operator: ">=", value: "150%", number: 1.5},
+				{field: "ratio", operator: "<", value: "2.5", number: 2.5},
+				{field: "progress", operator: ">", value: "50%", number: 50},
+				{field: "seeding_time", operator: ">", value: "48h", number: 172800},
+			}},
+		},
+		{
+			expr: "name=a=b",
+			want: [][]filterCondition{
+				{{field: "name", operator: "=", value: "a=b"}},
+			},
+		},
+		{
+			expr: "",
+			want: nil,
+		},
+		{
+			expr: " & | ",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		f, err := ParseTorrentFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseTorrentFilter(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(f.groups, tt.want) {
+			t.Errorf("ParseTorrentFilter(%q) = %+v, want %+v", tt.expr, f.groups, tt.want)
+		}
+	}
+}
+
+func TestParseTorrentFilterErrors(t *testing.T) {
+	tests := []string{
+		"seeding",
+		"=seeding",
+		"state=unknown",
+		"state>seeding",
+		"name>=ubuntu",
+		"tag<tv",
+		"ratio~1",
+		"ratio>high",
+		"ratio>1x",
+		"size>big",
+		"size>-1GB",
+		"seeds>5 seeds",
+		"seeding_time>2 days",
+		"owner=me",
+		"state=seeding&ratio>",
+		"state=seeding|peers",
+	}
+
+	for _, expr := range tests {
+		if f, err := ParseTorrentFilter(expr); err == nil {
+			t.Errorf("ParseTorrentFilter(%q) = %+v, want error", expr, f.groups)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package bittorrent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	"github.com/elgatito/elementum/bittorrent/probe"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+const (
+	// probeTimeout limits the time we wait for header and index pieces of a file
+	probeTimeout = 2 * time.Minute
+	// probeCandidatesTimeout limits the time we wait for headers of candidate files, before the choice
+	probeCandidatesTimeout = 20 * time.Second
+	// probeCandidatesMax is the number of candidate files, above which we don't probe them before the choice
+	probeCandidatesMax = 8
+	// probeHeaderSize is the size of the file start, requested for the probe before the choice
+	probeHeaderSize = 2 * 1024 * 1024
+)
+
+// probeExtensions lists files that can be parsed by the container probe
+var probeExtensions = map[string]bool{
+	".mkv":  true,
+	".mk3d": true,
+	".webm": true,
+	".mp4":  true,
+	".m4v":  true,
+	".mov":  true,
+	".avi":  true,
+}
+
+// probeFile reads container headers of a file, using torrent reader, so needed pieces
+// are downloaded first. Result is cached per file, and used for duration, stream info
+// and file choice. MP4 index, if located at the end of the file, is added to the buffer.
+func (t *Torrent) probeFile(f *File, timeout time.Duration) *probe.Info {
+	if f == nil || !probeExtensions[strings.ToLower(filepath.Ext(f.Path))] {
+		return nil
+	}
+
+	t.muProbed.Lock()
+	if info, ok := t.probed[f.Index]; ok {
+		t.muProbed.Unlock()
+		return info
+	}
+	// Mark file as being probed, to avoid concurrent probes
+	t.probed[f.Index] = nil
+	t.muProbed.Unlock()
+
+	started := time.Now()
+
+	var info *probe.Info
+	err := t.withFileReader(f, timeout, func(r *TorrentFSEntry) (err error) {
+		if offset, size, err := probe.LocateIndex(r, f.Size); err == nil && size > 0 {
+			t.bufferIndex(f, offset, size)
+		}
+
+		info, err = probe.Probe(r, f.Size)
+		return
+	})
+	if err != nil {
+		log.Warningf("Unable to probe %s: %s", f.Path, err)
+
+		// Allowing to probe the file again, with a longer timeout, when it is chosen
+		t.muProbed.Lock()
+		delete(t.probed, f.Index)
+		t.muProbed.Unlock()
+		return nil
+	}
+
+	log.Infof("Probed %s in %s: %s", f.Path, time.Since(started), info)
+
+	t.muProbed.Lock()
+	t.probed[f.Index] = info
+	t.muProbed.Unlock()
+
+	return info
+}
+
+// probeStreamFile probes the file, chosen for playback, and takes its duration from the container
+func (t *Torrent) probeStreamFile(f *File) {
+	info := t.probeFile(f, probeTimeout)
+
+	// Container duration is more precise than TMDB runtime
+	if info != nil && info.Duration > 0 && t.bufferFile == f {
+		t.SetStreamDuration(info.Duration)
+	}
+}
+
+// probeCandidates probes candidate files before the choice, to choose by audio language
+// and to show media information in the choice dialog. Header pieces of the files are requested,
+// files, that are not probed in time, are left without media information.
+func (t *Torrent) probeCandidates(choices []*CandidateFile) {
+	if len(choices) < 2 || len(choices) > probeCandidatesMax || t.pieceLength <= 0 {
+		return
+	}
+
+	started := time.Now()
+	wg := sync.WaitGroup{}
+	for _, c := range choices {
+		f := t.files[c.Index]
+		if !probeExtensions[strings.ToLower(filepath.Ext(f.Path))] || t.GetProbeInfo(f) != nil {
+			continue
+		}
+
+		pieces := t.requestHeader(f)
+		wg.Add(1)
+		go func(f *File, pieces []int) {
+			defer wg.Done()
+
+			if t.probeFile(f, probeCandidatesTimeout) == nil && !t.IsMemoryStorage() {
+				// Header was not downloaded in time, no need to keep it in the queue
+				for _, piece := range pieces {
+					t.th.PiecePriority(piece, 0)
+				}
+			}
+		}(f, pieces)
+	}
+	wg.Wait()
+
+	log.Debugf("Probed candidate files in %s", time.Since(started))
+}
+
+// requestHeader requests pieces of the file start, needed for the probe, with top priority.
+// Memory storage readers request pieces by themselves.
+func (t *Torrent) requestHeader(f *File) []int {
+	if t.IsMemoryStorage() || f.Size <= 0 {
+		return nil
+	}
+
+	size := int64(probeHeaderSize)
+	if size > f.Size {
+		size = f.Size
+	}
+
+	pieces := []int{}
+	for piece := int(f.Offset / t.pieceLength); piece <= int((f.Offset+size-1)/t.pieceLength); piece++ {
+		if t.hasPiece(piece) {
+			continue
+		}
+
+		t.th.PiecePriority(piece, 7)
+		t.th.SetPieceDeadline(piece, 0, 0)
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
+
+// bufferIndex adds file index pieces to the buffer, if we are still buffering this file.
+func (t *Torrent) bufferIndex(f *File, offset, size int64) {
+	if !t.IsBuffering || t.bufferFile != f || t.pieceLength <= 0 {
+		return
+	}
+
+	start, end, _, _ := t.getBufferSize(f.TorrentOffset(offset), 0, size)
+	log.Infof("Index of %s is located at the end of the file, adding pieces %d-%d to the buffer", f.Path, start, end)
+
+	t.extendBuffer(start, end)
+}
+
+// GetProbeInfo returns cached container information for a file, or nil if file was not probed.
+func (t *Torrent) GetProbeInfo(f *File) *probe.Info {
+	if f == nil {
+		return nil
+	}
+
+	t.muProbed.Lock()
+	defer t.muProbed.Unlock()
+
+	return t.probed[f.Index]
+}
+
+// GetStreamInfo returns Kodi stream details for a file, taken from container probe.
+func (t *Torrent) GetStreamInfo(f *File) *xbmc.StreamInfo {
+	info := t.GetProbeInfo(f)
+	if info == nil {
+		return nil
+	}
+
+	ret := &xbmc.StreamInfo{}
+	if v := info.MainVideo(); v != nil {
+		ret.Video = &xbmc.StreamInfoEntry{
+			Codec:    v.Codec,
+			Width:    v.Width,
+			Height:   v.Height,
+			Duration: int(info.Duration.Seconds()),
+		}
+		if v.Height > 0 {
+			ret.Video.Aspect = float32(v.Width) / float32(v.Height)
+		}
+	}
+	if a := info.MainAudio(); a != nil {
+		ret.Audio = &xbmc.StreamInfoEntry{
+			Codec:    a.Codec,
+			Language: a.Language,
+			Channels: a.Channels,
+		}
+	}
+	if len(info.Subtitles) > 0 {
+		ret.Subtitle = &xbmc.StreamInfoEntry{
+			Language: info.Subtitles[0].Language,
+		}
+	}
+
+	return ret
+}
+
+// probeDescription returns short description of probed file, to show in file choice dialog
+func probeDescription(info *probe.Info) string {
+	if info == nil {
+		return ""
+	}
+
+	parts := []string{}
+	if v := info.MainVideo(); v != nil {
+		video := v.Codec
+		if v.Height > 0 {
+			video += fmt.Sprintf(" %dp", v.Height)
+		}
+		if v.HDR != "" {
+			video += " " + v.HDR
+		}
+		parts = append(parts, video)
+	}
+	if langs := info.AudioLanguages(); len(langs) > 0 {
+		parts = append(parts, strings.Join(langs, "/"))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,325 @@
+package bittorrent
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/sync"
+	"github.com/zeebo/bencode"
+
+	lt "github.com/ElementumOrg/libtorrent-go"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+)
+
+const (
+	metadataCheckInterval = 500 * time.Millisecond
+	metadataRemoveTimeout = 10 * time.Second
+	metadataCacheSize     = 200
+)
+
+var (
+	errNotMagnet        = errors.New("Only magnet links can be resolved")
+	errMetadataCanceled = errors.New("Torrent is added to the session, metadata resolver is canceled")
+)
+
+// TorrentMetadata is a description of the torrent, fetched without starting a download
+type TorrentMetadata struct {
+	InfoHash  string          `json:"info_hash"`
+	Name      string          `json:"name"`
+	Size      int64           `json:"size"`
+	IsPrivate bool            `json:"is_private"`
+	Cached    bool            `json:"cached"`
+	Files     []*MetadataFile `json:"files"`
+}
+
+// MetadataFile is a file inside of the torrent
+type MetadataFile struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+}
+
+type metadataInfoRaw struct {
+	Name    string `bencode:"name"`
+	Length  int64  `bencode:"length"`
+	Private int64  `bencode:"private"`
+	Files   []struct {
+		Length int64    `bencode:"length"`
+		Path   []string `bencode:"path"`
+	} `bencode:"files"`
+}
+
+// metadataRequest is a running resolver, or a torrent being added to the session
+type metadataRequest struct {
+	infoHash string
+	done     chan struct{}
+	cancel   chan struct{}
+}
+
+// metadataRequests keeps resolvers and torrents being added, so that the same magnet is not added twice,
+// and resolver does not remove the torrent, that was added by the user.
+var metadataRequests = struct {
+	sync.Mutex
+	items map[string]*metadataRequest
+}{
+	items: map[string]*metadataRequest{},
+}
+
+// acquireMetadataRequest waits until nobody else adds the torrent to the session, and registers the caller.
+// With cancelResolver running resolver is asked to stop, instead of waiting for its result.
+func acquireMetadataRequest(infoHash string, cancelResolver bool) *metadataRequest {
+	for {
+		metadataRequests.Lock()
+		r, running := metadataRequests.items[infoHash]
+		if !running {
+			r = &metadataRequest{
+				infoHash: infoHash,
+				done:     make(chan struct{}),
+				cancel:   make(chan struct{}),
+			}
+			metadataRequests.items[infoHash] = r
+			metadataRequests.Unlock()
+			return r
+		}
+
+		if cancelResolver {
+			select {
+			case <-r.cancel:
+			default:
+				close(r.cancel)
+			}
+		}
+		metadataRequests.Unlock()
+
+		<-r.done
+	}
+}
+
+// release unregisters the request and wakes up waiting ones
+func (r *metadataRequest) release() {
+	metadataRequests.Lock()
+	defer metadataRequests.Unlock()
+
+	if metadataRequests.items[r.infoHash] == r {
+		delete(metadataRequests.items, r.infoHash)
+	}
+	close(r.done)
+}
+
+// ParseTorrentMetadata reads files from bencoded torrent file
+func ParseTorrentMetadata(b []byte) (*TorrentMetadata, error) {
+	var raw struct {
+		Info bencode.RawMessage `bencode:"info"`
+	}
+	if err := bencode.DecodeBytes(b, &raw); err != nil {
+		return nil, err
+	} else if len(raw.Info) == 0 {
+		return nil, errors.New("Torrent does not contain info section")
+	}
+
+	info := metadataInfoRaw{}
+	if err := bencode.DecodeBytes(raw.Info, &info); err != nil {
+		return nil, err
+	}
+
+	m := &TorrentMetadata{
+		InfoHash:  fmt.Sprintf("%x", sha1.Sum(raw.Info)),
+		Name:      info.Name,
+		IsPrivate: info.Private == 1,
+		Files:     []*MetadataFile{},
+	}
+
+	if len(info.Files) == 0 {
+		m.Files = append(m.Files, &MetadataFile{Path: info.Name, Size: info.Length})
+		m.Size = info.Length
+		return m, nil
+	}
+
+	for i, f := range info.Files {
+		m.Files = append(m.Files, &MetadataFile{
+			Index: i,
+			Path:  path.Join(append([]string{info.Name}, f.Path...)...),
+			Size:  f.Length,
+		})
+		m.Size += f.Length
+	}
+	return m, nil
+}
+
+// cachedMetadata looks for torrent file in active torrents, assigned torrents, resolved magnets and torrent history
+func (s *Service) cachedMetadata(infoHash string) []byte {
+	if t := s.GetTorrentByHash(infoHash); t != nil && t.HasMetadata() && t.gotMetainfo.IsSet() {
+		return t.GetMetadata()
+	}
+
+	var tm database.TorrentAssignMetadata
+	if err := database.GetStormDB().One("InfoHash", infoHash, &tm); err == nil && len(tm.Metadata) > 0 && tm.Metadata[0] != '{' {
+		return tm.Metadata
+	}
+
+	if b := database.GetStorm().GetResolvedMetadata(infoHash); len(b) > 0 {
+		return b
+	}
+
+	var th database.TorrentHistory
+	if err := database.GetStormDB().One("InfoHash", infoHash, &th); err == nil && len(th.Metadata) > 0 && th.Metadata[0] != '{' {
+		return th.Metadata
+	}
+
+	return nil
+}
+
+// FetchMetadata resolves magnet link into torrent metadata, using BEP 9 to get info from peers.
+// Torrent is added to the session in upload mode without files, so that no storage is allocated,
+// and is removed as soon as metadata is received, or when the same torrent is added by AddTorrent.
+// Request is released only after libtorrent has removed the torrent.
+// Result is cached in the database, only recent results are kept.
+func (s *Service) FetchMetadata(uri string, timeout time.Duration) (*TorrentMetadata, error) {
+	uri = strings.Replace(strings.TrimSpace(uri), " ", "", -1)
+	if !strings.HasPrefix(uri, "magnet:") {
+		return nil, errNotMagnet
+	}
+	if s.Session == nil || s.Session.Swigcptr() == 0 {
+		return nil, errors.New("Session is not ready")
+	}
+
+	torrentParams := lt.NewAddTorrentParams()
+	defer lt.DeleteAddTorrentParams(torrentParams)
+
+	ec := lt.NewErrorCode()
+	defer lt.DeleteErrorCode(ec)
+	lt.ParseMagnetUri(uri, torrentParams, ec)
+	if ec.Failed() {
+		return nil, errors.New(ec.Message().(string))
+	}
+
+	infoHash := hex.EncodeToString([]byte(torrentParams.GetInfoHash().ToString()))
+
+	// Wait for the resolver of the same magnet, if it is running
+	req := acquireMetadataRequest(infoHash, false)
+	defer req.release()
+
+	if b := s.cachedMetadata(infoHash); b != nil {
+		if m, err := ParseTorrentMetadata(b); err == nil {
+			m.Cached = true
+			return m, nil
+		}
+	}
+	if s.GetTorrentByHash(infoHash) != nil {
+		return nil, fmt.Errorf("Torrent %s is already added and is waiting for metadata", infoHash)
+	}
+
+	log.Infof("Fetching metadata for torrent: %s", infoHash)
+
+	flags := torrentParams.GetFlags()
+	flags &^= uint64(lt.AddTorrentParamsFlagPaused) | uint64(lt.AddTorrentParamsFlagAutoManaged)
+	// Resolver should never take over the torrent, that is already in the session
+	flags |= uint64(lt.AddTorrentParamsFlagUploadMode) | uint64(lt.AddTorrentParamsFlagDuplicateIsError)
+	torrentParams.SetFlags(flags)
+	torrentParams.SetSavePath(config.Get().Info.TempPath)
+	torrentParams.SetMaxConnections(getPlatformSpecificConnectionLimit())
+
+	filesPriorities := lt.NewStdVectorInt()
+	defer lt.DeleteStdVectorInt(filesPriorities)
+	for i := 0; i <= 500; i++ {
+		filesPriorities.Add(0)
+	}
+	torrentParams.SetFilePriorities(filesPriorities)
+
+	for _, tracker := range HealthyTrackers(getExtraTrackers()) {
+		torrentParams.GetTrackers().Add(tracker)
+	}
+
+	th, err := s.Session.AddTorrent(torrentParams)
+	if err != nil {
+		return nil, err
+	}
+	defer s.removeMetadataTorrent(th, infoHash)
+
+	closing := s.Closer.C()
+	expired := time.After(timeout)
+	ticker := time.NewTicker(metadataCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return nil, errors.New("Service is closing")
+		case <-req.cancel:
+			return nil, errMetadataCanceled
+		case <-expired:
+			return nil, fmt.Errorf("Expired timeout for fetching metadata for %d seconds", int(timeout.Seconds()))
+		case <-ticker.C:
+			ts := th.Status(uint(lt.WrappedTorrentHandleQueryName))
+			hasMetadata := ts.GetHasMetadata()
+			lt.DeleteTorrentStatus(ts)
+			if !hasMetadata {
+				continue
+			}
+
+			b := torrentInfoBytes(th.TorrentFile())
+			m, err := ParseTorrentMetadata(b)
+			if err != nil {
+				return nil, err
+			}
+
+			log.Infof("Fetched metadata for torrent %s: %s", infoHash, m.Name)
+			database.GetStorm().AddResolvedMetadata(infoHash, b, metadataCacheSize)
+			return m, nil
+		}
+	}
+}
+
+// removeMetadataTorrent removes resolver's torrent and waits until libtorrent reports it is removed,
+// so that the same torrent, added right after the resolver is released, is not taken as a duplicate.
+func (s *Service) removeMetadataTorrent(th lt.TorrentHandle, infoHash string) {
+	alerts, alertsDone := s.Alerts()
+	defer close(alertsDone)
+
+	if err := s.Session.RemoveTorrent(th, 0); err != nil {
+		log.Warningf("Could not remove metadata resolver for %s: %s", infoHash, err)
+		return
+	}
+
+	closing := s.Closer.C()
+	expired := time.After(metadataRemoveTimeout)
+	for {
+		select {
+		case <-closing:
+			return
+		case <-expired:
+			log.Warningf("Metadata resolver for %s is not removed in %d seconds", infoHash, int(metadataRemoveTimeout.Seconds()))
+			return
+		case alert, ok := <-alerts:
+			if !ok {
+				return
+			}
+			if alert.Type != lt.TorrentRemovedAlertAlertType {
+				continue
+			}
+
+			removedAlert := lt.SwigcptrTorrentRemovedAlert(alert.Pointer)
+			if hex.EncodeToString([]byte(removedAlert.GetInfoHash().ToString())) == infoHash {
+				return
+			}
+		}
+	}
+}
+
+// torrentInfoBytes generates bencoded torrent file from torrent info
+func torrentInfoBytes(ti lt.TorrentInfo) []byte {
+	torrentFile := lt.NewCreateTorrent(ti)
+	defer lt.DeleteCreateTorrent(torrentFile)
+
+	torrentContent := torrentFile.Generate()
+	defer lt.DeleteEntry(torrentContent)
+
+	return []byte(lt.Bencode(torrentContent))
+}
@@ -0,0 +1,277 @@
+package bittorrent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/elgatito/elementum/bittorrent/probe"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/util"
+)
+
+const (
+	defaultMoviesTemplate = "{name}.{ext}"
+	defaultShowsTemplate  = "{show} ({year})/{season_dir}/{name}.{ext}"
+)
+
+var (
+	templateVarRegex      = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+	emptyBracketsRegex    = regexp.MustCompile(`\s*(\(\s*\)|\[\s*\])`)
+	fileEpisodeRegex      = regexp.MustCompile(`(?i)(?:^|\W|_)S(\d{1,2})\W?E(\d{1,3})`)
+	fileEpisodeShortRegex = regexp.MustCompile(`(?:^|\W|_)(\d{1,2})x(\d{2,3})(?:\W|_|$)`)
+)
+
+// OrganizedFile is a result of placing torrent file into the library
+type OrganizedFile struct {
+	Source      string
+	Destination string
+}
+
+// organizeVars collects template variables for a torrent file, using TMDB metadata linked from BTItem
+func organizeVars(item *database.BTItem, path string, probed *probe.Info) map[string]string {
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+
+	vars := map[string]string{
+		"name": strings.TrimSuffix(name, ext),
+		"ext":  strings.TrimPrefix(ext, "."),
+	}
+
+	if res := matchLowerTags(&TorrentFile{Name: " " + name}, resolutionTags); res != ResolutionUnknown {
+		vars["resolution"] = Resolutions[res]
+	} else if v := probed.MainVideo(); v != nil && v.Height > 0 {
+		vars["resolution"] = fmt.Sprintf("%dp", v.Height)
+	}
+
+	if item.Type == movieType {
+		if movie := tmdb.GetMovie(item.ID, config.Get().Language); movie != nil {
+			vars["title"] = movie.Title
+			vars["original_title"] = movie.OriginalTitle
+			vars["imdb"] = movie.IMDBId
+			if movie.Year() > 0 {
+				vars["year"] = strconv.Itoa(movie.Year())
+			}
+		}
+		return vars
+	}
+
+	if item.ShowID == 0 {
+		return vars
+	}
+
+	show := tmdb.GetShow(item.ShowID, config.Get().Language)
+	if show == nil {
+		return vars
+	}
+
+	// Files of season packs have their own episode numbers
+	season, episode := item.Season, item.Episode
+	if m := fileEpisodeRegex.FindStringSubmatch(name); len(m) > 0 {
+		season, _ = strconv.Atoi(m[1])
+		episode, _ = strconv.Atoi(m[2])
+	} else if m := fileEpisodeShortRegex.FindStringSubmatch(name); len(m) > 0 {
+		season, _ = strconv.Atoi(m[1])
+		episode, _ = strconv.Atoi(m[2])
+	}
+
+	vars["show"] = show.Name
+	vars["title"] = show.Name
+	vars["original_title"] = show.OriginalName
+	if year := strings.Split(show.FirstAirDate, "-")[0]; year != "" {
+		vars["year"] = year
+	}
+	vars["season"] = strconv.Itoa(season)
+	vars["season_dir"] = fmt.Sprintf("Season %d", season)
+	if season == 0 {
+		vars["season_dir"] = "Specials"
+	}
+	if episode > 0 {
+		vars["episode"] = strconv.Itoa(episode)
+		if e := tmdb.GetEpisode(item.ShowID, season, episode, config.Get().Language); e != nil {
+			vars["episode_title"] = e.Name
+		}
+	}
+
+	return vars
+}
+
+// renderTemplate substitutes variables in the path template, like "{show}/Season {season:02}/{name}",
+// number after a colon sets zero-padded width. Returns false if template uses unknown variable.
+func renderTemplate(tmpl string, vars map[string]string) (string, bool) {
+	ok := true
+	parts := []string{}
+	for _, p := range strings.Split(filepath.ToSlash(tmpl), "/") {
+		p = templateVarRegex.ReplaceAllStringFunc(p, func(m string) string {
+			sm := templateVarRegex.FindStringSubmatch(m)
+			v, exists := vars[sm[1]]
+			if !exists {
+				// Resolution and episode title are optional, as they are not always known
+				if sm[1] != "resolution" && sm[1] != "episode_title" {
+					ok = false
+				}
+				return ""
+			}
+			if sm[2] != "" {
+				width, _ := strconv.Atoi(sm[2])
+				if n, err := strconv.Atoi(v); err == nil {
+					v = fmt.Sprintf("%0*d", width, n)
+				}
+			}
+			return util.ToFileName(v)
+		})
+
+		p = strings.Trim(emptyBracketsRegex.ReplaceAllString(p, ""), " .-")
+		if p != "" && p != "." && p != ".." {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", false
+	}
+	return filepath.Join(parts...), ok
+}
+
+// organizedPath returns destination path of a torrent file inside the library,
+// categoryPath replaces library folder, and torrents without media type keep original names there.
+func organizedPath(item *database.BTItem, path string, probed *probe.Info, categoryPath string) string {
+	root := filepath.Dir(config.Get().CompletedShowsPath)
+	tmpl := config.Get().CompletedShowsTemplate
+	if tmpl == "" {
+		tmpl = defaultShowsTemplate
+	}
+	if item.Type == movieType {
+		root = filepath.Dir(config.Get().CompletedMoviesPath)
+		tmpl = config.Get().CompletedMoviesTemplate
+		if tmpl == "" {
+			tmpl = defaultMoviesTemplate
+		}
+	} else if item.Type == "" {
+		tmpl = defaultMoviesTemplate
+	}
+	if categoryPath != "" {
+		root = categoryPath
+	}
+
+	vars := organizeVars(item, path, probed)
+	dst, ok := renderTemplate(tmpl, vars)
+	if !ok {
+		log.Warningf("Not enough metadata to apply template %s to %s, keeping original name", tmpl, path)
+		dst, _ = renderTemplate(defaultMoviesTemplate, vars)
+	} else if !strings.Contains(filepath.Base(filepath.ToSlash(tmpl)), "{ext}") {
+		dst += "." + vars["ext"]
+	}
+
+	return filepath.Join(root, dst)
+}
+
+// subtitlesFor returns subtitles files from the torrent, which belong to the video file,
+// together with the suffix, that should follow new video name, like ".en.srt".
+func subtitlesFor(files []*File, video *File, single bool) map[*File]string {
+	ret := map[*File]string{}
+
+	base := strings.TrimSuffix(filepath.Base(video.Path), filepath.Ext(video.Path))
+	dir := filepath.Dir(video.Path)
+	for _, f := range files {
+		name := filepath.Base(f.Path)
+		ext := filepath.Ext(name)
+		if !util.IsSubtitlesExt(ext) {
+			continue
+		}
+
+		if strings.HasPrefix(name, base+".") {
+			ret[f] = name[len(base):]
+		} else if single && (dir == "." || strings.HasPrefix(f.Path, dir+string(os.PathSeparator))) {
+			// Single video with subtitles in a separate folder, like Subs/English.srt
+			ret[f] = "." + util.ToFileName(strings.TrimSuffix(name, ext)) + ext
+		}
+	}
+
+	return ret
+}
+
+// organizeFile places file into the destination with chosen mode, and creates parent folders.
+// Existing destination of the same size is considered already organized.
+func organizeFile(src, dst string, mode int) error {
+	if dstInfo, err := os.Stat(dst); err == nil {
+		if srcInfo, err := os.Stat(src); err == nil && srcInfo.Size() == dstInfo.Size() {
+			return nil
+		}
+		return fmt.Errorf("Destination %s already exists", dst)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	switch mode {
+	case config.CompletedHardlink:
+		// Hardlinks are not possible across filesystems
+		err := os.Link(src, dst)
+		if err == nil {
+			return nil
+		}
+		log.Warningf("Unable to create hardlink for %s, copying instead: %s", src, err)
+		fallthrough
+	case config.CompletedCopy:
+		_, err := util.Copy(src, dst, false)
+		return err
+	default:
+		_, err := util.Move(src, dst)
+		return err
+	}
+}
+
+// Organize places downloaded files of the torrent into the library, using path templates.
+// srcPath returns location of the file on disk, as it can differ for extracted archives.
+func (t *Torrent) Organize(item *database.BTItem, srcPath func(f *File) string, mode int) ([]*OrganizedFile, error) {
+	videos := []*File{}
+	for _, fp := range item.Files {
+		f := t.GetFileByPath(fp)
+		if f == nil || util.IsSubtitlesExt(filepath.Ext(f.Path)) {
+			continue
+		}
+		videos = append(videos, f)
+	}
+	if len(videos) == 0 {
+		return nil, fmt.Errorf("No files to organize for %s", t.Name())
+	}
+
+	ret := []*OrganizedFile{}
+	for _, f := range videos {
+		src := srcPath(f)
+		if src == "" {
+			continue
+		}
+
+		dst := organizedPath(item, src, t.GetProbeInfo(f), t.CompletedPath())
+		log.Infof("Organizing %s to %s", src, dst)
+		if err := organizeFile(src, dst, mode); err != nil {
+			return ret, err
+		}
+		ret = append(ret, &OrganizedFile{Source: src, Destination: dst})
+
+		dstBase := strings.TrimSuffix(dst, filepath.Ext(dst))
+		for sub, suffix := range subtitlesFor(t.files, f, len(videos) == 1) {
+			subSrc := filepath.Join(t.DownloadPath(), sub.Path)
+			if _, err := os.Stat(subSrc); err != nil {
+				continue
+			}
+
+			subDst := dstBase + suffix
+			if err := organizeFile(subSrc, subDst, mode); err != nil {
+				log.Warningf("Unable to organize subtitles %s: %s", subSrc, err)
+				continue
+			}
+			ret = append(ret, &OrganizedFile{Source: subSrc, Destination: subDst})
+		}
+	}
+
+	return ret, nil
+}
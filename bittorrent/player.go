@@ -20,9 +20,12 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/sanity-io/litter"
 
+	"github.com/elgatito/elementum/anime"
+	"github.com/elgatito/elementum/bittorrent/bluray"
 	"github.com/elgatito/elementum/broadcast"
 	"github.com/elgatito/elementum/config"
 	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/library"
 	"github.com/elgatito/elementum/library/playcount"
 	"github.com/elgatito/elementum/library/uid"
 	"github.com/elgatito/elementum/osdb"
@@ -147,7 +150,7 @@ func NewPlayer(bts *Service, params PlayerParams, xbmcHost *xbmc.XBMCHost) *Play
 		xbmcHost: xbmcHost,
 
 		overlayStatusEnabled: config.Get().EnableOverlayStatus,
-		scrobble:             config.Get().Scrobble && params.TMDBId > 0 && config.Get().TraktToken != "",
+		scrobble:             config.Get().Scrobble && params.TMDBId > 0 && len(library.EnabledSyncBackends()) > 0,
 		hasChosenFile:        false,
 		fileSize:             0,
 		fileName:             "",
@@ -223,7 +226,7 @@ func (btp *Player) resumeTorrent() error {
 
 // PlayURL ...
 func (btp *Player) PlayURL() string {
-	if btp.t.IsRarArchive {
+	if btp.needsExtraction() {
 		extractedPath := filepath.Join(filepath.Dir(btp.chosenFile.Path), "extracted", btp.extracted)
 		return util.EncodeFileURL(extractedPath)
 	}
@@ -319,6 +322,29 @@ func (btp *Player) processMetadata() {
 		return
 	}
 
+	if btp.t.IsRarArchive {
+		// Stored archives are streamed directly, others need to be downloaded and extracted
+		if vf, err := btp.t.OpenRarArchive(btp.chosenFile); err == nil {
+			btp.chosenFile = vf
+		} else {
+			log.Warningf("Unable to stream from RAR archive: %s", err)
+			if btp.xbmcHost != nil && !btp.xbmcHost.DialogConfirm("Elementum", "LOCALIZE[30303]") {
+				btp.notEnoughSpace = true
+				btp.bufferEvents.Broadcast(errors.New("RAR archive detected and download was cancelled"))
+				return
+			}
+		}
+	}
+
+	// Blu-ray discs are played as concatenated clips of the main title
+	if dir, ok := bluray.DiscDir(btp.chosenFile.Path); ok {
+		if vf, err := btp.t.OpenBluRay(dir); err == nil {
+			btp.chosenFile = vf
+		} else {
+			log.Warningf("Unable to find Blu-ray main title, playing %s: %s", btp.chosenFile.Path, err)
+		}
+	}
+
 	btp.p.ResumeToken = strconv.FormatUint(xxhash.Sum64String(btp.t.InfoHash()+btp.chosenFile.Path), 10)
 	btp.hasChosenFile = true
 	btp.fileSize = btp.chosenFile.Size
@@ -356,8 +382,10 @@ func (btp *Player) processMetadata() {
 
 	files := []string{}
 	if btp.chosenFile != nil {
-		btp.t.DownloadFileWithPriority(btp.chosenFile, 2)
-		files = append(files, btp.chosenFile.Path)
+		for _, f := range btp.chosenFile.SourceFiles() {
+			btp.t.DownloadFileWithPriority(f, 2)
+			files = append(files, f.Path)
+		}
 	}
 	if btp.subtitlesFile != nil {
 		btp.t.DownloadFileWithPriority(btp.subtitlesFile, 2)
@@ -372,7 +400,7 @@ func (btp *Player) processMetadata() {
 	go database.GetStorm().AddTorrentHistory(btp.t.InfoHash(), btp.t.Title(), meta)
 	go database.GetStorm().AddTorrentLink(strconv.Itoa(btp.p.TMDBId), btp.t.InfoHash(), meta, true)
 
-	if btp.t.IsRarArchive {
+	if btp.needsExtraction() {
 		// Just disable sequential download for RAR archives
 		log.Info("Disabling sequential download")
 		btp.t.th.SetSequentialDownload(false)
@@ -385,7 +413,9 @@ func (btp *Player) processMetadata() {
 		defer lt.DeleteStdVectorInt(filePriorities)
 
 		if btp.chosenFile != nil {
-			filePriorities.Set(btp.chosenFile.Index, 4)
+			for _, f := range btp.chosenFile.SourceFiles() {
+				filePriorities.Set(f.Index, 4)
+			}
 		}
 		if btp.subtitlesFile != nil {
 			filePriorities.Set(btp.subtitlesFile.Index, 4)
@@ -395,9 +425,15 @@ func (btp *Player) processMetadata() {
 		btp.t.SaveDBFiles()
 	}
 
+	// Files of the playback queue are downloaded in the queue order after the current one
+	if btp.t.HasPlaylist() {
+		btp.t.SetPlaylistCurrent(btp.chosenFile)
+	}
+
 	log.Info("Setting piece priorities")
 
 	if !btp.p.Background {
+		btp.t.SetStreamDuration(btp.expectedDuration())
 		go btp.t.Buffer(btp.chosenFile, btp.p.ResumeHash == "")
 	}
 }
@@ -422,6 +458,11 @@ func (btp *Player) statusStrings(progress float64, status lt.TorrentStatus) (str
 		done := int64(float64(progress/100) * float64(query))
 
 		line1 = fmt.Sprintf("%s (%.2f%%) | (%s / %s)", statusName, progress, humanize.Bytes(uint64(done)), humanize.Bytes(uint64(query)))
+
+		// Estimated time until the buffer is enough to start playback
+		if eta := btp.t.GetBufferETA(); eta >= 0 {
+			line1 += fmt.Sprintf(" | ETA %s", eta.Round(time.Second))
+		}
 	} else if btp.t.IsMemoryStorage() {
 		// For memory storage show also memory size near percents.
 		line1 = fmt.Sprintf("%s (%.2f%% / %s)", statusName, progress, humanize.Bytes(uint64(btp.t.MemorySize)))
@@ -430,7 +471,7 @@ func (btp *Player) statusStrings(progress float64, status lt.TorrentStatus) (str
 	// File size
 	var totalSize int64
 	if btp.t.ti != nil && btp.t.ti.Swigcptr() != 0 {
-		if btp.fileSize > 0 && !btp.t.IsRarArchive {
+		if btp.fileSize > 0 && !btp.needsExtraction() {
 			totalSize = btp.fileSize
 		} else {
 			totalSize = btp.t.ti.TotalSize()
@@ -442,6 +483,8 @@ func (btp *Player) statusStrings(progress float64, status lt.TorrentStatus) (str
 	if btp.t.IsPlaying && btp.p.VideoDuration > 0 {
 		bps := uint64(totalSize) / uint64(btp.p.VideoDuration)
 		line1 += fmt.Sprintf(" - LOCALIZE[30640] ~ %s (%.2f MBit)", humanize.Bytes(bps), float64(bps*8)/1000000)
+	} else if bps := uint64(btp.t.GetStreamBitrate(btp.chosenFile)); btp.t.IsBuffering && bps > 0 {
+		line1 += fmt.Sprintf(" - LOCALIZE[30640] ~ %s (%.2f MBit)", humanize.Bytes(bps), float64(bps*8)/1000000)
 	}
 
 	seeds, seedsTotal, peers, peersTotal := btp.t.GetConnections()
@@ -451,12 +494,17 @@ func (btp *Player) statusStrings(progress float64, status lt.TorrentStatus) (str
 		seeds, seedsTotal, peers, peersTotal,
 	)
 	line3 := btp.t.Name()
-	if btp.fileName != "" && !btp.t.IsRarArchive {
+	if btp.fileName != "" && !btp.needsExtraction() {
 		line3 = btp.fileName
 	}
 	return line1, line2, line3
 }
 
+// needsExtraction checks whether chosen file is a RAR archive, which can't be streamed directly
+func (btp *Player) needsExtraction() bool {
+	return btp.t.IsRarArchive && (btp.chosenFile == nil || !btp.chosenFile.IsVirtual())
+}
+
 // HasChosenFile ...
 func (btp *Player) HasChosenFile() bool {
 	return btp.hasChosenFile && btp.chosenFile != nil
@@ -588,16 +636,16 @@ func (btp *Player) updateBufferDialog() (bool, error) {
 	defer perf.ScopeTimer()()
 
 	// Handle "Checking" state for resumed downloads
-	if btp.t.GetLastStatus(false).GetState() == StatusChecking || btp.t.IsRarArchive {
+	if btp.t.GetLastStatus(false).GetState() == StatusChecking || btp.needsExtraction() {
 		progress := btp.t.GetBufferProgress()
 		line1, line2, line3 := btp.statusStrings(progress, btp.t.GetLastStatus(false))
 		if btp.dialogProgress != nil {
 			btp.dialogProgress.Update(int(progress), line1, line2, line3)
 		}
 
-		if btp.t.IsRarArchive && progress >= 100 {
-			archivePath := filepath.Join(btp.s.config.DownloadPath, btp.chosenFile.Path)
-			destPath := filepath.Join(btp.s.config.DownloadPath, filepath.Dir(btp.chosenFile.Path), "extracted")
+		if btp.needsExtraction() && progress >= 100 {
+			archivePath := filepath.Join(btp.t.DownloadPath(), btp.chosenFile.Path)
+			destPath := filepath.Join(btp.t.DownloadPath(), filepath.Dir(btp.chosenFile.Path), "extracted")
 
 			if _, err := os.Stat(destPath); err == nil {
 				btp.findExtracted(destPath)
@@ -744,7 +792,7 @@ playbackWaitLoop:
 
 	log.Infof("Got playback: %fs / %fs", btp.p.WatchedTime, btp.p.VideoDuration)
 	if btp.scrobble {
-		trakt.Scrobble("start", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+		library.Scrobble("start", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 		btp.p.TraktScrobbled = true
 	}
 
@@ -759,6 +807,11 @@ playbackLoop:
 		<-oneSecond.C
 		btp.updateWatchTimes()
 
+		// Kodi knows real duration of the file, so we use it for bitrate estimation
+		if btp.p.VideoDuration > 0 {
+			btp.t.SetStreamDuration(time.Duration(btp.p.VideoDuration) * time.Second)
+		}
+
 		// Trigger UpNext notification if Player is done with initialization
 		if btp.p.VideoDuration > 0 && !btp.p.UpNextSent {
 			go btp.processUpNextPayload()
@@ -767,7 +820,7 @@ playbackLoop:
 		if btp.p.Seeked {
 			btp.p.Seeked = false
 			if btp.scrobble {
-				go trakt.Scrobble("start", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+				go library.Scrobble("start", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 			}
 		} else if btp.xbmcHost == nil || btp.xbmcHost.PlayerIsPaused() {
 			if btp.overlayStatusEnabled && btp.p.Playing {
@@ -783,7 +836,7 @@ playbackLoop:
 			if playing {
 				playing = false
 				if btp.scrobble {
-					go trakt.Scrobble("pause", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+					go library.Scrobble("pause", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 				}
 			}
 		} else {
@@ -794,7 +847,7 @@ playbackLoop:
 			if !playing {
 				playing = true
 				if btp.scrobble {
-					go trakt.Scrobble("start", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+					go library.Scrobble("start", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 				}
 			}
 		}
@@ -812,9 +865,9 @@ playbackLoop:
 		btp.UpdateWatched()
 		if btp.scrobble {
 			if btp.IsWatched() {
-				trakt.Scrobble("stop", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+				library.Scrobble("stop", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 			} else {
-				trakt.Scrobble("pause", btp.p.ContentType, btp.p.TMDBId, btp.p.WatchedTime, btp.p.VideoDuration)
+				library.Scrobble("pause", btp.syncItem(), btp.p.WatchedTime, btp.p.VideoDuration)
 			}
 		}
 
@@ -863,36 +916,27 @@ func (btp *Player) UpdateWatched() {
 
 	// Update Watched state for current file
 	SetWatchedFile(btp.chosenFile.Path, btp.chosenFile.Size, btp.IsWatched())
+	btp.t.MarkPlaylistWatched(btp.chosenFile, btp.IsWatched())
 
 	if btp.IsWatched() {
-		var watched *trakt.WatchedItem
-
 		// TODO: Make use of Playcount, possibly increment when Watched, use old value if in progress
 		if btp.p.ContentType == movieType {
-			watched = &trakt.WatchedItem{
-				MediaType: btp.p.ContentType,
-				Movie:     btp.p.TMDBId,
-				Watched:   true,
-			}
 			if btp.p.KodiID != 0 && btp.xbmcHost != nil {
 				btp.xbmcHost.SetMovieWatched(btp.p.KodiID, 1, 0, 0)
 			}
 		} else if btp.p.ContentType == episodeType {
-			watched = &trakt.WatchedItem{
-				MediaType: btp.p.ContentType,
-				Show:      btp.p.ShowID,
-				Season:    btp.p.Season,
-				Episode:   btp.p.Episode,
-				Watched:   true,
-			}
 			if btp.p.KodiID != 0 && btp.xbmcHost != nil {
 				btp.xbmcHost.SetEpisodeWatched(btp.p.KodiID, 1, 0, 0)
 			}
 		}
 
-		if config.Get().TraktToken != "" && watched != nil && !btp.p.TraktScrobbled {
-			log.Debugf("Setting Trakt watched for: %#v", watched)
-			go trakt.SetWatched(watched)
+		if item := btp.syncItem(); item != nil && !btp.p.TraktScrobbled {
+			item.Watched = true
+			log.Debugf("Setting watched in sync backends for: %#v", item)
+			go library.SetWatched([]*library.SyncItem{item})
+		}
+		if config.Get().TraktToken != "" && config.Get().TraktRateAfterWatching && btp.xbmcHost != nil {
+			go btp.rateAfterWatching()
 		}
 	} else if btp.p.WatchedTime > 180 {
 		if btp.p.Resume != nil {
@@ -920,6 +964,60 @@ func (btp *Player) IsWatched() bool {
 	return btp.p.WatchedProgress > float64(config.Get().PlaybackPercent)
 }
 
+// syncItem returns current movie or episode for sync backends
+func (btp *Player) syncItem() *library.SyncItem {
+	if btp.p.ContentType == movieType && btp.p.TMDBId != 0 {
+		return &library.SyncItem{
+			MediaType: library.SyncMovie,
+			TMDB:      btp.p.TMDBId,
+		}
+	} else if btp.p.ContentType == episodeType && (btp.p.ShowID != 0 || btp.p.TMDBId != 0) {
+		return &library.SyncItem{
+			MediaType:   library.SyncEpisode,
+			TMDB:        btp.p.ShowID,
+			EpisodeTMDB: btp.p.TMDBId,
+			Season:      btp.p.Season,
+			Episode:     btp.p.Episode,
+		}
+	}
+	return nil
+}
+
+// traktItem returns current movie or episode for Trakt ratings and playback progress
+func (btp *Player) traktItem() *trakt.RatedItem {
+	if btp.p.ContentType == movieType && btp.p.TMDBId != 0 {
+		return &trakt.RatedItem{
+			MediaType: trakt.RatedMovie,
+			Movie:     btp.p.TMDBId,
+		}
+	} else if btp.p.ContentType == episodeType && btp.p.ShowID != 0 {
+		return &trakt.RatedItem{
+			MediaType: trakt.RatedEpisode,
+			Show:      btp.p.ShowID,
+			Season:    btp.p.Season,
+			Episode:   btp.p.Episode,
+		}
+	}
+	return nil
+}
+
+// rateAfterWatching asks user to rate watched movie or episode on Trakt
+func (btp *Player) rateAfterWatching() {
+	item := btp.traktItem()
+	if item == nil {
+		return
+	}
+
+	rating := trakt.SelectRating(btp.xbmcHost, "LOCALIZE[30699]")
+	if rating == 0 {
+		return
+	}
+
+	if _, err := trakt.RateItem(item, rating); err != nil && err != trakt.ErrQueued {
+		log.Warningf("Could not rate %s on Trakt: %s", item.MediaType, err)
+	}
+}
+
 func (btp *Player) smartMatch(choices []*CandidateFile) {
 	if !config.Get().SmartEpisodeMatch {
 		return
@@ -1055,7 +1153,7 @@ func (btp *Player) onStateChanged(stateAlert lt.StateChangedAlert) {
 }
 
 func (btp *Player) startNextFile() {
-	if (btp.p.ShowID == 0 && btp.p.Query == "") || !btp.t.HasNextFile || !btp.next.done || btp.next.f == nil || btp.t.IsBuffering || btp.next.started {
+	if (btp.p.ShowID == 0 && btp.p.Query == "" && !btp.t.HasPlaylist()) || !btp.t.HasNextFile || !btp.next.done || btp.next.f == nil || btp.t.IsBuffering || btp.next.started {
 		return
 	}
 
@@ -1064,14 +1162,23 @@ func (btp *Player) startNextFile() {
 }
 
 func (btp *Player) findNextFile() {
-	if (btp.p.ShowID == 0 && btp.p.Query == "") || btp.next.done || !config.Get().SmartEpisodeStart {
+	if btp.next.done {
+		return
+	}
+
+	// Playback queue defines the next file itself, otherwise we are guessing it
+	if item := btp.t.NextPlaylistItem(btp.chosenFile); item != nil {
+		btp.next.f = item.File
+	} else if (btp.p.ShowID == 0 && btp.p.Query == "") || !config.Get().SmartEpisodeStart {
 		return
 	}
 
 	// Set mark to avoid more than once
 	btp.next.done = true
 
-	if btp.p.ShowID != 0 {
+	if btp.next.f != nil {
+		log.Debugf("Next file is taken from the playback queue")
+	} else if btp.p.ShowID != 0 {
 		// Searching if we have next episode in the torrent
 		if btp.next.f == nil {
 			btp.next.f = btp.t.GetNextEpisodeFile(btp.p.Season, btp.p.Episode+1)
@@ -1255,6 +1362,18 @@ func (btp *Player) SaveStoredResume() {
 		database.GetCache().Delete(database.CommonBucket, key)
 	} else {
 		database.GetCache().SetCachedObject(database.CommonBucket, storedResumeExpiration, key, btp.p.StoredResume)
+
+		// Without scrobbling Trakt does not know about the position, so it is sent explicitly
+		if !btp.scrobble && config.Get().TraktToken != "" && config.Get().TraktSyncPlaybackProgress {
+			if item := btp.traktItem(); item != nil {
+				progress := btp.p.StoredResume.Position / btp.p.StoredResume.Total * 100
+				go func() {
+					if err := trakt.SetPlaybackProgress(item, progress); err != nil {
+						log.Warningf("Could not save playback progress to Trakt: %s", err)
+					}
+				}()
+			}
+		}
 	}
 }
 
@@ -1534,15 +1653,7 @@ func MatchEpisodeFilename(s, e int, isSingleSeason bool, activeSeason int, show
 	}
 
 	if found == 0 && show != nil && episode != nil && show.IsAnime() {
-		if an, _ := show.AnimeInfoWithShow(episode, tvdbShow); an != 0 {
-			re := regexp.MustCompile(fmt.Sprintf(singleEpisodeMatchRegex, an))
-			for i, choice := range choices {
-				if re.MatchString(choice.Filename) {
-					index = i
-					found++
-				}
-			}
-		}
+		index, found = matchAnimeEpisode(anime.EpisodeNumbers(show, tvdbShow, s, e), choices)
 	}
 
 	if found == 0 && activeSeason == s {
@@ -1558,6 +1669,33 @@ func MatchEpisodeFilename(s, e int, isSingleSeason bool, activeSeason int, show
 	return
 }
 
+// matchAnimeEpisode finds file by absolute or AniDB episode numbers,
+// files with single episode are preferred over batch ranges.
+func matchAnimeEpisode(numbers []int, choices []*CandidateFile) (index, found int) {
+	index = -1
+
+	for _, n := range numbers {
+		re := regexp.MustCompile(fmt.Sprintf(singleEpisodeMatchRegex, n))
+		for i, choice := range choices {
+			if from, _ := anime.ParseRange(choice.Filename); from == 0 && re.MatchString(choice.Filename) {
+				index = i
+				found++
+			}
+		}
+		if found > 0 {
+			return
+		}
+	}
+
+	for i, choice := range choices {
+		if anime.InRange(choice.Filename, numbers...) {
+			index = i
+			found++
+		}
+	}
+	return
+}
+
 func removeTrailingMinus(in string) string {
 	if strings.HasPrefix(in, "-") {
 		return in[1:]
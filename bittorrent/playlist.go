@@ -0,0 +1,325 @@
+package bittorrent
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/tvdb"
+	"github.com/elgatito/elementum/util"
+)
+
+// PlaylistItem is a torrent file, played as a part of multi-file playback queue
+type PlaylistItem struct {
+	File    *File  `json:"-"`
+	Index   int    `json:"index"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Label   string `json:"label"`
+	ShowID  int    `json:"show_id,omitempty"`
+	Season  int    `json:"season,omitempty"`
+	Episode int    `json:"episode,omitempty"`
+	Watched bool   `json:"watched"`
+}
+
+var (
+	errPlaylistPosition = errors.New("Playlist position is out of range")
+)
+
+// BuildPlaylist collects video files of the torrent in the playback order.
+// If showID is set, files are matched to TMDB episodes and sorted by season and episode,
+// otherwise files are sorted by path, comparing numbers by their value.
+func (t *Torrent) BuildPlaylist(showID int) []*PlaylistItem {
+	reSkip := regexp.MustCompile(skipFileRegex)
+	minSize := config.Get().MinCandidateSize
+
+	items := []*PlaylistItem{}
+	for _, f := range t.files {
+		name := filepath.Base(f.Path)
+		ext := filepath.Ext(name)
+		if f.Size <= minSize || reSkip.MatchString(name) || isRarVolume(name) || isBluRayFile(f.Path) ||
+			util.IsAudioExt(ext) || util.IsSubtitlesExt(ext) {
+			continue
+		}
+
+		items = append(items, &PlaylistItem{
+			File:    f,
+			Index:   f.Index,
+			Path:    f.Path,
+			Size:    f.Size,
+			Label:   name,
+			Watched: IsWatchedFile(f.Path, f.Size),
+		})
+	}
+
+	if showID != 0 {
+		t.matchPlaylistEpisodes(showID, items)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return playlistLess(items[i], items[j])
+	})
+
+	return items
+}
+
+// playlistLess orders matched episodes by season and episode before other files, which are ordered by path
+func playlistLess(a, b *PlaylistItem) bool {
+	if (a.Episode > 0) != (b.Episode > 0) {
+		return a.Episode > 0
+	} else if a.Episode > 0 && (a.Season != b.Season || a.Episode != b.Episode) {
+		if a.Season != b.Season {
+			return a.Season < b.Season
+		}
+		return a.Episode < b.Episode
+	}
+	return naturalLess(a.Path, b.Path)
+}
+
+// matchPlaylistEpisodes sets episode metadata for items, which file names match TMDB episodes
+func (t *Torrent) matchPlaylistEpisodes(showID int, items []*PlaylistItem) {
+	show := tmdb.GetShow(showID, config.Get().Language)
+	if show == nil {
+		return
+	}
+
+	var tvdbShow *tvdb.Show
+	if show.IsAnime() {
+		tvdbID := util.StrInterfaceToInt(show.ExternalIDs.TVDBID)
+		tvdbShow, _ = tvdb.GetShow(tvdbID, config.Get().Language)
+	}
+
+	choices := make([]*CandidateFile, 0, len(items))
+	for _, i := range items {
+		choices = append(choices, &CandidateFile{
+			Index:    i.Index,
+			Filename: filepath.Base(i.Path),
+			Path:     i.Path,
+			Size:     i.Size,
+		})
+	}
+
+	activeSeason := 0
+	if t.DBItem != nil && t.DBItem.ShowID == showID {
+		activeSeason = t.DBItem.Season
+	}
+
+	for _, season := range show.Seasons {
+		if season == nil || season.EpisodeCount == 0 {
+			continue
+		}
+		tmdbSeason := tmdb.GetSeason(showID, season.Season, config.Get().Language, len(show.Seasons))
+		if tmdbSeason == nil {
+			continue
+		}
+
+		for _, episode := range tmdbSeason.Episodes {
+			if episode == nil {
+				continue
+			}
+
+			index, found := MatchEpisodeFilename(season.Season, episode.EpisodeNumber, show.CountRealSeasons() == 1, activeSeason, show, episode, tvdbShow, choices)
+			if index < 0 || found != 1 || items[index].Episode > 0 {
+				continue
+			}
+
+			items[index].ShowID = showID
+			items[index].Season = season.Season
+			items[index].Episode = episode.EpisodeNumber
+			items[index].Label = fmt.Sprintf("S%02dE%02d - %s", season.Season, episode.EpisodeNumber, episode.Name)
+		}
+	}
+}
+
+// SetPlaylist replaces playback queue of the torrent and prioritizes queued files
+func (t *Torrent) SetPlaylist(items []*PlaylistItem) {
+	t.muPlaylist.Lock()
+	t.playlist = items
+	t.muPlaylist.Unlock()
+
+	t.prioritizePlaylist()
+}
+
+// GetPlaylist returns a copy of playback queue
+func (t *Torrent) GetPlaylist() []*PlaylistItem {
+	t.muPlaylist.Lock()
+	defer t.muPlaylist.Unlock()
+
+	return append([]*PlaylistItem{}, t.playlist...)
+}
+
+// HasPlaylist ...
+func (t *Torrent) HasPlaylist() bool {
+	t.muPlaylist.Lock()
+	defer t.muPlaylist.Unlock()
+
+	return len(t.playlist) > 0
+}
+
+// MovePlaylistItem moves queued item to another position
+func (t *Torrent) MovePlaylistItem(from, to int) error {
+	t.muPlaylist.Lock()
+	if from < 0 || from >= len(t.playlist) || to < 0 || to >= len(t.playlist) {
+		t.muPlaylist.Unlock()
+		return errPlaylistPosition
+	}
+
+	item := t.playlist[from]
+	t.playlist = append(t.playlist[:from], t.playlist[from+1:]...)
+	t.playlist = append(t.playlist[:to], append([]*PlaylistItem{item}, t.playlist[to:]...)...)
+	t.muPlaylist.Unlock()
+
+	t.prioritizePlaylist()
+	return nil
+}
+
+// RemovePlaylistItem removes item from the queue, file stays in the torrent
+func (t *Torrent) RemovePlaylistItem(pos int) error {
+	t.muPlaylist.Lock()
+	if pos < 0 || pos >= len(t.playlist) {
+		t.muPlaylist.Unlock()
+		return errPlaylistPosition
+	}
+
+	t.playlist = append(t.playlist[:pos], t.playlist[pos+1:]...)
+	t.muPlaylist.Unlock()
+
+	t.prioritizePlaylist()
+	return nil
+}
+
+// NextPlaylistItem returns queued item, which follows the file
+func (t *Torrent) NextPlaylistItem(f *File) *PlaylistItem {
+	if f == nil {
+		return nil
+	}
+
+	t.muPlaylist.Lock()
+	defer t.muPlaylist.Unlock()
+
+	for i, item := range t.playlist {
+		if item.File == f && i+1 < len(t.playlist) {
+			return t.playlist[i+1]
+		}
+	}
+	return nil
+}
+
+// SetPlaylistCurrent marks the file as currently played item of the queue
+func (t *Torrent) SetPlaylistCurrent(f *File) {
+	t.muPlaylist.Lock()
+	t.playlistCurrent = f
+	t.muPlaylist.Unlock()
+
+	t.prioritizePlaylist()
+}
+
+// prioritizePlaylist sets file priorities in the queue order, starting after the current file,
+// so that the next item is downloaded first, while the rest is downloaded in the background.
+// Current file is prioritized by the player.
+func (t *Torrent) prioritizePlaylist() {
+	if t.IsMemoryStorage() || t.th == nil {
+		return
+	}
+
+	t.muPlaylist.Lock()
+	items := append([]*PlaylistItem{}, t.playlist...)
+	current := t.playlistCurrent
+	t.muPlaylist.Unlock()
+
+	start := 0
+	for i, item := range items {
+		if item.File == current {
+			start = i + 1
+			break
+		}
+	}
+
+	for i := start; i < len(items); i++ {
+		priority := 1
+		if i == start && current != nil {
+			priority = 2
+		}
+		t.DownloadFileWithPriority(items[i].File, priority)
+	}
+}
+
+// MarkPlaylistWatched updates watched state of the queued file
+func (t *Torrent) MarkPlaylistWatched(f *File, watched bool) {
+	t.muPlaylist.Lock()
+	defer t.muPlaylist.Unlock()
+
+	for _, item := range t.playlist {
+		if item.File == f {
+			item.Watched = watched
+		}
+	}
+}
+
+// GetPlaylistItemURL returns plugin URL, which plays queued item with its episode metadata
+func (t *Torrent) GetPlaylistItemURL(item *PlaylistItem) string {
+	var contentType, show, season, episode string
+	if item.Episode > 0 {
+		contentType = episodeType
+		show = strconv.Itoa(item.ShowID)
+		season = strconv.Itoa(item.Season)
+		episode = strconv.Itoa(item.Episode)
+	}
+
+	return URLQuery(fmt.Sprintf(URLForXBMC("/play")+"/%s", url.PathEscape(item.Label)),
+		"resume", t.InfoHash(),
+		"oindex", strconv.Itoa(item.Index),
+		"type", contentType,
+		"show", show,
+		"season", season,
+		"episode", episode)
+}
+
+// naturalLess compares strings, treating digit sequences as numbers, so that "Part 2" goes before "Part 10".
+// Digit sequences are compared without leading zeros, by length and then lexically, so long numbers do not overflow.
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if isASCIIDigit(ra[i]) && isASCIIDigit(rb[j]) {
+			si := i
+			for i < len(ra) && isASCIIDigit(ra[i]) {
+				i++
+			}
+			sj := j
+			for j < len(rb) && isASCIIDigit(rb[j]) {
+				j++
+			}
+
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			} else if na != nb {
+				return na < nb
+			}
+			continue
+		}
+
+		ca, cb := unicode.ToLower(ra[i]), unicode.ToLower(rb[j])
+		if ca != cb {
+			return ca < cb
+		}
+		i++
+		j++
+	}
+
+	return len(ra)-i < len(rb)-j
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
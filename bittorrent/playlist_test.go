@@ -0,0 +1,105 @@
+package bittorrent
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "Part 2.mkv", b: "Part 10.mkv", want: true},
+		{a: "Part 10.mkv", b: "Part 2.mkv", want: false},
+		{a: "Part 02.mkv", b: "Part 10.mkv", want: true},
+		{a: "Part 007.mkv", b: "Part 7.mkv", want: false},
+		{a: "Part 7.mkv", b: "Part 007.mkv", want: false},
+		{a: "S01E09.mkv", b: "S01E10.mkv", want: true},
+		{a: "S02E01.mkv", b: "S01E10.mkv", want: false},
+		{a: "a.mkv", b: "B.mkv", want: true},
+		{a: "Disc 1/01.mkv", b: "Disc 1/01.mkv", want: false},
+		{a: "Part", b: "Part 1", want: true},
+		{a: "Part 1", b: "Part", want: false},
+		{a: "Track 99999999999999999999.flac", b: "Track 100000000000000000000.flac", want: true},
+		{a: "Track 100000000000000000000.flac", b: "Track 99999999999999999999.flac", want: false},
+		{a: "Track 18446744073709551617.flac", b: "Track 18446744073709551616.flac", want: false},
+		{a: "Part ٣.mkv", b: "Part 2.mkv", want: false},
+		{a: "Part 2.mkv", b: "Part ٣.mkv", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPlaylistOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []*PlaylistItem
+		want  []string
+	}{
+		{
+			name: "files",
+			items: []*PlaylistItem{
+				{Path: "Course/Lesson 10.mp4"},
+				{Path: "Course/lesson 2.mp4"},
+				{Path: "Course/Lesson 1.mp4"},
+				{Path: "Course/Extra/Lesson 1.mp4"},
+				{Path: "Course/Lesson 001.mp4"},
+			},
+			want: []string{
+				"Course/Extra/Lesson 1.mp4",
+				"Course/Lesson 1.mp4",
+				"Course/Lesson 001.mp4",
+				"Course/lesson 2.mp4",
+				"Course/Lesson 10.mp4",
+			},
+		},
+		{
+			name: "episodes",
+			items: []*PlaylistItem{
+				{Path: "Show/Sample.mkv"},
+				{Path: "Show/S02E01.mkv", Season: 2, Episode: 1},
+				{Path: "Show/S01E10.mkv", Season: 1, Episode: 10},
+				{Path: "Show/Bonus.mkv"},
+				{Path: "Show/S01E02.mkv", Season: 1, Episode: 2},
+			},
+			want: []string{
+				"Show/S01E02.mkv",
+				"Show/S01E10.mkv",
+				"Show/S02E01.mkv",
+				"Show/Bonus.mkv",
+				"Show/Sample.mkv",
+			},
+		},
+		{
+			name: "same episode",
+			items: []*PlaylistItem{
+				{Path: "Show/S01E01 Part 2.mkv", Season: 1, Episode: 1},
+				{Path: "Show/S01E01 Part 1.mkv", Season: 1, Episode: 1},
+			},
+			want: []string{
+				"Show/S01E01 Part 1.mkv",
+				"Show/S01E01 Part 2.mkv",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		sort.SliceStable(tt.items, func(i, j int) bool {
+			return playlistLess(tt.items[i], tt.items[j])
+		})
+
+		got := []string{}
+		for _, i := range tt.items {
+			got = append(got, i.Path)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: playlist order = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
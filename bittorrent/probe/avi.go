@@ -0,0 +1,130 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// aviMaxHeaderSize limits the size of hdrl list we read into memory
+const aviMaxHeaderSize = 4 * 1024 * 1024
+
+var errAviInvalid = errors.New("Invalid AVI header")
+
+type riffChunk struct {
+	id   string
+	data []byte
+}
+
+// riffChunks splits a buffer into RIFF chunks, LIST chunks keep their list type as first 4 bytes of data
+func riffChunks(data []byte) []riffChunk {
+	ret := []riffChunk{}
+	for pos := 0; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if size < 0 || pos+8+size > len(data) {
+			break
+		}
+
+		ret = append(ret, riffChunk{id: id, data: data[pos+8 : pos+8+size]})
+		// Chunks are word-aligned
+		pos += 8 + size + size%2
+	}
+	return ret
+}
+
+func probeAVI(r io.ReadSeeker, size int64) (*Info, error) {
+	// RIFF header (12 bytes) is followed by LIST hdrl
+	header := make([]byte, 12)
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[0:4]) != "LIST" || string(header[8:12]) != "hdrl" {
+		return nil, errAviInvalid
+	}
+
+	listSize := int64(binary.LittleEndian.Uint32(header[4:]))
+	if listSize < 4 || listSize > aviMaxHeaderSize {
+		return nil, errAviInvalid
+	}
+
+	hdrl := make([]byte, listSize-4)
+	if _, err := io.ReadFull(r, hdrl); err != nil {
+		return nil, err
+	}
+
+	info := &Info{Container: ContainerAVI}
+	width, height := 0, 0
+
+	for _, chunk := range riffChunks(hdrl) {
+		switch {
+		case chunk.id == "avih" && len(chunk.data) >= 40:
+			microSecPerFrame := binary.LittleEndian.Uint32(chunk.data[0:])
+			totalFrames := binary.LittleEndian.Uint32(chunk.data[16:])
+			info.Duration = time.Duration(uint64(totalFrames)*uint64(microSecPerFrame)) * time.Microsecond
+			width = int(binary.LittleEndian.Uint32(chunk.data[32:]))
+			height = int(binary.LittleEndian.Uint32(chunk.data[36:]))
+		case chunk.id == "LIST" && len(chunk.data) >= 4 && string(chunk.data[0:4]) == "strl":
+			aviParseStream(chunk.data[4:], info)
+		}
+	}
+
+	if v := info.MainVideo(); v != nil && v.Width == 0 {
+		v.Width, v.Height = width, height
+	}
+
+	return info, nil
+}
+
+func aviParseStream(data []byte, info *Info) {
+	var streamType, handler string
+	var format []byte
+	name := ""
+
+	for _, chunk := range riffChunks(data) {
+		switch chunk.id {
+		case "strh":
+			if len(chunk.data) >= 8 {
+				streamType = string(chunk.data[0:4])
+				handler = string(chunk.data[4:8])
+			}
+		case "strf":
+			format = chunk.data
+		case "strn":
+			name = strings.TrimRight(string(chunk.data), "\x00")
+		}
+	}
+
+	track := &Track{Name: name}
+	switch streamType {
+	case "vids":
+		// BITMAPINFOHEADER
+		if len(format) >= 20 {
+			track.Width = int(int32(binary.LittleEndian.Uint32(format[4:])))
+			track.Height = int(int32(binary.LittleEndian.Uint32(format[8:])))
+			if track.Height < 0 {
+				track.Height = -track.Height
+			}
+			handler = string(format[16:20])
+		}
+		track.Codec = codecName(handler)
+		info.Video = append(info.Video, track)
+	case "auds":
+		// WAVEFORMATEX
+		if len(format) >= 4 {
+			tag := binary.LittleEndian.Uint16(format[0:])
+			track.Channels = int(binary.LittleEndian.Uint16(format[2:]))
+			if codec, ok := audioFormatTags[tag]; ok {
+				track.Codec = codec
+			}
+		}
+		info.Audio = append(info.Audio, track)
+	case "txts":
+		info.Subtitles = append(info.Subtitles, track)
+	}
+}
@@ -0,0 +1,489 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	mkvEBML                    = 0x1A45DFA3
+	mkvDocType                 = 0x4282
+	mkvSegment                 = 0x18538067
+	mkvSeekHead                = 0x114D9B74
+	mkvSeek                    = 0x4DBB
+	mkvSeekID                  = 0x53AB
+	mkvSeekPosition            = 0x53AC
+	mkvInfo                    = 0x1549A966
+	mkvTimecodeScale           = 0x2AD7B1
+	mkvDuration                = 0x4489
+	mkvTracks                  = 0x1654AE6B
+	mkvTrackEntry              = 0xAE
+	mkvTrackType               = 0x83
+	mkvCodecID                 = 0x86
+	mkvLanguage                = 0x22B59C
+	mkvLanguageBCP47           = 0x22B59D
+	mkvName                    = 0x536E
+	mkvFlagDefault             = 0x88
+	mkvFlagForced              = 0x55AA
+	mkvVideo                   = 0xE0
+	mkvPixelWidth              = 0xB0
+	mkvPixelHeight             = 0xBA
+	mkvColour                  = 0x55B0
+	mkvTransferCharacteristics = 0x55BA
+	mkvBlockAdditionMapping    = 0x41E4
+	mkvBlockAddIDType          = 0x41E7
+	mkvAudio                   = 0xE1
+	mkvChannels                = 0x9F
+	mkvCluster                 = 0x1F43B675
+
+	mkvTrackTypeVideo    = 1
+	mkvTrackTypeAudio    = 2
+	mkvTrackTypeSubtitle = 17
+
+	// mkvMaxElementSize limits the size of elements we read into memory
+	mkvMaxElementSize = 16 * 1024 * 1024
+	// mkvUnknownSize is a marker of elements with unknown size, used for live streams
+	mkvUnknownSize = -1
+)
+
+var errMkvInvalid = errors.New("Invalid Matroska element")
+
+type ebmlElement struct {
+	id     uint64
+	size   int64
+	offset int64 // offset of element data
+}
+
+// ebmlReader reads EBML elements from a seekable reader
+type ebmlReader struct {
+	r   io.ReadSeeker
+	pos int64
+}
+
+func (e *ebmlReader) seek(pos int64) error {
+	if _, err := e.r.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+	e.pos = pos
+	return nil
+}
+
+func (e *ebmlReader) readByte() (byte, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(e.r, b); err != nil {
+		return 0, err
+	}
+	e.pos++
+	return b[0], nil
+}
+
+// readVint reads variable-size integer, keeping length marker if needed (for IDs).
+func (e *ebmlReader) readVint(keepMarker bool) (uint64, int, error) {
+	first, err := e.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= 8 && first&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, errMkvInvalid
+	}
+
+	value := uint64(first)
+	if !keepMarker {
+		value &= uint64(0xFF >> uint(length))
+	}
+	for i := 1; i < length; i++ {
+		b, err := e.readByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		value = value<<8 | uint64(b)
+	}
+
+	return value, length, nil
+}
+
+func (e *ebmlReader) next() (*ebmlElement, error) {
+	id, _, err := e.readVint(true)
+	if err != nil {
+		return nil, err
+	}
+	size, length, err := e.readVint(false)
+	if err != nil {
+		return nil, err
+	}
+
+	el := &ebmlElement{id: id, size: int64(size), offset: e.pos}
+	// All data bits set means unknown size
+	if size == (uint64(1)<<uint(7*length))-1 {
+		el.size = mkvUnknownSize
+	}
+	return el, nil
+}
+
+func (e *ebmlReader) readData(el *ebmlElement) ([]byte, error) {
+	if el.size < 0 || el.size > mkvMaxElementSize {
+		return nil, errMkvInvalid
+	}
+
+	buf := make([]byte, el.size)
+	if _, err := io.ReadFull(e.r, buf); err != nil {
+		return nil, err
+	}
+	e.pos += el.size
+	return buf, nil
+}
+
+func (e *ebmlReader) skip(el *ebmlElement) error {
+	if el.size < 0 {
+		return errMkvInvalid
+	}
+	return e.seek(el.offset + el.size)
+}
+
+// children iterates over child elements of a master element
+func (e *ebmlReader) children(parent *ebmlElement, fn func(el *ebmlElement) error) error {
+	end := parent.offset + parent.size
+	for parent.size < 0 || e.pos < end {
+		el, err := e.next()
+		if err != nil {
+			return err
+		}
+
+		before := e.pos
+		if err := fn(el); err != nil {
+			return err
+		}
+		// Skip element if callback did not consume it
+		if e.pos == before {
+			if err := e.skip(el); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ebmlUint(data []byte) uint64 {
+	var ret uint64
+	for _, b := range data {
+		ret = ret<<8 | uint64(b)
+	}
+	return ret
+}
+
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+func ebmlString(data []byte) string {
+	// Strings can be zero-padded
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i])
+		}
+	}
+	return string(data)
+}
+
+func probeMatroska(r io.ReadSeeker, size int64) (*Info, error) {
+	e := &ebmlReader{r: r}
+	if err := e.seek(0); err != nil {
+		return nil, err
+	}
+
+	header, err := e.next()
+	if err != nil {
+		return nil, err
+	} else if header.id != mkvEBML {
+		return nil, ErrUnknownContainer
+	}
+	if err := e.children(header, func(el *ebmlElement) error {
+		if el.id != mkvDocType {
+			return nil
+		}
+		data, err := e.readData(el)
+		if err != nil {
+			return err
+		}
+		if doc := ebmlString(data); doc != "matroska" && doc != "webm" {
+			return ErrUnknownContainer
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	segment, err := e.next()
+	if err != nil {
+		return nil, err
+	} else if segment.id != mkvSegment {
+		return nil, errMkvInvalid
+	}
+
+	info := &Info{Container: ContainerMatroska}
+	m := &matroskaParser{e: e, info: info, segment: segment, timecodeScale: 1000000}
+
+	// Walk top-level elements until the first cluster,
+	// then follow SeekHead for Info and Tracks, if they are located after clusters.
+	err = e.children(segment, func(el *ebmlElement) error {
+		switch el.id {
+		case mkvSeekHead:
+			return m.parseSeekHead(el)
+		case mkvInfo:
+			m.hasInfo = true
+			return m.parseInfo(el)
+		case mkvTracks:
+			m.hasTracks = true
+			return m.parseTracks(el)
+		case mkvCluster:
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if !m.hasInfo && m.infoPosition > 0 {
+		if el, err := m.elementAt(m.infoPosition, mkvInfo); err == nil {
+			m.parseInfo(el)
+		}
+	}
+	if !m.hasTracks && m.tracksPosition > 0 {
+		if el, err := m.elementAt(m.tracksPosition, mkvTracks); err == nil {
+			if err := m.parseTracks(el); err != nil {
+				return info, err
+			}
+		}
+	}
+
+	if m.duration > 0 {
+		info.Duration = time.Duration(m.duration * float64(m.timecodeScale))
+	}
+
+	return info, nil
+}
+
+type matroskaParser struct {
+	e       *ebmlReader
+	info    *Info
+	segment *ebmlElement
+
+	hasInfo        bool
+	hasTracks      bool
+	infoPosition   int64
+	tracksPosition int64
+
+	timecodeScale uint64
+	duration      float64
+}
+
+func (m *matroskaParser) elementAt(pos int64, id uint64) (*ebmlElement, error) {
+	if err := m.e.seek(m.segment.offset + pos); err != nil {
+		return nil, err
+	}
+	el, err := m.e.next()
+	if err != nil {
+		return nil, err
+	} else if el.id != id {
+		return nil, errMkvInvalid
+	}
+	return el, nil
+}
+
+func (m *matroskaParser) parseSeekHead(parent *ebmlElement) error {
+	return m.e.children(parent, func(seek *ebmlElement) error {
+		if seek.id != mkvSeek {
+			return nil
+		}
+
+		var id uint64
+		var pos int64
+		err := m.e.children(seek, func(el *ebmlElement) error {
+			data, err := m.e.readData(el)
+			if err != nil {
+				return err
+			}
+			switch el.id {
+			case mkvSeekID:
+				id = ebmlUint(data)
+			case mkvSeekPosition:
+				pos = int64(ebmlUint(data))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case mkvInfo:
+			m.infoPosition = pos
+		case mkvTracks:
+			m.tracksPosition = pos
+		}
+		return nil
+	})
+}
+
+func (m *matroskaParser) parseInfo(parent *ebmlElement) error {
+	return m.e.children(parent, func(el *ebmlElement) error {
+		if el.id != mkvTimecodeScale && el.id != mkvDuration {
+			return nil
+		}
+
+		data, err := m.e.readData(el)
+		if err != nil {
+			return err
+		}
+		if el.id == mkvTimecodeScale {
+			if scale := ebmlUint(data); scale > 0 {
+				m.timecodeScale = scale
+			}
+		} else {
+			m.duration = ebmlFloat(data)
+		}
+		return nil
+	})
+}
+
+func (m *matroskaParser) parseTracks(parent *ebmlElement) error {
+	return m.e.children(parent, func(entry *ebmlElement) error {
+		if entry.id != mkvTrackEntry {
+			return nil
+		}
+
+		track := &Track{Language: "eng", Default: true}
+		trackType := uint64(0)
+		codecID := ""
+		bcp47 := ""
+
+		err := m.e.children(entry, func(el *ebmlElement) error {
+			switch el.id {
+			case mkvVideo:
+				return m.parseVideo(el, track)
+			case mkvAudio:
+				return m.e.children(el, func(a *ebmlElement) error {
+					if a.id != mkvChannels {
+						return nil
+					}
+					data, err := m.e.readData(a)
+					if err != nil {
+						return err
+					}
+					track.Channels = int(ebmlUint(data))
+					return nil
+				})
+			case mkvBlockAdditionMapping:
+				return m.e.children(el, func(a *ebmlElement) error {
+					if a.id != mkvBlockAddIDType {
+						return nil
+					}
+					data, err := m.e.readData(a)
+					if err != nil {
+						return err
+					}
+					if t := ebmlUint(data); t == fourcc("dvcC") || t == fourcc("dvvC") {
+						track.HDR = DolbyVision
+					}
+					return nil
+				})
+			case mkvTrackType, mkvCodecID, mkvLanguage, mkvLanguageBCP47, mkvName, mkvFlagDefault, mkvFlagForced:
+			default:
+				return nil
+			}
+
+			data, err := m.e.readData(el)
+			if err != nil {
+				return err
+			}
+			switch el.id {
+			case mkvTrackType:
+				trackType = ebmlUint(data)
+			case mkvCodecID:
+				codecID = ebmlString(data)
+			case mkvLanguage:
+				track.Language = ebmlString(data)
+			case mkvLanguageBCP47:
+				bcp47 = ebmlString(data)
+			case mkvName:
+				track.Name = ebmlString(data)
+			case mkvFlagDefault:
+				track.Default = ebmlUint(data) == 1
+			case mkvFlagForced:
+				track.Forced = ebmlUint(data) == 1
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// LanguageBCP47 takes precedence over Language, when present
+		if bcp47 != "" {
+			track.Language = bcp47
+		}
+		track.Codec = codecName(codecID)
+
+		switch trackType {
+		case mkvTrackTypeVideo:
+			m.info.Video = append(m.info.Video, track)
+		case mkvTrackTypeAudio:
+			m.info.Audio = append(m.info.Audio, track)
+		case mkvTrackTypeSubtitle:
+			m.info.Subtitles = append(m.info.Subtitles, track)
+		}
+		return nil
+	})
+}
+
+func (m *matroskaParser) parseVideo(parent *ebmlElement, track *Track) error {
+	return m.e.children(parent, func(el *ebmlElement) error {
+		switch el.id {
+		case mkvColour:
+			return m.e.children(el, func(c *ebmlElement) error {
+				if c.id != mkvTransferCharacteristics {
+					return nil
+				}
+				data, err := m.e.readData(c)
+				if err != nil {
+					return err
+				}
+				// Dolby Vision is more specific, so don't override it
+				if hdr := hdrFromTransfer(ebmlUint(data)); hdr != "" && track.HDR == "" {
+					track.HDR = hdr
+				}
+				return nil
+			})
+		case mkvPixelWidth, mkvPixelHeight:
+			data, err := m.e.readData(el)
+			if err != nil {
+				return err
+			}
+			if el.id == mkvPixelWidth {
+				track.Width = int(ebmlUint(data))
+			} else {
+				track.Height = int(ebmlUint(data))
+			}
+		}
+		return nil
+	})
+}
+
+func fourcc(s string) uint64 {
+	return uint64(binary.BigEndian.Uint32([]byte(s)))
+}
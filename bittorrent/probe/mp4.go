@@ -0,0 +1,307 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// mp4MaxMoovSize limits the size of moov atom we read into memory
+	mp4MaxMoovSize = 64 * 1024 * 1024
+	// mp4VisualEntryHeader is a size of VisualSampleEntry fields before child boxes
+	mp4VisualEntryHeader = 78
+)
+
+var errMp4Invalid = errors.New("Invalid MP4 box")
+
+type mp4Box struct {
+	kind string
+	data []byte
+}
+
+// mp4Boxes splits a buffer into child boxes
+func mp4Boxes(data []byte) []mp4Box {
+	ret := []mp4Box{}
+	for pos := 0; pos+8 <= len(data); {
+		size := int64(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		header := 8
+
+		if size == 1 {
+			if pos+16 > len(data) {
+				break
+			}
+			size = int64(binary.BigEndian.Uint64(data[pos+8:]))
+			header = 16
+		} else if size == 0 {
+			size = int64(len(data) - pos)
+		}
+		if size < int64(header) || int64(pos)+size > int64(len(data)) {
+			break
+		}
+
+		ret = append(ret, mp4Box{
+			kind: kind,
+			data: data[pos+header : pos+int(size)],
+		})
+		pos += int(size)
+	}
+	return ret
+}
+
+func mp4Find(data []byte, kind string) *mp4Box {
+	for _, b := range mp4Boxes(data) {
+		if b.kind == kind {
+			return &b
+		}
+	}
+	return nil
+}
+
+// mp4WalkTopLevel iterates over top-level boxes, reading only box headers.
+// Callback returns true to stop the iteration.
+func mp4WalkTopLevel(r io.ReadSeeker, size int64, fn func(kind string, offset, boxSize, headerSize int64) (bool, error)) error {
+	header := make([]byte, 16)
+	for pos := int64(0); pos+8 <= size; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+		if boxSize == 1 {
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		} else if boxSize == 0 {
+			boxSize = size - pos
+		}
+		if boxSize < headerSize {
+			return errMp4Invalid
+		}
+
+		if stop, err := fn(kind, pos, boxSize, headerSize); err != nil || stop {
+			return err
+		}
+
+		pos += boxSize
+	}
+
+	return nil
+}
+
+// LocateIndex returns position of MP4 moov atom, if it is located after media data.
+// For other containers, or for files with index in the beginning, it returns zero size.
+func LocateIndex(r io.ReadSeeker, size int64) (offset int64, length int64, err error) {
+	header := make([]byte, 8)
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+	if kind := string(header[4:8]); kind != "ftyp" && kind != "moov" && kind != "free" {
+		return
+	}
+
+	seenMdat := false
+	err = mp4WalkTopLevel(r, size, func(kind string, pos, boxSize, headerSize int64) (bool, error) {
+		if kind == "mdat" {
+			seenMdat = true
+		} else if kind == "moov" {
+			if seenMdat {
+				offset, length = pos, boxSize
+			}
+			return true, nil
+		}
+		return false, nil
+	})
+	return
+}
+
+func probeMP4(r io.ReadSeeker, size int64) (*Info, error) {
+	info := &Info{Container: ContainerMP4}
+
+	var moov []byte
+	seenMdat := false
+	err := mp4WalkTopLevel(r, size, func(kind string, pos, boxSize, headerSize int64) (bool, error) {
+		if kind == "mdat" {
+			seenMdat = true
+			return false, nil
+		} else if kind != "moov" {
+			return false, nil
+		}
+
+		// Index after media data should be downloaded before the playback can start
+		if seenMdat {
+			info.IndexOffset = pos
+			info.IndexSize = boxSize
+		}
+
+		if boxSize-headerSize > mp4MaxMoovSize {
+			return true, errMp4Invalid
+		}
+		moov = make([]byte, boxSize-headerSize)
+		if _, err := io.ReadFull(r, moov); err != nil {
+			return true, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return info, err
+	} else if moov == nil {
+		return info, errMp4Invalid
+	}
+
+	for _, box := range mp4Boxes(moov) {
+		switch box.kind {
+		case "mvhd":
+			info.Duration = mp4ParseDuration(box.data, 12, 16)
+		case "trak":
+			mp4ParseTrak(box.data, info)
+		}
+	}
+
+	return info, nil
+}
+
+// mp4ParseDuration reads timescale and duration from mvhd/mdhd full box,
+// offsets are given for version 0, version 1 uses 64-bit times.
+func mp4ParseDuration(data []byte, timescaleOffset, durationOffset int) time.Duration {
+	if len(data) < 4 {
+		return 0
+	}
+
+	var timescale, duration uint64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data[20:]))
+		duration = binary.BigEndian.Uint64(data[24:])
+	} else {
+		if len(data) < durationOffset+4 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data[timescaleOffset:]))
+		duration = uint64(binary.BigEndian.Uint32(data[durationOffset:]))
+	}
+
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
+
+func mp4ParseTrak(data []byte, info *Info) {
+	track := &Track{}
+
+	if tkhd := mp4Find(data, "tkhd"); tkhd != nil && len(tkhd.data) >= 84 {
+		// Flags: track_enabled = 1, track_in_movie = 2
+		track.Default = tkhd.data[3]&0x1 != 0
+		end := len(tkhd.data)
+		track.Width = int(binary.BigEndian.Uint32(tkhd.data[end-8:]) >> 16)
+		track.Height = int(binary.BigEndian.Uint32(tkhd.data[end-4:]) >> 16)
+	}
+
+	mdia := mp4Find(data, "mdia")
+	if mdia == nil {
+		return
+	}
+
+	if mdhd := mp4Find(mdia.data, "mdhd"); mdhd != nil {
+		offset := 20
+		if len(mdhd.data) > 0 && mdhd.data[0] == 1 {
+			offset = 32
+		}
+		if len(mdhd.data) >= offset+2 {
+			track.Language = mp4Language(binary.BigEndian.Uint16(mdhd.data[offset:]))
+		}
+	}
+
+	handler := ""
+	if hdlr := mp4Find(mdia.data, "hdlr"); hdlr != nil && len(hdlr.data) >= 12 {
+		handler = string(hdlr.data[8:12])
+	}
+
+	var entry *mp4Box
+	if minf := mp4Find(mdia.data, "minf"); minf != nil {
+		if stbl := mp4Find(minf.data, "stbl"); stbl != nil {
+			// stsd is a full box with entry count before sample entries
+			if stsd := mp4Find(stbl.data, "stsd"); stsd != nil && len(stsd.data) > 8 {
+				if entries := mp4Boxes(stsd.data[8:]); len(entries) > 0 {
+					entry = &entries[0]
+				}
+			}
+		}
+	}
+	if entry != nil {
+		track.Codec = codecName(entry.kind)
+	}
+
+	switch handler {
+	case "vide":
+		if entry != nil {
+			mp4ParseVisualEntry(entry.data, track)
+		}
+		info.Video = append(info.Video, track)
+	case "soun":
+		if entry != nil && len(entry.data) >= 18 {
+			track.Channels = int(binary.BigEndian.Uint16(entry.data[16:]))
+		}
+		track.Width, track.Height = 0, 0
+		info.Audio = append(info.Audio, track)
+	case "sbtl", "subt", "text":
+		track.Width, track.Height = 0, 0
+		info.Subtitles = append(info.Subtitles, track)
+	}
+}
+
+func mp4ParseVisualEntry(data []byte, track *Track) {
+	if len(data) < mp4VisualEntryHeader {
+		return
+	}
+
+	if w, h := int(binary.BigEndian.Uint16(data[24:])), int(binary.BigEndian.Uint16(data[26:])); w > 0 && h > 0 {
+		track.Width, track.Height = w, h
+	}
+
+	for _, box := range mp4Boxes(data[mp4VisualEntryHeader:]) {
+		switch box.kind {
+		case "dvcC", "dvvC":
+			track.HDR = DolbyVision
+		case "colr":
+			// nclx: colour_type(4) primaries(2) transfer(2) matrix(2)
+			if len(box.data) >= 8 && string(box.data[0:4]) == "nclx" && track.HDR == "" {
+				track.HDR = hdrFromTransfer(uint64(binary.BigEndian.Uint16(box.data[6:])))
+			}
+		}
+	}
+}
+
+// mp4Language decodes packed ISO 639-2/T language code
+func mp4Language(packed uint16) string {
+	if packed == 0 || packed == 0x7FFF {
+		return ""
+	}
+
+	b := []byte{
+		byte((packed>>10)&0x1F) + 0x60,
+		byte((packed>>5)&0x1F) + 0x60,
+		byte(packed&0x1F) + 0x60,
+	}
+	for _, c := range b {
+		if c < 'a' || c > 'z' {
+			return ""
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,325 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+var log = logging.MustGetLogger("probe")
+
+const (
+	// ContainerMatroska ...
+	ContainerMatroska = "matroska"
+	// ContainerMP4 ...
+	ContainerMP4 = "mp4"
+	// ContainerAVI ...
+	ContainerAVI = "avi"
+
+	// HDR10 ...
+	HDR10 = "hdr10"
+	// HLG ...
+	HLG = "hlg"
+	// DolbyVision ...
+	DolbyVision = "dolbyvision"
+)
+
+var (
+	// ErrUnknownContainer is returned when file header does not match any supported container
+	ErrUnknownContainer = errors.New("Unknown container format")
+)
+
+// Info contains media information read from container headers
+type Info struct {
+	Container string        `json:"container"`
+	Duration  time.Duration `json:"duration"`
+	Video     []*Track      `json:"video,omitempty"`
+	Audio     []*Track      `json:"audio,omitempty"`
+	Subtitles []*Track      `json:"subtitles,omitempty"`
+
+	// IndexOffset and IndexSize point to the index (MP4 moov atom),
+	// when it is located after media data, so it should be downloaded before playback.
+	IndexOffset int64 `json:"index_offset,omitempty"`
+	IndexSize   int64 `json:"index_size,omitempty"`
+}
+
+// Track describes single stream in a container
+type Track struct {
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Default  bool   `json:"default,omitempty"`
+	Forced   bool   `json:"forced,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	HDR      string `json:"hdr,omitempty"`
+	Channels int    `json:"channels,omitempty"`
+}
+
+// Probe detects container format by reading the header and extracts media information.
+// Reader is expected to block until requested data is available.
+func Probe(r io.ReadSeeker, size int64) (*Info, error) {
+	header := make([]byte, 12)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	var info *Info
+	var err error
+	switch {
+	case header[0] == 0x1A && header[1] == 0x45 && header[2] == 0xDF && header[3] == 0xA3:
+		info, err = probeMatroska(r, size)
+	case string(header[4:8]) == "ftyp" || string(header[4:8]) == "moov" || string(header[4:8]) == "free":
+		info, err = probeMP4(r, size)
+	case string(header[0:4]) == "RIFF" && string(header[8:12]) == "AVI ":
+		info, err = probeAVI(r, size)
+	default:
+		return nil, ErrUnknownContainer
+	}
+
+	if err != nil {
+		return info, err
+	}
+
+	log.Debugf("Probed %s", info)
+	return info, nil
+}
+
+// String returns short description of media information, used for logging and dialogs.
+func (i *Info) String() string {
+	if i == nil {
+		return ""
+	}
+
+	parts := []string{i.Container}
+	if i.Duration > 0 {
+		parts = append(parts, i.Duration.Round(time.Second).String())
+	}
+	if v := i.MainVideo(); v != nil {
+		video := fmt.Sprintf("%s %dx%d", v.Codec, v.Width, v.Height)
+		if v.HDR != "" {
+			video += " " + v.HDR
+		}
+		parts = append(parts, video)
+	}
+	if langs := i.AudioLanguages(); len(langs) > 0 {
+		parts = append(parts, "audio: "+strings.Join(langs, ","))
+	}
+	if langs := i.SubtitleLanguages(); len(langs) > 0 {
+		parts = append(parts, "subs: "+strings.Join(langs, ","))
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// MainVideo returns first video track
+func (i *Info) MainVideo() *Track {
+	if i == nil || len(i.Video) == 0 {
+		return nil
+	}
+
+	return i.Video[0]
+}
+
+// MainAudio returns default audio track, or the first one
+func (i *Info) MainAudio() *Track {
+	if i == nil || len(i.Audio) == 0 {
+		return nil
+	}
+
+	for _, t := range i.Audio {
+		if t.Default {
+			return t
+		}
+	}
+	return i.Audio[0]
+}
+
+// AudioLanguages returns distinct languages of audio tracks
+func (i *Info) AudioLanguages() []string {
+	if i == nil {
+		return nil
+	}
+	return languages(i.Audio)
+}
+
+// SubtitleLanguages returns distinct languages of subtitle tracks
+func (i *Info) SubtitleLanguages() []string {
+	if i == nil {
+		return nil
+	}
+	return languages(i.Subtitles)
+}
+
+// HasAudioLanguage checks whether any of audio tracks matches the language.
+// Language can be ISO 639-1 or ISO 639-2 code.
+func (i *Info) HasAudioLanguage(lang string) bool {
+	if i == nil || lang == "" {
+		return false
+	}
+
+	for _, t := range i.Audio {
+		if SameLanguage(t.Language, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+func languages(tracks []*Track) []string {
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, t := range tracks {
+		if t.Language == "" || t.Language == "und" || seen[t.Language] {
+			continue
+		}
+		seen[t.Language] = true
+		ret = append(ret, t.Language)
+	}
+	return ret
+}
+
+// SameLanguage compares language codes in ISO 639-1 or ISO 639-2 (B or T) forms
+func SameLanguage(a, b string) bool {
+	a = normalizeLanguage(a)
+	b = normalizeLanguage(b)
+	return a != "" && a == b
+}
+
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	// Cut off BCP 47 region part
+	if idx := strings.IndexAny(lang, "-_"); idx != -1 {
+		lang = lang[:idx]
+	}
+	if l, ok := iso6392to1[lang]; ok {
+		return l
+	}
+	return lang
+}
+
+// iso6392to1 maps ISO 639-2 (both B and T) codes of common languages to ISO 639-1
+var iso6392to1 = map[string]string{
+	"ara": "ar", "bul": "bg", "cat": "ca", "ces": "cs", "cze": "cs", "chi": "zh", "zho": "zh",
+	"dan": "da", "deu": "de", "ger": "de", "ell": "el", "gre": "el", "eng": "en", "spa": "es",
+	"est": "et", "fas": "fa", "per": "fa", "fin": "fi", "fra": "fr", "fre": "fr", "heb": "he",
+	"hin": "hi", "hrv": "hr", "hun": "hu", "ind": "id", "ita": "it", "jpn": "ja", "kor": "ko",
+	"lit": "lt", "lav": "lv", "nld": "nl", "dut": "nl", "nor": "no", "nob": "nb", "pol": "pl",
+	"por": "pt", "ron": "ro", "rum": "ro", "rus": "ru", "slk": "sk", "slo": "sk", "slv": "sl",
+	"srp": "sr", "swe": "sv", "tha": "th", "tur": "tr", "ukr": "uk", "vie": "vi",
+}
+
+// codecNames maps container codec identifiers to names that Kodi uses in stream details
+var codecNames = map[string]string{
+	// Matroska
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_AV1":            "av1",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_MPEG4/ISO/SP":   "mpeg4",
+	"V_MPEG2":          "mpeg2video",
+	"V_MS/VFW/FOURCC":  "vfw",
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_DTS/EXPRESS":    "dts",
+	"A_DTS/LOSSLESS":   "dtshd_ma",
+	"A_TRUEHD":         "truehd",
+	"A_FLAC":           "flac",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_MPEG/L3":        "mp3",
+	"A_MPEG/L2":        "mp2",
+	"A_PCM/INT/LIT":    "pcm",
+	"S_TEXT/UTF8":      "srt",
+	"S_TEXT/ASS":       "ass",
+	"S_TEXT/SSA":       "ssa",
+	"S_TEXT/WEBVTT":    "webvtt",
+	"S_HDMV/PGS":       "pgs",
+	"S_VOBSUB":         "dvdsub",
+
+	// MP4 sample entries
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "hevc",
+	"hev1": "hevc",
+	"dvh1": "hevc",
+	"dvhe": "hevc",
+	"av01": "av1",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"dtsc": "dts",
+	"dtsh": "dtshd_ma",
+	"dtsl": "dtshd_ma",
+	"mlpa": "truehd",
+	"fLaC": "flac",
+	"Opus": "opus",
+	"tx3g": "mov_text",
+	"wvtt": "webvtt",
+	"stpp": "ttml",
+
+	// AVI fourcc
+	"XVID": "xvid",
+	"xvid": "xvid",
+	"DIVX": "mpeg4",
+	"divx": "mpeg4",
+	"DX50": "mpeg4",
+	"FMP4": "mpeg4",
+	"H264": "h264",
+	"h264": "h264",
+	"X264": "h264",
+	"x264": "h264",
+	"HEVC": "hevc",
+	"MJPG": "mjpeg",
+}
+
+// audioFormatTags maps WAVEFORMATEX format tags to Kodi codec names
+var audioFormatTags = map[uint16]string{
+	0x0001: "pcm",
+	0x0050: "mp2",
+	0x0055: "mp3",
+	0x00FF: "aac",
+	0x2000: "ac3",
+	0x2001: "dts",
+	0x706D: "aac",
+	0xF1AC: "flac",
+}
+
+func codecName(id string) string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+
+	// Some codec IDs have profile suffixes, like A_AAC/MPEG4/LC
+	for prefix, name := range codecNames {
+		if strings.HasPrefix(id, prefix+"/") {
+			return name
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// hdrFromTransfer returns HDR type from transfer characteristics value (ITU-T H.273)
+func hdrFromTransfer(transfer uint64) string {
+	switch transfer {
+	case 16:
+		return HDR10
+	case 18:
+		return HLG
+	}
+	return ""
+}
@@ -1,39 +1,94 @@
 package bittorrent
 
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+)
+
+const (
+	// QueuePriorityLow ...
+	QueuePriorityLow = -1
+	// QueuePriorityNormal ...
+	QueuePriorityNormal = 0
+	// QueuePriorityHigh ...
+	QueuePriorityHigh = 1
+)
+
+const (
+	// QueueMoveUp ...
+	QueueMoveUp = "up"
+	// QueueMoveDown ...
+	QueueMoveDown = "down"
+	// QueueMoveTop ...
+	QueueMoveTop = "top"
+	// QueueMoveBottom ...
+	QueueMoveBottom = "bottom"
+)
+
+var (
+	errQueueNotFound = errors.New("Torrent is not in the queue")
+	errQueueMove     = errors.New("Unknown queue move direction")
+)
+
 // Queue represents list of torrents inside of a session
 type Queue struct {
 	s        *Service
 	torrents []*Torrent
+
+	mu      sync.RWMutex
+	muApply sync.Mutex
 }
 
 // NewQueue contructor for empty Queue
 func NewQueue(s *Service) *Queue {
 	return &Queue{
-		s,
-		[]*Torrent{},
+		s:        s,
+		torrents: []*Torrent{},
 	}
 }
 
-// Add torrent to the queue
+// Add torrent to the queue, torrent takes its saved position, or goes to the end
 func (q *Queue) Add(t *Torrent) bool {
 	if q.FindByHash(t.InfoHash()) != nil {
 		return false
 	}
 
-	q.torrents = append(q.torrents, t)
+	if item := database.GetStorm().GetBTItem(t.InfoHash()); item != nil {
+		t.QueuePosition = item.QueuePosition
+		t.QueuePriority = item.Priority
+		t.IsQueued = item.Queued
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Torrents without saved position are appended, so the slice is not sorted by position
+	idx := len(q.torrents)
+	if t.QueuePosition > 0 {
+		for i, ti := range q.torrents {
+			if ti.QueuePosition == 0 || ti.QueuePosition > t.QueuePosition {
+				idx = i
+				break
+			}
+		}
+	}
+
+	q.torrents = append(q.torrents, nil)
+	copy(q.torrents[idx+1:], q.torrents[idx:])
+	q.torrents[idx] = t
 	return true
 }
 
 // Delete removes torrent from the queue
 func (q *Queue) Delete(t *Torrent) bool {
-	idx := -1
-	for i, ti := range q.torrents {
-		if ti.InfoHash() == t.InfoHash() {
-			idx = i
-			break
-		}
-	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
 
+	idx := q.indexOf(t)
 	if idx < 0 {
 		return false
 	}
@@ -44,11 +99,17 @@ func (q *Queue) Delete(t *Torrent) bool {
 
 // All returns all queue
 func (q *Queue) All() []*Torrent {
-	return q.torrents
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return append([]*Torrent{}, q.torrents...)
 }
 
 // FindByHash checks if torrent with infohash is in the queue
 func (q *Queue) FindByHash(hash string) *Torrent {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
 	for _, t := range q.torrents {
 		if t.InfoHash() == hash {
 			return t
@@ -60,6 +121,9 @@ func (q *Queue) FindByHash(hash string) *Torrent {
 
 // FindByURI checks if torrent with infohash is in the queue
 func (q *Queue) FindByURI(uri string) *Torrent {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
 	for _, t := range q.torrents {
 		if t.torrentFile == uri {
 			return t
@@ -72,5 +136,150 @@ func (q *Queue) FindByURI(uri string) *Torrent {
 // Clean would cleanup torrents list,
 // should be used in case of a service reload
 func (q *Queue) Clean() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	q.torrents = []*Torrent{}
 }
+
+// Position returns 1-based position of the torrent in the queue
+func (q *Queue) Position(t *Torrent) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.indexOf(t) + 1
+}
+
+// Move changes position of the torrent in the queue
+func (q *Queue) Move(t *Torrent, direction string) error {
+	q.mu.Lock()
+
+	idx := q.indexOf(t)
+	if idx < 0 {
+		q.mu.Unlock()
+		return errQueueNotFound
+	}
+
+	to := idx
+	switch direction {
+	case QueueMoveUp:
+		to = idx - 1
+	case QueueMoveDown:
+		to = idx + 1
+	case QueueMoveTop:
+		to = 0
+	case QueueMoveBottom:
+		to = len(q.torrents) - 1
+	default:
+		q.mu.Unlock()
+		return errQueueMove
+	}
+	if to < 0 || to >= len(q.torrents) || to == idx {
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.torrents = append(q.torrents[:idx], q.torrents[idx+1:]...)
+	q.torrents = append(q.torrents[:to], append([]*Torrent{t}, q.torrents[to:]...)...)
+	q.mu.Unlock()
+
+	q.save()
+	q.Apply()
+	return nil
+}
+
+// SetPriority changes priority of the torrent, torrents with higher priority are started first
+func (q *Queue) SetPriority(t *Torrent, priority int) error {
+	if q.Position(t) == 0 {
+		return errQueueNotFound
+	}
+
+	if priority > QueuePriorityHigh {
+		priority = QueuePriorityHigh
+	} else if priority < QueuePriorityLow {
+		priority = QueuePriorityLow
+	}
+	t.QueuePriority = priority
+
+	q.save()
+	q.Apply()
+	return nil
+}
+
+// save stores queue positions and priorities in the database
+func (q *Queue) save() {
+	for i, t := range q.All() {
+		t.QueuePosition = i + 1
+		database.GetStorm().UpdateBTItemQueue(t.InfoHash(), t.QueuePosition, t.QueuePriority)
+	}
+}
+
+// indexOf should be called with locked mutex
+func (q *Queue) indexOf(t *Torrent) int {
+	for i, ti := range q.torrents {
+		if ti == t || ti.InfoHash() == t.InfoHash() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Apply pauses and resumes torrents, so that only allowed number of downloads and seeds is active.
+// Torrents used by players are always active, and other downloads are paused while a player is buffering.
+func (q *Queue) Apply() {
+	if q.s.Closer.IsSet() || q.s.Session == nil || q.s.Session.IsPaused() {
+		return
+	}
+
+	q.muApply.Lock()
+	defer q.muApply.Unlock()
+
+	maxDownloads := config.Get().MaxActiveDownloads
+	maxSeeds := config.Get().MaxActiveSeeds
+	isBuffering := config.Get().QueuePauseOnBuffering && q.s.anyTorrentIsBuffering()
+
+	torrents := q.All()
+	sort.SliceStable(torrents, func(i, j int) bool {
+		return torrents[i].QueuePriority > torrents[j].QueuePriority
+	})
+
+	// Streamed torrents take slots first
+	downloads, seeds := 0, 0
+	for _, t := range torrents {
+		if t.isStreaming() {
+			if t.IsQueued {
+				t.unqueue()
+			}
+			if t.IsFinished() {
+				seeds++
+			} else {
+				downloads++
+			}
+		}
+	}
+
+	for _, t := range torrents {
+		if t.isStreaming() || t.IsMemoryStorage() || t.Closer.IsSet() || !t.HasMetadata() {
+			continue
+		}
+		// Torrents, paused by the user, are not managed
+		if t.IsPaused || (t.GetPaused() && !t.IsQueued) {
+			continue
+		}
+
+		allowed := true
+		if t.IsFinished() {
+			seeds++
+			allowed = maxSeeds <= 0 || seeds <= maxSeeds
+		} else {
+			downloads++
+			allowed = !isBuffering && (maxDownloads <= 0 || downloads <= maxDownloads)
+		}
+
+		if allowed && t.IsQueued {
+			t.unqueue()
+		} else if !allowed && !t.IsQueued {
+			t.queue()
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package rar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotRar is returned when volume does not start with RAR signature
+	ErrNotRar = errors.New("Not a RAR archive")
+	// ErrEncrypted is returned when archive headers are encrypted
+	ErrEncrypted = errors.New("RAR archive headers are encrypted")
+	// ErrInvalidHeader is returned when header can't be parsed
+	ErrInvalidHeader = errors.New("Invalid RAR header")
+
+	signature4 = []byte{0x52, 0x61, 0x72, 0x21, 0x1A, 0x07, 0x00}
+	signature5 = []byte{0x52, 0x61, 0x72, 0x21, 0x1A, 0x07, 0x01, 0x00}
+)
+
+// maxHeaderSize limits the size of headers we read into memory
+const maxHeaderSize = 1024 * 1024
+
+// Volume describes single RAR volume
+type Volume struct {
+	Version   int
+	Solid     bool
+	Encrypted bool
+	Entries   []*Entry
+}
+
+// Entry is a file header inside a volume. For files, split across volumes,
+// each volume has own entry, describing the part of data stored in it.
+type Entry struct {
+	Name         string
+	DataOffset   int64
+	PackedSize   int64
+	UnpackedSize int64
+	Stored       bool
+	Encrypted    bool
+	IsDir        bool
+	SplitBefore  bool
+	SplitAfter   bool
+}
+
+// ReadVolume reads headers of a RAR volume. Reading stops after an entry, which continues
+// in the next volume, so only the beginning of a volume is usually needed.
+func ReadVolume(r io.ReadSeeker, size int64) (*Volume, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	sig := make([]byte, len(signature5))
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(sig, signature5):
+		return readVolume5(r, size, int64(len(signature5)))
+	case bytes.Equal(sig[:len(signature4)], signature4):
+		return readVolume4(r, size, int64(len(signature4)))
+	}
+
+	return nil, ErrNotRar
+}
+
+// Streamable checks whether entry data can be read as is, without decompression
+func (e *Entry) Streamable() bool {
+	return e.Stored && !e.Encrypted && !e.IsDir
+}
+
+const (
+	block4Main = 0x73
+	block4File = 0x74
+	block4End  = 0x7B
+
+	flag4MainSolid     = 0x0008
+	flag4MainEncrypted = 0x0080
+	flag4HasData       = 0x8000
+
+	flag4FileSplitBefore = 0x0001
+	flag4FileSplitAfter  = 0x0002
+	flag4FileEncrypted   = 0x0004
+	flag4FileDirectory   = 0x00E0
+	flag4FileLarge       = 0x0100
+	flag4FileUnicode     = 0x0200
+
+	method4Store = 0x30
+)
+
+func readVolume4(r io.ReadSeeker, size, pos int64) (*Volume, error) {
+	v := &Volume{Version: 4}
+	header := make([]byte, 7)
+
+	for pos+7 <= size {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return v, err
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			return v, err
+		}
+
+		blockType := header[2]
+		flags := binary.LittleEndian.Uint16(header[3:])
+		headSize := int64(binary.LittleEndian.Uint16(header[5:]))
+		if headSize < 7 || headSize > maxHeaderSize {
+			return v, ErrInvalidHeader
+		}
+
+		body := make([]byte, headSize-7)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return v, err
+		}
+
+		dataSize := int64(0)
+		if flags&flag4HasData != 0 && len(body) >= 4 {
+			dataSize = int64(binary.LittleEndian.Uint32(body))
+		}
+
+		switch blockType {
+		case block4Main:
+			v.Solid = flags&flag4MainSolid != 0
+			v.Encrypted = flags&flag4MainEncrypted != 0
+			if v.Encrypted {
+				return v, ErrEncrypted
+			}
+		case block4File:
+			e, err := parseFile4(body, flags)
+			if err != nil {
+				return v, err
+			}
+			e.DataOffset = pos + headSize
+			dataSize = e.PackedSize
+			v.Entries = append(v.Entries, e)
+
+			if e.SplitAfter {
+				return v, nil
+			}
+		case block4End:
+			return v, nil
+		}
+
+		pos += headSize + dataSize
+	}
+
+	return v, nil
+}
+
+func parseFile4(body []byte, flags uint16) (*Entry, error) {
+	// PACK_SIZE(4) UNP_SIZE(4) HOST_OS(1) FILE_CRC(4) FTIME(4) UNP_VER(1) METHOD(1) NAME_SIZE(2) ATTR(4)
+	if len(body) < 25 {
+		return nil, ErrInvalidHeader
+	}
+
+	e := &Entry{
+		PackedSize:   int64(binary.LittleEndian.Uint32(body[0:])),
+		UnpackedSize: int64(binary.LittleEndian.Uint32(body[4:])),
+		Stored:       body[18] == method4Store,
+		Encrypted:    flags&flag4FileEncrypted != 0,
+		IsDir:        flags&flag4FileDirectory == flag4FileDirectory,
+		SplitBefore:  flags&flag4FileSplitBefore != 0,
+		SplitAfter:   flags&flag4FileSplitAfter != 0,
+	}
+
+	nameSize := int(binary.LittleEndian.Uint16(body[19:]))
+	pos := 25
+	if flags&flag4FileLarge != 0 {
+		if len(body) < pos+8 {
+			return nil, ErrInvalidHeader
+		}
+		e.PackedSize |= int64(binary.LittleEndian.Uint32(body[pos:])) << 32
+		e.UnpackedSize |= int64(binary.LittleEndian.Uint32(body[pos+4:])) << 32
+		pos += 8
+	}
+	if len(body) < pos+nameSize {
+		return nil, ErrInvalidHeader
+	}
+
+	name := body[pos : pos+nameSize]
+	// Unicode names keep ASCII version before zero byte
+	if flags&flag4FileUnicode != 0 {
+		if idx := bytes.IndexByte(name, 0); idx != -1 {
+			name = name[:idx]
+		}
+	}
+	e.Name = normalizeName(string(name))
+
+	return e, nil
+}
+
+const (
+	block5Main       = 1
+	block5File       = 2
+	block5Encryption = 4
+	block5End        = 5
+
+	flag5Extra       = 0x0001
+	flag5Data        = 0x0002
+	flag5SplitBefore = 0x0008
+	flag5SplitAfter  = 0x0010
+
+	flag5MainSolid = 0x0004
+
+	flag5FileDirectory   = 0x0001
+	flag5FileTime        = 0x0002
+	flag5FileCRC         = 0x0004
+	extra5FileEncryption = 0x01
+)
+
+func readVolume5(r io.ReadSeeker, size, pos int64) (*Volume, error) {
+	v := &Volume{Version: 5}
+	// CRC32(4) + header size vint (up to 3 bytes for headers we accept)
+	prefix := make([]byte, 7)
+
+	for pos+7 <= size {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return v, err
+		}
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			return v, err
+		}
+
+		headSize, n := vint(prefix[4:])
+		if n == 0 || headSize == 0 || headSize > maxHeaderSize {
+			return v, ErrInvalidHeader
+		}
+
+		headerStart := pos + 4 + int64(n)
+		if _, err := r.Seek(headerStart, io.SeekStart); err != nil {
+			return v, err
+		}
+		body := make([]byte, headSize)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return v, err
+		}
+
+		h := &reader5{data: body}
+		blockType := h.vint()
+		flags := h.vint()
+		extraSize := uint64(0)
+		dataSize := uint64(0)
+		if flags&flag5Extra != 0 {
+			extraSize = h.vint()
+		}
+		if flags&flag5Data != 0 {
+			dataSize = h.vint()
+		}
+		if h.err || extraSize > headSize || dataSize > uint64(size) {
+			return v, ErrInvalidHeader
+		}
+
+		switch blockType {
+		case block5Main:
+			archiveFlags := h.vint()
+			v.Solid = archiveFlags&flag5MainSolid != 0
+		case block5Encryption:
+			v.Encrypted = true
+			return v, ErrEncrypted
+		case block5File:
+			e, err := parseFile5(h, body, extraSize)
+			if err != nil {
+				return v, err
+			}
+			e.DataOffset = headerStart + int64(headSize)
+			e.PackedSize = int64(dataSize)
+			e.SplitBefore = flags&flag5SplitBefore != 0
+			e.SplitAfter = flags&flag5SplitAfter != 0
+			v.Entries = append(v.Entries, e)
+
+			if e.SplitAfter {
+				return v, nil
+			}
+		case block5End:
+			return v, nil
+		}
+
+		pos = headerStart + int64(headSize) + int64(dataSize)
+	}
+
+	return v, nil
+}
+
+func parseFile5(h *reader5, body []byte, extraSize uint64) (*Entry, error) {
+	e := &Entry{}
+
+	fileFlags := h.vint()
+	e.UnpackedSize = int64(h.vint())
+	h.vint() // attributes
+	if fileFlags&flag5FileTime != 0 {
+		h.skip(4)
+	}
+	if fileFlags&flag5FileCRC != 0 {
+		h.skip(4)
+	}
+	compression := h.vint()
+	h.vint() // host OS
+	nameLength := h.vint()
+	name := h.bytes(int(nameLength))
+	if h.err {
+		return nil, ErrInvalidHeader
+	}
+
+	// Compression method is stored in bits 7-9, 0 means no compression
+	e.Stored = (compression>>7)&0x7 == 0
+	e.IsDir = fileFlags&flag5FileDirectory != 0
+	e.Name = normalizeName(string(name))
+
+	// Extra area is located at the end of the header
+	if extraSize > 0 && extraSize <= uint64(len(body)) {
+		extra := &reader5{data: body[uint64(len(body))-extraSize:]}
+		for extra.pos < len(extra.data) {
+			recordSize := extra.vint()
+			start := extra.pos
+			// Record size includes record type, so it can't be zero or exceed the rest of the area
+			if extra.err || recordSize == 0 || recordSize > uint64(len(extra.data)-start) {
+				return nil, ErrInvalidHeader
+			}
+
+			recordType := extra.vint()
+			if recordType == extra5FileEncryption {
+				e.Encrypted = true
+			}
+			extra.pos = start + int(recordSize)
+		}
+	}
+
+	return e, nil
+}
+
+type reader5 struct {
+	data []byte
+	pos  int
+	err  bool
+}
+
+func (r *reader5) vint() uint64 {
+	if r.err || r.pos >= len(r.data) {
+		r.err = true
+		return 0
+	}
+	v, n := vint(r.data[r.pos:])
+	if n == 0 {
+		r.err = true
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *reader5) skip(n int) {
+	if r.pos+n > len(r.data) {
+		r.err = true
+		return
+	}
+	r.pos += n
+}
+
+func (r *reader5) bytes(n int) []byte {
+	if r.err || n < 0 || r.pos+n > len(r.data) {
+		r.err = true
+		return nil
+	}
+	ret := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return ret
+}
+
+// vint decodes RAR5 variable length integer, returns zero length on error
+func vint(data []byte) (uint64, int) {
+	var ret uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		ret |= uint64(data[i]&0x7F) << (7 * uint(i))
+		if data[i]&0x80 == 0 {
+			return ret, i + 1
+		}
+	}
+	return 0, 0
+}
+
+func normalizeName(name string) string {
+	return strings.Replace(name, "\\", "/", -1)
+}
@@ -0,0 +1,174 @@
+package rar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func putVint(v uint64) []byte {
+	ret := []byte{}
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v != 0 {
+			ret = append(ret, b|0x80)
+			continue
+		}
+		return append(ret, b)
+	}
+}
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// block5 builds RAR5 header block: CRC32, header size and header body
+func block5(body []byte) []byte {
+	return join(make([]byte, 4), putVint(uint64(len(body))), body)
+}
+
+// file5 builds RAR5 file header with data size and optional extra area
+func file5(name string, compression uint64, flags uint64, dataSize uint64, extra []byte) []byte {
+	flags |= flag5Data
+	if len(extra) > 0 {
+		flags |= flag5Extra
+	}
+
+	header := join(putVint(block5File), putVint(flags))
+	if len(extra) > 0 {
+		header = join(header, putVint(uint64(len(extra))))
+	}
+	header = join(header,
+		putVint(dataSize),
+		putVint(0),           // file flags
+		putVint(dataSize),    // unpacked size
+		putVint(0),           // attributes
+		putVint(compression), // compression info
+		putVint(0),           // host OS
+		putVint(uint64(len(name))),
+		[]byte(name),
+		extra,
+	)
+	return block5(header)
+}
+
+func volume4File(name string, method byte, flags uint16, data []byte) []byte {
+	body := make([]byte, 25)
+	binary.LittleEndian.PutUint32(body[0:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(body[4:], uint32(len(data)))
+	body[18] = method
+	binary.LittleEndian.PutUint16(body[19:], uint16(len(name)))
+	body = append(body, name...)
+
+	header := make([]byte, 7)
+	header[2] = block4File
+	binary.LittleEndian.PutUint16(header[3:], flags|flag4HasData)
+	binary.LittleEndian.PutUint16(header[5:], uint16(7+len(body)))
+	return join(header, body, data)
+}
+
+func TestReadVolume(t *testing.T) {
+	data := []byte("0123456789")
+
+	stored5 := join(signature5, file5("dir\\movie.mkv", 0, 0, uint64(len(data)), nil), data)
+	compressed5 := join(signature5, file5("movie.mkv", 3<<7, 0, uint64(len(data)), nil), data)
+	split5 := join(signature5, file5("movie.mkv", 0, flag5SplitAfter, uint64(len(data)), nil), data)
+	encrypted5 := join(signature5, file5("movie.mkv", 0, 0, uint64(len(data)), join(putVint(2), putVint(extra5FileEncryption), []byte{0})), data)
+	stored4 := join(signature4, volume4File("dir\\movie.mkv", method4Store, 0, data))
+
+	tests := []struct {
+		name       string
+		volume     []byte
+		err        error
+		version    int
+		entryName  string
+		dataOffset int64
+		stored     bool
+		encrypted  bool
+		splitAfter bool
+	}{
+		{name: "rar5 stored", volume: stored5, version: 5, entryName: "dir/movie.mkv", dataOffset: int64(len(stored5) - len(data)), stored: true},
+		{name: "rar5 compressed", volume: compressed5, version: 5, entryName: "movie.mkv", dataOffset: int64(len(compressed5) - len(data))},
+		{name: "rar5 split", volume: split5, version: 5, entryName: "movie.mkv", dataOffset: int64(len(split5) - len(data)), stored: true, splitAfter: true},
+		{name: "rar5 encrypted file", volume: encrypted5, version: 5, entryName: "movie.mkv", dataOffset: int64(len(encrypted5) - len(data)), stored: true, encrypted: true},
+		{name: "rar4 stored", volume: stored4, version: 4, entryName: "dir/movie.mkv", dataOffset: int64(len(stored4) - len(data)), stored: true},
+		{name: "not rar", volume: []byte("PK\x03\x04 not a rar file"), err: ErrNotRar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := ReadVolume(bytes.NewReader(tt.volume), int64(len(tt.volume)))
+			if err != tt.err {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			if v.Version != tt.version {
+				t.Errorf("version = %d, want %d", v.Version, tt.version)
+			}
+			if len(v.Entries) != 1 {
+				t.Fatalf("entries = %d, want 1", len(v.Entries))
+			}
+
+			e := v.Entries[0]
+			if e.Name != tt.entryName {
+				t.Errorf("name = %q, want %q", e.Name, tt.entryName)
+			}
+			if e.DataOffset != tt.dataOffset {
+				t.Errorf("data offset = %d, want %d", e.DataOffset, tt.dataOffset)
+			}
+			if e.PackedSize != int64(len(data)) {
+				t.Errorf("packed size = %d, want %d", e.PackedSize, len(data))
+			}
+			if e.Stored != tt.stored || e.Encrypted != tt.encrypted || e.SplitAfter != tt.splitAfter {
+				t.Errorf("stored/encrypted/split = %v/%v/%v, want %v/%v/%v", e.Stored, e.Encrypted, e.SplitAfter, tt.stored, tt.encrypted, tt.splitAfter)
+			}
+		})
+	}
+}
+
+func TestReadVolumeInvalid(t *testing.T) {
+	data := []byte("0123456789")
+
+	tests := []struct {
+		name  string
+		extra []byte
+	}{
+		{name: "record size over 2^63", extra: join(putVint(1<<63), putVint(extra5FileEncryption))},
+		{name: "record size over area", extra: join(putVint(100), putVint(extra5FileEncryption))},
+		{name: "zero record size", extra: join(putVint(0), putVint(extra5FileEncryption))},
+		{name: "truncated record", extra: []byte{0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := join(signature5, file5("movie.mkv", 0, 0, uint64(len(data)), tt.extra), data)
+			if _, err := ReadVolume(bytes.NewReader(volume), int64(len(volume))); err != ErrInvalidHeader {
+				t.Errorf("error = %v, want %v", err, ErrInvalidHeader)
+			}
+		})
+	}
+}
+
+func TestVint(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want uint64
+		n    int
+	}{
+		{data: []byte{0x05}, want: 5, n: 1},
+		{data: []byte{0x80, 0x01}, want: 128, n: 2},
+		{data: []byte{0xFF, 0xFF, 0x03}, want: 0xFFFF, n: 3},
+		{data: []byte{0x80}, want: 0, n: 0},
+		{data: nil, want: 0, n: 0},
+	}
+
+	for _, tt := range tests {
+		if got, n := vint(tt.data); got != tt.want || n != tt.n {
+			t.Errorf("vint(%v) = %d, %d, want %d, %d", tt.data, got, n, tt.want, tt.n)
+		}
+	}
+}
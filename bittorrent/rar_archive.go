@@ -0,0 +1,216 @@
+package bittorrent
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elgatito/elementum/bittorrent/rar"
+)
+
+const (
+	// rarHeaderTimeout limits the time we wait for headers of a single RAR volume
+	rarHeaderTimeout = 1 * time.Minute
+)
+
+var (
+	rarPartRegex = regexp.MustCompile(`(?i)^(.*)\.part(\d+)\.rar$`)
+	rarOldRegex  = regexp.MustCompile(`(?i)^(.*)\.(rar|[r-z]\d{2})$`)
+
+	errRarCompressed = errors.New("RAR archive is compressed or encrypted")
+)
+
+// isRarVolume checks if file name looks like a RAR volume
+func isRarVolume(name string) bool {
+	return rarPartRegex.MatchString(name) || rarOldRegex.MatchString(name)
+}
+
+// isFirstRarVolume checks if file name looks like the first volume of RAR archive
+func isFirstRarVolume(name string) bool {
+	if m := rarPartRegex.FindStringSubmatch(name); len(m) > 0 {
+		num, _ := strconv.Atoi(m[2])
+		return num <= 1
+	}
+	return strings.HasSuffix(strings.ToLower(name), ".rar")
+}
+
+// rarVolumeOrder returns sort key of a volume inside the archive
+func rarVolumeOrder(name string) (string, int) {
+	if m := rarPartRegex.FindStringSubmatch(name); len(m) > 0 {
+		num, _ := strconv.Atoi(m[2])
+		return strings.ToLower(m[1]), num
+	}
+	if m := rarOldRegex.FindStringSubmatch(name); len(m) > 0 {
+		ext := strings.ToLower(m[2])
+		if ext == "rar" {
+			return strings.ToLower(m[1]), -1
+		}
+		// .r00-.r99 are followed by .s00-.s99 and so on
+		num, _ := strconv.Atoi(ext[1:])
+		return strings.ToLower(m[1]), int(ext[0]-'r')*100 + num
+	}
+	return "", 0
+}
+
+// rarVolumes returns all volumes of the archive, sorted in the archive order
+func (t *Torrent) rarVolumes(first *File) []*File {
+	base, _ := rarVolumeOrder(first.Path)
+	isNewStyle := rarPartRegex.MatchString(first.Path)
+
+	ret := []*File{}
+	for _, f := range t.files {
+		if isNewStyle != rarPartRegex.MatchString(f.Path) {
+			continue
+		}
+		if b, _ := rarVolumeOrder(f.Path); b == base && isRarVolume(f.Path) {
+			ret = append(ret, f)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		_, a := rarVolumeOrder(ret[i].Path)
+		_, b := rarVolumeOrder(ret[j].Path)
+		return a < b
+	})
+	return ret
+}
+
+// rarSize returns total size of all archive volumes
+func (t *Torrent) rarSize(first *File) (size int64) {
+	for _, v := range t.rarVolumes(first) {
+		size += v.Size
+	}
+	return
+}
+
+// OpenRarArchive reads headers of RAR volumes and, if the biggest file in the archive
+// is stored without compression, returns virtual file, which maps to data in volumes.
+// Such file can be streamed directly, without downloading and extracting the archive.
+func (t *Torrent) OpenRarArchive(first *File) (*File, error) {
+	if first == nil {
+		return nil, errors.New("No file to open")
+	}
+
+	t.muVirtualFiles.Lock()
+	for _, vf := range t.virtualFiles {
+		if vf.Segments[0].File == first {
+			t.muVirtualFiles.Unlock()
+			return vf, nil
+		}
+	}
+	t.muVirtualFiles.Unlock()
+
+	volumes := t.rarVolumes(first)
+	if len(volumes) == 0 || volumes[0] != first {
+		return nil, fmt.Errorf("%s is not the first RAR volume", first.Path)
+	}
+
+	defer func(now time.Time) {
+		log.Infof("Reading headers of %d RAR volumes took %s", len(volumes), time.Since(now))
+	}(time.Now())
+
+	// Requesting first pieces of all volumes at once, to avoid waiting for each of them
+	for _, v := range volumes {
+		t.th.PiecePriority(v.PieceStart, 7)
+		t.th.SetPieceDeadline(v.PieceStart, 0, 0)
+	}
+
+	var entry *rar.Entry
+	segments := []*FileSegment{}
+	for i, v := range volumes {
+		var volume *rar.Volume
+		err := t.withFileReader(v, rarHeaderTimeout, func(r *TorrentFSEntry) (err error) {
+			volume, err = rar.ReadVolume(r, v.Size)
+			return
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read RAR volume %s: %s", v.Path, err)
+		} else if volume.Solid {
+			return nil, errRarCompressed
+		}
+
+		var e *rar.Entry
+		if i == 0 {
+			for _, ve := range volume.Entries {
+				if !ve.IsDir && (e == nil || ve.UnpackedSize > e.UnpackedSize) {
+					e = ve
+				}
+			}
+			entry = e
+		} else {
+			for _, ve := range volume.Entries {
+				if ve.SplitBefore && ve.Name == entry.Name {
+					e = ve
+					break
+				}
+			}
+		}
+
+		if e == nil {
+			if i == 0 {
+				return nil, errors.New("RAR archive has no files")
+			}
+			break
+		} else if !e.Streamable() {
+			return nil, errRarCompressed
+		}
+
+		segments = append(segments, &FileSegment{
+			File:   v,
+			Offset: e.DataOffset,
+			Size:   e.PackedSize,
+		})
+
+		if !e.SplitAfter {
+			break
+		}
+	}
+
+	size := int64(0)
+	for _, s := range segments {
+		size += s.Size
+	}
+	if size != entry.UnpackedSize {
+		return nil, fmt.Errorf("RAR archive is incomplete, found %s of %s", humanize.Bytes(uint64(size)), humanize.Bytes(uint64(entry.UnpackedSize)))
+	}
+
+	vf := &File{
+		Index:    -1,
+		Name:     filepath.Base(filepath.FromSlash(entry.Name)),
+		Size:     size,
+		Path:     filepath.Join(filepath.Dir(first.Path), filepath.FromSlash(entry.Name)),
+		Segments: segments,
+	}
+	vf.Offset = vf.TorrentOffset(0)
+	vf.PieceStart = int(vf.Offset / t.pieceLength)
+	vf.PieceEnd = int(vf.TorrentOffset(size-1) / t.pieceLength)
+
+	log.Infof("Found stored file %s (%s) in %d RAR volumes", vf.Path, humanize.Bytes(uint64(vf.Size)), len(segments))
+
+	t.muVirtualFiles.Lock()
+	t.virtualFiles = append(t.virtualFiles, vf)
+	t.muVirtualFiles.Unlock()
+
+	return vf, nil
+}
+
+// AllFiles returns torrent files together with virtual files
+func (t *Torrent) AllFiles() []*File {
+	t.muVirtualFiles.Lock()
+	defer t.muVirtualFiles.Unlock()
+
+	if len(t.virtualFiles) == 0 {
+		return t.files
+	}
+
+	ret := make([]*File, 0, len(t.files)+len(t.virtualFiles))
+	ret = append(ret, t.files...)
+	return append(ret, t.virtualFiles...)
+}
@@ -68,7 +68,7 @@ type Service struct {
 
 	dialogProgressBG *xbmc.DialogProgressBG
 
-	MarkedToMove string
+	markedToMove sync.Map
 
 	alertsBroadcaster *broadcast.Broadcaster
 	Closer            event.Event
@@ -114,6 +114,7 @@ func NewService() *Service {
 	go s.startServices()
 
 	go s.watchConfig()
+	go s.trackersProber()
 	go s.onSaveResumeDataConsumer()
 	go s.onSaveResumeDataWriter()
 
@@ -248,6 +249,11 @@ func (s *Service) configure() {
 	// settings.SetInt("request_timeout", 2)
 	settings.SetInt("stop_tracker_timeout", 1)
 
+	// Active torrents are limited by our own Queue, which knows what is being streamed
+	settings.SetInt("active_downloads", -1)
+	settings.SetInt("active_seeds", -1)
+	settings.SetInt("active_limit", -1)
+
 	// Ratios
 	settings.SetInt("seed_time_limit", 0)
 	settings.SetInt("seed_time_ratio_limit", 0)
@@ -417,9 +423,6 @@ func (s *Service) configure() {
 		settings.SetInt("seed_time_ratio_limit", 0)
 		settings.SetInt("seed_time_limit", 0)
 
-		settings.SetInt("active_downloads", -1)
-		settings.SetInt("active_seeds", -1)
-		settings.SetInt("active_limit", -1)
 		settings.SetInt("active_tracker_limit", -1)
 		settings.SetInt("active_dht_limit", -1)
 		settings.SetInt("active_lsd_limit", -1)
@@ -788,9 +791,13 @@ func (s *Service) AddTorrent(xbmcHost *xbmc.XBMCHost, uri string, paused bool, d
 		torrentParams.SetFilePriorities(filesPriorities)
 	}
 
+	// Metadata resolver of the same magnet is stopped, so it does not remove the torrent, added here
+	req := acquireMetadataRequest(infoHash, true)
+
 	// Call torrent creation
 	th, err = s.Session.AddTorrent(torrentParams)
 	if err != nil {
+		req.release()
 		return nil, err
 	}
 	if !paused {
@@ -820,8 +827,8 @@ func (s *Service) AddTorrent(xbmcHost *xbmc.XBMCHost, uri string, paused bool, d
 			th.ReplaceTrackers(trackers)
 		}
 
-		if len(extraTrackers) > 0 && config.Get().AddExtraTrackers != addExtraTrackersNone {
-			for _, tracker := range extraTrackers {
+		if extra := getExtraTrackers(); len(extra) > 0 && config.Get().AddExtraTrackers != addExtraTrackersNone {
+			for _, tracker := range HealthyTrackers(extra) {
 				if tracker == "" {
 					continue
 				}
@@ -837,6 +844,12 @@ func (s *Service) AddTorrent(xbmcHost *xbmc.XBMCHost, uri string, paused bool, d
 		log.Debugf("After modifications loaded torrent has %d trackers", th.Trackers().Size())
 	}
 
+	// Trackers, edited by the user, take precedence over any modifications
+	if item := database.GetStorm().GetBTItem(infoHash); item != nil && item.Trackers != nil {
+		log.Debugf("Restoring %d trackers, edited by the user", len(item.Trackers))
+		replaceTrackers(th, item.Trackers)
+	}
+
 	log.Infof("Setting sequential download to: %v", downloadStorage != config.StorageMemory)
 	th.SetSequentialDownload(downloadStorage != config.StorageMemory)
 
@@ -849,6 +862,7 @@ func (s *Service) AddTorrent(xbmcHost *xbmc.XBMCHost, uri string, paused bool, d
 
 	t.addedTime = addedTime
 	s.q.Add(t)
+	req.release()
 
 	if !t.HasMetadata() {
 		if err := t.WaitForMetadata(xbmcHost, infoHash); err != nil {
@@ -1094,7 +1108,21 @@ func (s *Service) onAlertsConsumer() {
 					ta := lt.SwigcptrTrackerReplyAlert(alertPtr)
 					for _, t := range s.q.All() {
 						if t.th != nil && ta.GetHandle().Equal(t.th) {
-							t.trackers.Store(ta.TrackerUrl(), ta.GetNumPeers())
+							t.onTrackerReply(ta.TrackerUrl(), ta.GetNumPeers())
+						}
+					}
+				case lt.TrackerErrorAlertAlertType:
+					ta := lt.SwigcptrTrackerErrorAlert(alertPtr)
+					for _, t := range s.q.All() {
+						if t.th != nil && ta.GetHandle().Equal(t.th) {
+							t.onTrackerError(ta.TrackerUrl(), ta.ErrorMessage())
+						}
+					}
+				case lt.TrackerWarningAlertAlertType:
+					ta := lt.SwigcptrTrackerWarningAlert(alertPtr)
+					for _, t := range s.q.All() {
+						if t.th != nil && ta.GetHandle().Equal(t.th) {
+							t.onTrackerWarning(ta.TrackerUrl(), ta.WarningMessage())
 						}
 					}
 				case lt.DhtReplyAlertAlertType:
@@ -1273,6 +1301,7 @@ func (s *Service) onDownloadProgress() {
 
 	pathChecked := make(map[string]bool)
 	warnedMissing := make(map[string]bool)
+	organized := make(map[string]bool)
 
 	xbmcHost, _ := xbmc.GetLocalXBMCHost()
 
@@ -1295,6 +1324,8 @@ func (s *Service) onDownloadProgress() {
 				return
 			}
 
+			s.q.Apply()
+
 			var totalDownloadRate float64
 			var totalUploadRate float64
 			var totalProgress int
@@ -1399,15 +1430,33 @@ func (s *Service) onDownloadProgress() {
 					}
 				}
 
-				if s.MarkedToMove != "" && infoHash == s.MarkedToMove {
-					s.MarkedToMove = ""
+				if _, marked := s.markedToMove.LoadAndDelete(infoHash); marked {
 					status = StatusStrings[StatusSeeding]
 				}
 
 				//
 				// Handle moving completed downloads
 				//
-				if t.IsMemoryStorage() || !s.config.CompletedMove || status != StatusStrings[StatusSeeding] || s.anyPlayerIsPlaying() {
+				if t.IsMemoryStorage() || s.anyPlayerIsPlaying() {
+					continue
+				}
+				// Categories with completed folder are moved even if completed move is disabled
+				categoryPath := t.CompletedPath()
+				if !s.config.CompletedMove && categoryPath == "" {
+					continue
+				}
+				// Copies and hardlinks are made as soon as download is finished, as torrent keeps seeding original files
+				keepSeeding := s.config.CompletedMoveMode == config.CompletedCopy || s.config.CompletedMoveMode == config.CompletedHardlink
+				if keepSeeding {
+					if progress < 100 || organized[infoHash] {
+						continue
+					}
+					// Organized flag is kept in the database, so files are not placed again after restart
+					if item := database.GetStorm().GetBTItem(infoHash); item != nil && item.Organized {
+						organized[infoHash] = true
+						continue
+					}
+				} else if status != StatusStrings[StatusSeeding] {
 					continue
 				}
 				if xbmcHost != nil && xbmcHost.PlayerIsPlaying() {
@@ -1426,14 +1475,22 @@ func (s *Service) onDownloadProgress() {
 					}
 
 					errMsg := fmt.Sprintf("Missing item type to move files to completed folder for %s", torrentName)
-					if item.Type == "" {
+					if item.Type == "" && categoryPath == "" {
 						log.Error(errMsg)
 						return errors.New(errMsg)
 					}
-					log.Warning(torrentName, "finished seeding, moving files...")
 
 					// Check paths are valid and writable, and only once
-					if _, exists := pathChecked[item.Type]; !exists {
+					if categoryPath != "" {
+						if _, exists := pathChecked[categoryPath]; !exists {
+							pathChecked[categoryPath] = true
+							if err := util.IsWritablePath(categoryPath); err != nil {
+								warnedMissing[infoHash] = true
+								log.Error(err)
+								return err
+							}
+						}
+					} else if _, exists := pathChecked[item.Type]; !exists {
 						if item.Type == "movie" {
 							if err := util.IsWritablePath(s.config.CompletedMoviesPath); err != nil {
 								warnedMissing[infoHash] = true
@@ -1453,114 +1510,130 @@ func (s *Service) onDownloadProgress() {
 						}
 					}
 
-					log.Info("Removing the torrent without deleting files after Completed move ...")
-					t := s.GetTorrentByHash(infoHash)
-					s.RemoveTorrent(xbmcHost, t, false, false, false)
-
-					// Delete leftover .parts file if any
-					partsFile := filepath.Join(config.Get().DownloadPath, fmt.Sprintf(".%s.parts", infoHash))
-					os.Remove(partsFile)
-
-					// Delete fast resume data
-					fastResumeFile := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.fastresume", infoHash))
-					if _, err := os.Stat(fastResumeFile); err == nil {
-						log.Info("Deleting fast resume data at", fastResumeFile)
-						if err := os.Remove(fastResumeFile); err != nil {
-							log.Error(err)
-							return err
-						}
-					}
-
-					// Delete torrent file
-					torrentFile := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.torrent", infoHash))
-					if _, err := os.Stat(torrentFile); err == nil {
-						log.Info("Deleting torrent file at ", torrentFile)
-						if err := os.Remove(torrentFile); err != nil {
-							log.Error(err)
-							return err
-						}
-					}
-
 					if len(item.Files) <= 0 {
+						warnedMissing[infoHash] = true
 						return errors.New("No files saved for BTItem")
 					}
 
+					// Resolve file locations before the torrent is removed
 					torrentInfo := torrentHandle.TorrentFile()
+					sources := map[*File]string{}
+					extracted := map[*File]bool{}
 					for _, fp := range item.Files {
 						f := t.GetFileByPath(fp)
+						if f == nil {
+							continue
+						}
 
 						filePath := torrentInfo.Files().FilePath(f.Index)
 						fileName := filepath.Base(filePath)
 
-						extracted := ""
 						re := regexp.MustCompile(`(?i).*\.rar$`)
 						if re.MatchString(fileName) {
-							extractedPath := filepath.Join(s.config.DownloadPath, filepath.Dir(filePath), "extracted")
+							extractedFile := ""
+							extractedPath := filepath.Join(t.DownloadPath(), filepath.Dir(filePath), "extracted")
 							files, err := os.ReadDir(extractedPath)
 							if err != nil {
 								return err
 							}
 							if len(files) == 1 {
-								extracted = files[0].Name()
+								extractedFile = files[0].Name()
 							} else {
 								for _, file := range files {
 									fileNameCurrent := file.Name()
 									re := regexp.MustCompile(`(?i).*\.(mkv|mp4|mov|avi)`)
 									if re.MatchString(fileNameCurrent) {
-										extracted = fileNameCurrent
+										extractedFile = fileNameCurrent
 										break
 									}
 								}
 							}
-							if extracted != "" {
-								filePath = filepath.Join(filepath.Dir(filePath), "extracted", extracted)
-							} else {
+							if extractedFile == "" {
 								return errors.New("No extracted file to move")
 							}
+							filePath = filepath.Join(filepath.Dir(filePath), "extracted", extractedFile)
+							extracted[f] = true
 						}
 
-						var dstPath string
-						if item.Type == "movie" {
-							dstPath = filepath.Dir(s.config.CompletedMoviesPath)
-						} else {
-							dstPath = filepath.Dir(s.config.CompletedShowsPath)
-							if item.ShowID > 0 {
-								show := tmdb.GetShow(item.ShowID, config.Get().Language)
-								if show != nil {
-									showPath := util.ToFileName(fmt.Sprintf("%s (%s)", show.Name, strings.Split(show.FirstAirDate, "-")[0]))
-									seasonPath := filepath.Join(showPath, fmt.Sprintf("Season %d", item.Season))
-									if item.Season == 0 {
-										seasonPath = filepath.Join(showPath, "Specials")
-									}
-									dstPath = filepath.Join(dstPath, seasonPath)
-									os.MkdirAll(dstPath, 0755)
-								}
+						sources[f] = filepath.Join(t.DownloadPath(), filePath)
+					}
+
+					if keepSeeding {
+						organized[infoHash] = true
+						if err := database.GetStorm().UpdateBTItemOrganized(infoHash, true); err != nil {
+							log.Warningf("Could not save organized state of %s: %s", torrentName, err)
+						}
+						log.Infof("%s finished downloading, placing files to the library...", torrentName)
+					} else {
+						log.Warning(torrentName, "finished seeding, moving files...")
+						log.Info("Removing the torrent without deleting files after Completed move ...")
+						s.RemoveTorrent(xbmcHost, t, false, false, false)
+
+						// Delete leftover .parts file if any
+						partsFile := filepath.Join(t.DownloadPath(), fmt.Sprintf(".%s.parts", infoHash))
+						os.Remove(partsFile)
+
+						// Delete fast resume data
+						fastResumeFile := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.fastresume", infoHash))
+						if _, err := os.Stat(fastResumeFile); err == nil {
+							log.Info("Deleting fast resume data at", fastResumeFile)
+							if err := os.Remove(fastResumeFile); err != nil {
+								log.Error(err)
+								return err
 							}
 						}
 
-						go func() {
-							log.Infof("Moving %s to %s", fileName, dstPath)
-							srcPath := filepath.Join(s.config.DownloadPath, filePath)
-							if dst, err := util.Move(srcPath, dstPath); err != nil {
+						// Delete torrent file
+						torrentFile := filepath.Join(s.config.TorrentsPath, fmt.Sprintf("%s.torrent", infoHash))
+						if _, err := os.Stat(torrentFile); err == nil {
+							log.Info("Deleting torrent file at ", torrentFile)
+							if err := os.Remove(torrentFile); err != nil {
 								log.Error(err)
-							} else {
-								// Remove leftover folders
-								if dirPath := filepath.Dir(filePath); dirPath != "." {
-									os.RemoveAll(filepath.Dir(srcPath))
-									if extracted != "" {
-										parentPath := filepath.Clean(filepath.Join(filepath.Dir(srcPath), ".."))
-										if parentPath != "." && parentPath != s.config.DownloadPath {
-											os.RemoveAll(parentPath)
-										}
+								return err
+							}
+						}
+					}
+
+					go func() {
+						files, err := t.Organize(item, func(f *File) string { return sources[f] }, s.config.CompletedMoveMode)
+						if err != nil {
+							log.Error(err)
+						}
+						if len(files) == 0 {
+							return
+						}
+
+						if !keepSeeding {
+							// Remove leftover folders
+							for f, srcPath := range sources {
+								if filepath.Dir(f.Path) == "." {
+									continue
+								}
+								os.RemoveAll(filepath.Dir(srcPath))
+								if extracted[f] {
+									parentPath := filepath.Clean(filepath.Join(filepath.Dir(srcPath), ".."))
+									if parentPath != "." && parentPath != t.DownloadPath() {
+										os.RemoveAll(parentPath)
 									}
 								}
-								log.Warning(fileName, "moved to", dst)
+							}
+
+							log.Infof("Marking %s for removal from library and database...", torrentName)
+							database.GetStorm().UpdateBTItemStatus(infoHash, Remove)
+						}
+
+						// Scan only new folders, instead of the whole library
+						scanned := map[string]bool{}
+						for _, f := range files {
+							log.Warning(filepath.Base(f.Source), "placed to", f.Destination)
 
-								log.Infof("Marking %s for removal from library and database...", torrentName)
-								database.GetStorm().UpdateBTItemStatus(infoHash, Remove)
+							dir := filepath.Dir(f.Destination)
+							if !scanned[dir] && xbmcHost != nil {
+								scanned[dir] = true
+								xbmcHost.VideoLibraryScanDirectory(dir, false)
 							}
-						}()
-					}
+						}
+					}()
 					return nil
 				}()
 			}
@@ -1753,6 +1826,24 @@ func (s *Service) GetPlayer(kodiID int, tmdbID int) *Player {
 	return nil
 }
 
+// GetPlayerStreamInfo returns stream details of the file, chosen by the player of the movie or of the episode
+func (s *Service) GetPlayerStreamInfo(tmdbID, showID, season, episode int) *xbmc.StreamInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.Players {
+		if p == nil || p.t == nil || p.chosenFile == nil {
+			continue
+		}
+
+		if (tmdbID != 0 && p.p.ShowID == 0 && p.p.TMDBId == tmdbID) || (showID != 0 && p.p.ShowID == showID && p.p.Season == season && p.p.Episode == episode) {
+			return p.t.GetStreamInfo(p.chosenFile)
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) anyPlayerIsPlaying() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -1770,6 +1861,27 @@ func (s *Service) anyPlayerIsPlaying() bool {
 	return false
 }
 
+// MarkToMove forces moving of torrent files to completed folder on next progress check
+func (s *Service) MarkToMove(t *Torrent) {
+	s.markedToMove.Store(t.InfoHash(), true)
+}
+
+// GetQueue returns download queue
+func (s *Service) GetQueue() *Queue {
+	return s.q
+}
+
+// anyTorrentIsBuffering checks if any torrent is buffering for playback
+func (s *Service) anyTorrentIsBuffering() bool {
+	for _, t := range s.q.All() {
+		if t.IsBuffering {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetActivePlayer searches for player that is Playing anything
 func (s *Service) GetActivePlayer() *Player {
 	s.mu.Lock()
@@ -0,0 +1,146 @@
+package bittorrent
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/elgatito/elementum/bittorrent/tags"
+	"github.com/elgatito/elementum/database"
+)
+
+var errCategoryNotFound = errors.New("Category not found")
+
+// ensureDBItem returns BTItem of the torrent, creating an empty one, if torrent was added without it
+func (t *Torrent) ensureDBItem() *database.BTItem {
+	if t.DBItem == nil {
+		t.FetchDBItem()
+	}
+	if t.DBItem == nil {
+		database.GetStorm().UpdateBTItem(t.InfoHash(), 0, "", []string{}, t.Name(), 0, 0, 0)
+		t.FetchDBItem()
+	}
+	return t.DBItem
+}
+
+// Category returns name of the torrent category
+func (t *Torrent) Category() string {
+	if item := t.GetDBItem(); item != nil {
+		return item.Category
+	}
+	return ""
+}
+
+// Tags returns tags of the torrent
+func (t *Torrent) Tags() []string {
+	if item := t.GetDBItem(); item != nil && item.Tags != nil {
+		return item.Tags
+	}
+	return []string{}
+}
+
+// HasTag checks if torrent is marked with a tag, case-insensitively
+func (t *Torrent) HasTag(tag string) bool {
+	return tags.Has(t.Tags(), tag)
+}
+
+// SetTags replaces tags of the torrent
+func (t *Torrent) SetTags(list []string) error {
+	item := t.ensureDBItem()
+	if item == nil {
+		return fmt.Errorf("Could not save tags for %s", t.Name())
+	}
+
+	if err := database.GetStorm().UpdateBTItemTags(t.InfoHash(), item.Category, tags.Normalize(list)); err != nil {
+		return err
+	}
+	t.FetchDBItem()
+	return nil
+}
+
+// AddTags adds tags to the torrent, keeping existing ones
+func (t *Torrent) AddTags(list []string) error {
+	return t.SetTags(append(append([]string{}, t.Tags()...), list...))
+}
+
+// RemoveTags removes tags from the torrent
+func (t *Torrent) RemoveTags(list []string) error {
+	return t.SetTags(tags.Without(t.Tags(), list))
+}
+
+// SetCategory assigns category to the torrent, empty name removes category.
+// If category has own download path, torrent data is moved there.
+func (t *Torrent) SetCategory(name string) error {
+	name = strings.TrimSpace(name)
+	if name != "" && database.GetStorm().GetTorrentCategory(name) == nil {
+		return errCategoryNotFound
+	}
+
+	item := t.ensureDBItem()
+	if item == nil {
+		return fmt.Errorf("Could not save category for %s", t.Name())
+	}
+
+	oldPath := t.DownloadPath()
+	if err := database.GetStorm().UpdateBTItemTags(t.InfoHash(), name, item.Tags); err != nil {
+		return err
+	}
+	t.FetchDBItem()
+
+	if newPath := t.DownloadPath(); !t.IsMemoryStorage() && filepath.Clean(newPath) != filepath.Clean(oldPath) {
+		t.MoveStorage(newPath)
+	}
+	return nil
+}
+
+// DownloadPath returns folder, where torrent data is stored, according to the category
+func (t *Torrent) DownloadPath() string {
+	if item := t.GetDBItem(); item != nil && item.Category != "" {
+		if c := database.GetStorm().GetTorrentCategory(item.Category); c != nil && c.DownloadPath != "" {
+			return c.DownloadPath
+		}
+	}
+	return t.Service.config.DownloadPath
+}
+
+// CompletedPath returns folder for completed files from the category, if it is defined
+func (t *Torrent) CompletedPath() string {
+	if item := t.GetDBItem(); item != nil && item.Category != "" {
+		if c := database.GetStorm().GetTorrentCategory(item.Category); c != nil {
+			return c.CompletedPath
+		}
+	}
+	return ""
+}
+
+// MoveStorage moves torrent data to another folder
+func (t *Torrent) MoveStorage(path string) {
+	if t.th == nil || !t.th.IsValid() {
+		return
+	}
+
+	log.Infof("Moving data of %s to %s", t.Name(), path)
+	t.th.MoveStorage(path)
+	t.partsFile = filepath.Join(path, fmt.Sprintf(".%s.parts", t.InfoHash()))
+}
+
+// FilterTorrents returns torrents, matching category and tag, empty values match any torrent
+func (s *Service) FilterTorrents(category, tag string) []*Torrent {
+	ret := []*Torrent{}
+	for _, t := range s.q.All() {
+		if tags.Match(t.Category(), t.Tags(), category, tag) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// GetAllTags returns tags, used by any of the torrents
+func (s *Service) GetAllTags() []string {
+	ret := []string{}
+	for _, t := range s.q.All() {
+		ret = append(ret, t.Tags()...)
+	}
+	return tags.Normalize(ret)
+}
@@ -0,0 +1,54 @@
+package tags
+
+import "strings"
+
+// Parse splits comma-separated list of tags
+func Parse(s string) []string {
+	return Normalize(strings.Split(s, ","))
+}
+
+// Normalize trims tags and removes empty and duplicate ones, comparing case-insensitively
+func Normalize(tags []string) []string {
+	ret := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		ret = append(ret, tag)
+	}
+	return ret
+}
+
+// Has checks if the list contains a tag, case-insensitively
+func Has(tags []string, tag string) bool {
+	tag = strings.TrimSpace(tag)
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Without returns tags, that are not in the remove list
+func Without(tags []string, remove []string) []string {
+	ret := []string{}
+	for _, t := range tags {
+		if !Has(remove, t) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// Match checks if torrent with category and tags passes the filter, empty filter values match any torrent
+func Match(category string, tags []string, filterCategory, filterTag string) bool {
+	if filterCategory != "" && !strings.EqualFold(category, filterCategory) {
+		return false
+	}
+	return filterTag == "" || Has(tags, filterTag)
+}
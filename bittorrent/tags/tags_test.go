@@ -0,0 +1,70 @@
+package tags
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{s: "movies, 4k ,kids", want: []string{"movies", "4k", "kids"}},
+		{s: "Movies,movies,MOVIES", want: []string{"Movies"}},
+		{s: " , ,", want: []string{}},
+		{s: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestWithout(t *testing.T) {
+	tests := []struct {
+		tags   []string
+		remove []string
+		want   []string
+	}{
+		{tags: []string{"movies", "4k"}, remove: []string{"4K"}, want: []string{"movies"}},
+		{tags: []string{"movies", "4k"}, remove: []string{" movies "}, want: []string{"4k"}},
+		{tags: []string{"movies"}, remove: []string{"shows"}, want: []string{"movies"}},
+		{tags: nil, remove: []string{"shows"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Without(tt.tags, tt.remove); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Without(%v, %v) = %v, want %v", tt.tags, tt.remove, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		category       string
+		tags           []string
+		filterCategory string
+		filterTag      string
+		want           bool
+	}{
+		{name: "empty filter", category: "movies", tags: []string{"4k"}, want: true},
+		{name: "no category", tags: nil, want: true},
+		{name: "category", category: "Movies", filterCategory: "movies", want: true},
+		{name: "other category", category: "shows", filterCategory: "movies", want: false},
+		{name: "without category", filterCategory: "movies", want: false},
+		{name: "tag", tags: []string{"kids", "4K"}, filterTag: "4k", want: true},
+		{name: "missing tag", tags: []string{"kids"}, filterTag: "4k", want: false},
+		{name: "category and tag", category: "movies", tags: []string{"4k"}, filterCategory: "movies", filterTag: "4k", want: true},
+		{name: "category without tag", category: "movies", tags: []string{"kids"}, filterCategory: "movies", filterTag: "4k", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.category, tt.tags, tt.filterCategory, tt.filterTag); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/valyala/bytebufferpool"
 	"github.com/zeebo/bencode"
 
+	"github.com/elgatito/elementum/anime"
+	"github.com/elgatito/elementum/bittorrent/probe"
 	"github.com/elgatito/elementum/config"
 	"github.com/elgatito/elementum/database"
 	"github.com/elgatito/elementum/tmdb"
@@ -58,7 +60,9 @@ type Torrent struct {
 	readers            map[int64]*TorrentFSEntry
 	reservedPieces     []int
 	lastPrioritization string
+	lastReadahead      int64
 	trackers           sync.Map
+	trackerAlerts      sync.Map
 
 	awaitingPieces   *roaring.Bitmap
 	demandPieces     *roaring.Bitmap
@@ -76,8 +80,27 @@ type Torrent struct {
 	BufferPiecesProgress   map[int]float64
 	MemorySize             int64
 
+	bufferFile          *File
+	bufferStartedAt     time.Time
+	bufferPreEnd        int
+	bufferIsStartup     bool
+	streamDuration      time.Duration
+	averageDownloadRate float64
+	muBitrate           *sync.RWMutex
+
+	probed   map[int]*probe.Info
+	muProbed *sync.Mutex
+
+	virtualFiles   []*File
+	muVirtualFiles *sync.Mutex
+
+	playlist        []*PlaylistItem
+	playlistCurrent *File
+	muPlaylist      *sync.Mutex
+
 	IsPlaying                bool
 	IsPaused                 bool
+	IsQueued                 bool
 	IsBuffering              bool
 	IsBufferingFinished      bool
 	IsSeeding                bool
@@ -86,6 +109,8 @@ type Torrent struct {
 	IsNeedFinishNotification bool
 	HasNextFile              bool
 	PlayerAttached           int
+	QueuePosition            int
+	QueuePriority            int
 
 	DBItem *database.BTItem
 
@@ -140,12 +165,18 @@ func NewTorrent(service *Service, handle lt.TorrentHandle, info lt.TorrentInfo,
 		BufferPiecesProgress: map[int]float64{},
 		BufferProgress:       -1,
 
+		probed: map[int]*probe.Info{},
+
 		mu:               &sync.Mutex{},
 		muBuffer:         &sync.RWMutex{},
 		muReaders:        &sync.Mutex{},
 		muAwaitingPieces: &sync.RWMutex{},
 		muDemandPieces:   &sync.RWMutex{},
 		muStatus:         &sync.Mutex{},
+		muProbed:         &sync.Mutex{},
+		muVirtualFiles:   &sync.Mutex{},
+		muPlaylist:       &sync.Mutex{},
+		muBitrate:        &sync.RWMutex{},
 	}
 
 	return t
@@ -214,6 +245,7 @@ func (t *Torrent) Watch() {
 			go t.bufferFinishedEvent()
 
 		case <-t.prioritizeTicker.C:
+			go t.updateAverageDownloadRate()
 			go t.PrioritizePieces()
 
 		case <-t.nextTimer.C:
@@ -258,6 +290,9 @@ func (t *Torrent) bufferTickerEvent() {
 	defer perf.ScopeTimer()()
 
 	if t.IsBuffering && len(t.BufferPiecesProgress) > 0 {
+		// Extend the buffer if download is too slow for the stream bitrate
+		t.adjustBuffer()
+
 		// Making sure current progress is not less then previous
 		thisProgress := t.GetBufferProgress()
 
@@ -352,6 +387,8 @@ func (t *Torrent) bufferFinishedEvent() {
 
 	t.bufferTicker.Stop()
 	t.Service.RestoreLimits()
+
+	go t.Service.q.Apply()
 }
 
 // Buffer defines buffer pieces for downloading prior to sending file to Kodi.
@@ -368,40 +405,9 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 
 	t.startBufferTicker()
 
-	startBufferSize := t.Service.GetBufferSize()
+	startBufferSize := t.getAdaptiveBufferSize(file, t.Service.GetBufferSize())
 	preBufferStart, preBufferEnd, preBufferOffset, preBufferSize := t.getBufferSize(file.Offset, 0, startBufferSize)
-	postBufferStart, postBufferEnd, postBufferOffset, postBufferSize := t.getBufferSize(file.Offset, file.Size-int64(config.Get().EndBufferSize), int64(config.Get().EndBufferSize))
-
-	// TODO: Remove this piece of buffer adjustment?
-	// if config.Get().AutoAdjustBufferSize && preBufferEnd-preBufferStart < 10 {
-	// 	_, free := t.Service.GetMemoryStats()
-	// 	autodetectStart := 10
-	// 	// If this file is only a part of big torrent with big pieces -
-	// 	// we don't need that much to buffer. As it will take a lot of time.
-	// 	if t.pieceLength > 0 && file.Size/t.pieceLength <= 300 {
-	// 		autodetectStart = 6
-	// 	}
-
-	// 	// Let's try to
-	// 	var newBufferSize int64
-	// 	for i := autodetectStart; i >= 4; i -= 2 {
-	// 		mem := int64(i) * t.pieceLength
-
-	// 		if mem < startBufferSize {
-	// 			break
-	// 		}
-	// 		if free == 0 || !t.Service.IsMemoryStorage() || (mem*2)-startBufferSize < free {
-	// 			newBufferSize = mem
-	// 			break
-	// 		}
-	// 	}
-
-	// 	if newBufferSize > 0 {
-	// 		startBufferSize = newBufferSize
-	// 		preBufferStart, preBufferEnd, preBufferOffset, preBufferSize = t.getBufferSize(file.Offset, 0, startBufferSize)
-	// 		log.Infof("Adjusting buffer size to %s, to have at least %d pieces ready!", humanize.Bytes(uint64(startBufferSize)), preBufferEnd-preBufferStart+1)
-	// 	}
-	// }
+	postBufferStart, postBufferEnd, postBufferOffset, postBufferSize := t.getBufferSize(file.TorrentOffset(file.Size-int64(config.Get().EndBufferSize)), 0, int64(config.Get().EndBufferSize))
 
 	if t.IsMemoryStorage() {
 		// Try to increase memory size to at most 25 pieces to have more comfortable playback.
@@ -442,6 +448,11 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 	t.BufferProgressPrevious = 0
 	t.BufferLength = preBufferSize + postBufferSize
 
+	t.bufferFile = file
+	t.bufferStartedAt = time.Now()
+	t.bufferPreEnd = preBufferEnd
+	t.bufferIsStartup = isStartup
+
 	for i := preBufferStart; i <= preBufferEnd; i++ {
 		t.BufferPiecesProgress[i] = 0
 	}
@@ -456,15 +467,20 @@ func (t *Torrent) Buffer(file *File, isStartup bool) {
 
 	t.muBuffer.Unlock()
 
+	// Other downloads are paused by the queue while buffering
+	go t.Service.q.Apply()
+
 	log.Infof("Setting buffer for file: %s (%s / %s). Desired: %s. Pieces: %#v-%#v + %#v-%#v, PieceLength: %s, Pre: %s, Post: %s, WithOffset: %#v / %#v (%#v)",
 		file.Path, humanize.Bytes(uint64(file.Size)), humanize.Bytes(uint64(t.ti.TotalSize())),
-		humanize.Bytes(uint64(t.Service.GetBufferSize())),
+		humanize.Bytes(uint64(startBufferSize)),
 		preBufferStart, preBufferEnd, postBufferStart, postBufferEnd,
 		humanize.Bytes(uint64(t.pieceLength)), humanize.Bytes(uint64(preBufferSize)), humanize.Bytes(uint64(postBufferSize)),
 		preBufferOffset, postBufferOffset, file.Offset)
 
 	t.Service.SetBufferingLimits()
 
+	go t.probeStreamFile(file)
+
 	t.muBuffer.Lock()
 	defer t.muBuffer.Unlock()
 
@@ -616,6 +632,19 @@ func (t *Torrent) PrioritizePieces() {
 	seeds, seedsTotal, peers, peersTotal := t.GetConnections()
 	log.Debugf("Prioritizing pieces: %v%% / %s / %s, Con: %d/%d + %d/%d", int(t.GetProgress()), downSpeed, upSpeed, seeds, seedsTotal, peers, peersTotal)
 
+	// Readahead for file storage depends on download rate, so we refresh it while playing
+	if t.IsPlaying && !t.IsMemoryStorage() && config.Get().AutoAdjustBufferSize {
+		readahead := t.GetReadaheadSize()
+
+		t.muReaders.Lock()
+		isChanged := readahead != t.lastReadahead
+		t.muReaders.Unlock()
+
+		if isChanged {
+			t.ResetReaders()
+		}
+	}
+
 	t.muReaders.Lock()
 
 	numPieces := t.ti.NumPieces()
@@ -645,11 +674,10 @@ func (t *Torrent) PrioritizePieces() {
 		pr := r.ReaderPiecesRange()
 		log.Debugf("Reader range: %+v, last: %s", pr, r.lastUsed.Format(time.RFC3339))
 
-		for curPiece := pr.Begin; curPiece <= pr.End; curPiece++ {
+		for pos, curPiece := range r.ReaderPieces() {
 			if t.awaitingPieces.ContainsInt(curPiece) {
 				readerPieces[curPiece] = 7
 			} else {
-				pos := curPiece - pr.Begin
 				switch {
 				case pos <= 0:
 					readerPieces[curPiece] = 6
@@ -814,6 +842,8 @@ func (t *Torrent) GetSmartState() int {
 
 	if t.Service.Session.IsPaused() {
 		return StatusPaused
+	} else if t.IsQueued {
+		return StatusQueued
 	} else if torrentStatus.GetPaused() && state != StatusFinished && state != StatusFinding {
 		if progress == 100 {
 			return StatusFinished
@@ -1235,6 +1265,7 @@ func (t *Torrent) Pause() {
 	t.th.Pause()
 
 	t.IsPaused = true
+	t.setQueued(false)
 }
 
 // Resume ...
@@ -1249,6 +1280,77 @@ func (t *Torrent) Resume() {
 	t.th.Resume()
 
 	t.IsPaused = false
+	t.setQueued(false)
+}
+
+// ForceRecheck verifies downloaded data against piece hashes
+func (t *Torrent) ForceRecheck() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Rechecking torrent: %s", t.InfoHash())
+	t.th.ForceRecheck()
+}
+
+// ForceReannounce announces torrent to all trackers
+func (t *Torrent) ForceReannounce() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Reannouncing torrent: %s", t.InfoHash())
+	t.th.ForceReannounce()
+}
+
+// queue pauses torrent to free a slot for other torrents in the queue
+func (t *Torrent) queue() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Queueing torrent: %s", t.Name())
+
+	t.th.AutoManaged(false)
+	t.th.Pause()
+
+	t.setQueued(true)
+}
+
+// unqueue resumes torrent, that was paused by the queue
+func (t *Torrent) unqueue() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Starting queued torrent: %s", t.Name())
+
+	t.th.AutoManaged(true)
+	t.th.Resume()
+
+	t.setQueued(false)
+}
+
+// setQueued saves queued state, so torrents paused by the queue are resumed by it after restart
+func (t *Torrent) setQueued(queued bool) {
+	if t.IsQueued == queued {
+		return
+	}
+
+	t.IsQueued = queued
+	if err := database.GetStorm().UpdateBTItemQueued(t.InfoHash(), queued); err != nil {
+		log.Debugf("Could not save queued state of %s: %s", t.InfoHash(), err)
+	}
+}
+
+// isStreaming checks if torrent is used by a player
+func (t *Torrent) isStreaming() bool {
+	return t.PlayerAttached > 0 || t.IsBuffering || t.IsPlaying
+}
+
+// IsFinished checks if all selected files are downloaded
+func (t *Torrent) IsFinished() bool {
+	return t.GetLastStatus(false).GetProgress() >= 1
 }
 
 // GetDBItem ...
@@ -1295,7 +1397,11 @@ func (t *Torrent) GetReadaheadSize() (ret int64) {
 
 	defaultRA := int64(50 * 1024 * 1024)
 	if !t.IsMemoryStorage() {
-		return defaultRA
+		t.muBuffer.RLock()
+		file := t.bufferFile
+		t.muBuffer.RUnlock()
+
+		return t.getAdaptiveReadahead(file, defaultRA)
 	}
 
 	size := defaultRA
@@ -1331,6 +1437,7 @@ func (t *Torrent) ResetReaders() {
 	}
 
 	perReaderSize := t.GetReadaheadSize()
+	t.lastReadahead = perReaderSize
 	countActive := float64(0)
 	countIdle := float64(0)
 	countHead := float64(0)
@@ -1409,13 +1516,7 @@ func (t *Torrent) ReadersReadaheadSum() int64 {
 func (t *Torrent) GetMetadata() []byte {
 	defer perf.ScopeTimer()()
 
-	torrentFile := lt.NewCreateTorrent(t.ti)
-	defer lt.DeleteCreateTorrent(torrentFile)
-
-	torrentContent := torrentFile.Generate()
-	defer lt.DeleteEntry(torrentContent)
-
-	return []byte(lt.Bencode(torrentContent))
+	return torrentInfoBytes(t.ti)
 }
 
 // MakeFiles ...
@@ -1521,7 +1622,7 @@ func (t *Torrent) onMetadataReceived() {
 	// Reset fastResumeFile
 	infoHash := t.InfoHash()
 	t.fastResumeFile = filepath.Join(t.Service.config.TorrentsPath, fmt.Sprintf("%s.fastresume", infoHash))
-	t.partsFile = filepath.Join(t.Service.config.DownloadPath, fmt.Sprintf(".%s.parts", infoHash))
+	t.partsFile = filepath.Join(t.DownloadPath(), fmt.Sprintf(".%s.parts", infoHash))
 	t.memoryStorageFile = filepath.Join(t.Service.config.TorrentsPath, fmt.Sprintf(".%s.memory", infoHash))
 	t.fileStorageFile = filepath.Join(t.Service.config.TorrentsPath, fmt.Sprintf(".%s.file", infoHash))
 
@@ -1739,7 +1840,11 @@ func (t *Torrent) GetCandidateFiles(btp *Player) ([]*CandidateFile, int, error)
 		if reSkip.MatchString(fileName) {
 			continue
 		}
-		if size > minSize {
+		// Only the first volume of RAR archive can be opened
+		if isRarVolume(fileName) && !isFirstRarVolume(fileName) {
+			continue
+		}
+		if size > minSize || (isRarVolume(fileName) && t.rarSize(f) > minSize) {
 			candidateFiles = append(candidateFiles, i)
 		}
 		if strings.Contains(f.Path, "BDMV/STREAM/") {
@@ -1747,14 +1852,9 @@ func (t *Torrent) GetCandidateFiles(btp *Player) ([]*CandidateFile, int, error)
 			continue
 		}
 
+		// Download confirmation is asked by the player, if archive can't be streamed
 		if reRar.MatchString(fileName) && size > 10*1024*1024 {
 			t.IsRarArchive = true
-			if !btp.xbmcHost.DialogConfirm("Elementum", "LOCALIZE[30303]") {
-				if btp != nil {
-					btp.notEnoughSpace = true
-				}
-				return nil, -1, errors.New("RAR archive detected and download was cancelled")
-			}
 		}
 	}
 
@@ -1911,27 +2011,16 @@ func (t *Torrent) ChooseFile(btp *Player) (*File, int, error) {
 			}
 
 			if s := tmdb.GetShow(btp.p.ShowID, config.Get().Language); s != nil && s.IsAnime() {
-				season := tmdb.GetSeason(btp.p.ShowID, btp.p.Season, config.Get().Language, len(s.Seasons))
-				if season != nil && season.HasEpisode(btp.p.Episode) {
-					an, _ := s.AnimeInfo(season.GetEpisode(btp.p.Episode))
-					if an != 0 {
-						btp.p.AbsoluteNumber = an
-
-						re := regexp.MustCompile(fmt.Sprintf(singleEpisodeMatchRegex, btp.p.AbsoluteNumber))
-						for index, choice := range choices {
-							if re.MatchString(choice.Filename) {
-								lastMatched = index
-								foundMatches++
-							}
-						}
-
-						if foundMatches == 1 {
-							if btp == nil {
-								t.DownloadFile(files[choices[lastMatched].Index])
-								t.SaveDBFiles()
-							}
-							return files[choices[lastMatched].Index], lastMatched, nil
+				numbers := anime.EpisodeNumbers(s, anime.GetTVDBShow(s, config.Get().Language), btp.p.Season, btp.p.Episode)
+				if len(numbers) > 0 {
+					btp.p.AbsoluteNumber = numbers[0]
+
+					if lastMatched, foundMatches = matchAnimeEpisode(numbers, choices); foundMatches == 1 {
+						if btp == nil {
+							t.DownloadFile(files[choices[lastMatched].Index])
+							t.SaveDBFiles()
 						}
+						return files[choices[lastMatched].Index], lastMatched, nil
 					}
 				}
 			}
@@ -1942,6 +2031,24 @@ func (t *Torrent) ChooseFile(btp *Player) (*File, int, error) {
 			return files[choices[btp.p.FileIndex].Index], btp.p.FileIndex, nil
 		}
 
+		// Probing candidates, as probe results are used for the choice and for the dialog
+		t.probeCandidates(choices)
+
+		// Selecting the only probed file with audio in preferred language
+		if btp != nil && btp.p.Episode == 0 {
+			if index := t.chooseByLanguage(choices, config.Get().Language); index >= 0 {
+				log.Infof("Choosing %s, as the only file with audio in %s", choices[index].Path, config.Get().Language)
+				return files[choices[index].Index], index, nil
+			}
+		}
+
+		// Adding probed media information to file names
+		for _, c := range choices {
+			if desc := probeDescription(t.GetProbeInfo(files[c.Index])); desc != "" {
+				c.DisplayName += " [COLOR lightblue][" + desc + "][/COLOR]"
+			}
+		}
+
 		searchTitle := ""
 		if btp != nil {
 			if btp.p.AbsoluteNumber > 0 {
@@ -2001,6 +2108,33 @@ func (t *Torrent) ChooseFile(btp *Player) (*File, int, error) {
 	return files[biggestFile], -1, nil
 }
 
+// chooseByLanguage returns index of a choice, if it is the only probed file
+// that has audio track in the language, otherwise returns -1.
+func (t *Torrent) chooseByLanguage(choices []*CandidateFile, language string) int {
+	found := -1
+	probed := 0
+	for i, c := range choices {
+		info := t.GetProbeInfo(t.files[c.Index])
+		if info == nil {
+			continue
+		}
+
+		probed++
+		if info.HasAudioLanguage(language) {
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+	}
+
+	// Choosing only makes sense if there are other files with different languages
+	if probed < 2 {
+		return -1
+	}
+	return found
+}
+
 // GetPlayURL returns url ready for Kodi
 func (t *Torrent) GetPlayURL(fileIndex string) string {
 	var (
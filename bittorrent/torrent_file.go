@@ -535,7 +535,7 @@ func (t *TorrentFile) Resolve() error {
 
 // EnrichTrackers ...
 func (t *TorrentFile) EnrichTrackers() {
-	for _, trackerURL := range extraTrackers {
+	for _, trackerURL := range HealthyTrackers(getExtraTrackers()) {
 		if !util.StringSliceContains(t.Trackers, trackerURL) {
 			t.Trackers = append(t.Trackers, trackerURL)
 		}
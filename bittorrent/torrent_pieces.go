@@ -0,0 +1,183 @@
+package bittorrent
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	lt "github.com/ElementumOrg/libtorrent-go"
+)
+
+// PiecesHealth is a state of torrent pieces and active readers, used to render piece bar
+// and to find out why a stream is stalling.
+type PiecesHealth struct {
+	NumPieces    int    `json:"num_pieces"`
+	PieceLength  int64  `json:"piece_length"`
+	Completed    int    `json:"completed"`
+	DownloadRate int    `json:"download_rate"`
+	Buffering    bool   `json:"buffering"`
+	Playing      bool   `json:"playing"`
+	Memory       bool   `json:"memory"`
+	Pieces       string `json:"pieces"`
+
+	// Number of connected peers, that have each piece
+	Availability []int `json:"availability"`
+
+	// Pieces with deadlines and pieces, that are requested directly
+	Awaiting []int `json:"awaiting"`
+	Demand   []int `json:"demand"`
+
+	Readers []*ReaderHealth `json:"readers"`
+}
+
+// ReaderHealth is a readahead window of the reader with its completion estimate
+type ReaderHealth struct {
+	ID        int64     `json:"id"`
+	File      string    `json:"file"`
+	Active    bool      `json:"active"`
+	Head      bool      `json:"head"`
+	LastUsed  time.Time `json:"last_used"`
+	Readahead int64     `json:"readahead"`
+	Begin     int       `json:"begin"`
+	End       int       `json:"end"`
+	Completed int       `json:"completed"`
+	Progress  float64   `json:"progress"`
+
+	// First piece of the window, that is not downloaded, and number of peers, having it
+	FirstMissing             int `json:"first_missing"`
+	FirstMissingAvailability int `json:"first_missing_availability"`
+
+	// Bytes left to download for the window, and estimated seconds with current download rate,
+	// -1 means that window can not be completed with current rate
+	MissingBytes int64   `json:"missing_bytes"`
+	ETA          float64 `json:"eta"`
+}
+
+// PiecesHealth collects pieces bitfield, availability, deadlines and reader windows
+func (t *Torrent) PiecesHealth() *PiecesHealth {
+	ret := &PiecesHealth{
+		Availability: []int{},
+		Awaiting:     []int{},
+		Demand:       []int{},
+		Readers:      []*ReaderHealth{},
+	}
+	if t.Closer.IsSet() || t.th == nil || !t.th.IsValid() || !t.gotMetainfo.IsSet() {
+		return ret
+	}
+
+	ret.NumPieces = t.pieceCount
+	ret.PieceLength = t.pieceLength
+	ret.Buffering = t.IsBuffering
+	ret.Playing = t.IsPlaying
+	ret.Memory = t.IsMemoryStorage()
+	ret.DownloadRate, _ = t.GetSpeeds()
+
+	if err := t.updatePieces(); err == nil {
+		pieces := bytes.Buffer{}
+		pieces.Grow(t.pieceCount)
+
+		t.piecesMx.RLock()
+		for i := 0; i < t.pieceCount; i++ {
+			if t.pieces.GetBit(i) {
+				ret.Completed++
+				pieces.WriteByte('1')
+			} else {
+				pieces.WriteByte('0')
+			}
+		}
+		t.piecesMx.RUnlock()
+
+		ret.Pieces = pieces.String()
+	}
+
+	availability := lt.NewStdVectorInt()
+	defer lt.DeleteStdVectorInt(availability)
+
+	t.th.PieceAvailability(availability)
+	for i := 0; i < int(availability.Size()); i++ {
+		ret.Availability = append(ret.Availability, availability.Get(i))
+	}
+
+	t.muAwaitingPieces.RLock()
+	for _, piece := range t.awaitingPieces.ToArray() {
+		ret.Awaiting = append(ret.Awaiting, int(piece))
+	}
+	t.muAwaitingPieces.RUnlock()
+
+	t.muDemandPieces.RLock()
+	for _, piece := range t.demandPieces.ToArray() {
+		ret.Demand = append(ret.Demand, int(piece))
+	}
+	t.muDemandPieces.RUnlock()
+
+	t.muReaders.Lock()
+	windows := map[*ReaderHealth][]int{}
+	for _, r := range t.readers {
+		pr := r.ReaderPiecesRange()
+		rh := &ReaderHealth{
+			ID:           r.id,
+			File:         r.f.Path,
+			Active:       r.IsActive(),
+			Head:         r.IsHead(),
+			LastUsed:     r.lastUsed,
+			Readahead:    r.Readahead(),
+			Begin:        pr.Begin,
+			End:          pr.End,
+			FirstMissing: -1,
+		}
+		windows[rh] = r.ReaderPieces()
+		ret.Readers = append(ret.Readers, rh)
+	}
+	t.muReaders.Unlock()
+
+	for rh, window := range windows {
+		t.readerHealth(rh, window, ret)
+	}
+
+	sort.Slice(ret.Readers, func(i, j int) bool {
+		return ret.Readers[i].ID < ret.Readers[j].ID
+	})
+	return ret
+}
+
+// readerHealth calculates completion of the reader window with partial pieces progress
+func (t *Torrent) readerHealth(rh *ReaderHealth, window []int, ph *PiecesHealth) {
+	if len(window) == 0 {
+		return
+	}
+
+	progress := map[int]float64{}
+	for _, piece := range window {
+		progress[piece] = 0
+	}
+	t.piecesProgress(progress)
+
+	total := float64(0)
+	for _, piece := range window {
+		p := progress[piece]
+		total += p
+
+		if p >= 1.0 {
+			rh.Completed++
+			continue
+		}
+
+		rh.MissingBytes += int64(float64(t.pieceLength) * (1 - p))
+		if rh.FirstMissing < 0 {
+			rh.FirstMissing = piece
+			if piece < len(ph.Availability) {
+				rh.FirstMissingAvailability = ph.Availability[piece]
+			}
+		}
+	}
+	rh.Progress = 100 * total / float64(len(window))
+
+	switch {
+	case rh.MissingBytes == 0:
+		rh.ETA = 0
+	case ph.DownloadRate > 0:
+		rh.ETA = float64(rh.MissingBytes) / float64(ph.DownloadRate)
+	default:
+		rh.ETA = -1
+	}
+}
@@ -0,0 +1,211 @@
+package bittorrent
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	lt "github.com/ElementumOrg/libtorrent-go"
+
+	"github.com/elgatito/elementum/database"
+)
+
+var errTrackerIndex = errors.New("Tracker index is out of range")
+
+// TrackerStatus is a state of a torrent tracker, combined from announce entry and tracker alerts
+type TrackerStatus struct {
+	Index        int       `json:"index"`
+	URL          string    `json:"url"`
+	Tier         int       `json:"tier"`
+	Working      bool      `json:"working"`
+	Updating     bool      `json:"updating"`
+	Verified     bool      `json:"verified"`
+	Fails        int       `json:"fails"`
+	Seeds        int       `json:"seeds"`
+	Peers        int       `json:"peers"`
+	ReplyPeers   int       `json:"reply_peers"`
+	LastAnnounce time.Time `json:"last_announce"`
+	Message      string    `json:"message"`
+	Error        string    `json:"error"`
+}
+
+// trackerAlertState keeps information from tracker alerts, which is not stored in announce entries.
+// It is updated from alerts loop and read by API handlers, so fields are guarded by the mutex.
+type trackerAlertState struct {
+	mu sync.Mutex
+
+	ReplyPeers   int
+	LastAnnounce time.Time
+	Error        string
+	Message      string
+}
+
+func (t *Torrent) trackerState(url string) *trackerAlertState {
+	v, _ := t.trackerAlerts.LoadOrStore(url, &trackerAlertState{})
+	return v.(*trackerAlertState)
+}
+
+// onTrackerReply stores successful announce
+func (t *Torrent) onTrackerReply(url string, peers int) {
+	t.trackers.Store(url, peers)
+
+	st := t.trackerState(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.ReplyPeers = peers
+	st.LastAnnounce = time.Now()
+	st.Error = ""
+}
+
+// onTrackerError stores failed announce
+func (t *Torrent) onTrackerError(url, message string) {
+	st := t.trackerState(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.LastAnnounce = time.Now()
+	st.Error = message
+}
+
+// onTrackerWarning stores warning, sent by the tracker
+func (t *Torrent) onTrackerWarning(url, message string) {
+	st := t.trackerState(url)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Message = message
+}
+
+// Trackers returns current trackers of the torrent with their status
+func (t *Torrent) Trackers() []*TrackerStatus {
+	ret := []*TrackerStatus{}
+	if t.Closer.IsSet() || t.th == nil || !t.th.IsValid() {
+		return ret
+	}
+
+	trackers := t.th.Trackers()
+	defer lt.DeleteStdVectorAnnounceEntry(trackers)
+
+	for i := 0; i < int(trackers.Size()); i++ {
+		tracker := trackers.Get(i)
+		ts := &TrackerStatus{
+			Index:    i,
+			URL:      tracker.GetUrl(),
+			Tier:     int(tracker.GetTier()),
+			Working:  tracker.IsWorking(),
+			Updating: tracker.GetUpdating(),
+			Verified: tracker.GetVerified(),
+			Fails:    int(tracker.GetFails()),
+			Seeds:    int(tracker.GetScrapeComplete()),
+			Peers:    int(tracker.GetScrapeIncomplete()),
+			Message:  tracker.GetMessage(),
+		}
+
+		if v, ok := t.trackerAlerts.Load(ts.URL); ok {
+			st := v.(*trackerAlertState)
+			st.mu.Lock()
+			ts.ReplyPeers = st.ReplyPeers
+			ts.LastAnnounce = st.LastAnnounce
+			ts.Error = st.Error
+			if st.Message != "" {
+				ts.Message = st.Message
+			}
+			st.mu.Unlock()
+		}
+
+		ret = append(ret, ts)
+	}
+
+	return ret
+}
+
+// TrackerURLs returns list of tracker urls
+func (t *Torrent) TrackerURLs() []string {
+	ret := []string{}
+	for _, ts := range t.Trackers() {
+		ret = append(ret, ts.URL)
+	}
+	return ret
+}
+
+// SetTrackers replaces trackers of the running torrent, list is saved to be restored on next start
+func (t *Torrent) SetTrackers(urls []string) {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	clean := []string{}
+	seen := map[string]bool{}
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" && !seen[u] {
+			seen[u] = true
+			clean = append(clean, u)
+		}
+	}
+
+	log.Infof("Setting %d trackers for torrent: %s", len(clean), t.InfoHash())
+	replaceTrackers(t.th, clean)
+
+	if t.ensureDBItem() != nil {
+		database.GetStorm().UpdateBTItemTrackers(t.InfoHash(), clean)
+		t.FetchDBItem()
+	}
+}
+
+// AddTrackers adds trackers to the end of the list
+func (t *Torrent) AddTrackers(urls []string) {
+	t.SetTrackers(append(t.TrackerURLs(), urls...))
+}
+
+// RemoveTracker removes tracker by its index in the list
+func (t *Torrent) RemoveTracker(index int) error {
+	urls := t.TrackerURLs()
+	if index < 0 || index >= len(urls) {
+		return errTrackerIndex
+	}
+
+	t.trackerAlerts.Delete(urls[index])
+	t.trackers.Delete(urls[index])
+	t.SetTrackers(append(urls[:index], urls[index+1:]...))
+	return nil
+}
+
+// ReannounceTracker announces torrent to a single tracker
+func (t *Torrent) ReannounceTracker(index int) error {
+	if t.Closer.IsSet() {
+		return nil
+	}
+	if index < 0 || index >= len(t.TrackerURLs()) {
+		return errTrackerIndex
+	}
+
+	log.Infof("Reannouncing torrent %s to tracker %d", t.InfoHash(), index)
+	t.th.ForceReannounce(0, index)
+	return nil
+}
+
+// ForceDHTAnnounce announces torrent to DHT
+func (t *Torrent) ForceDHTAnnounce() {
+	if t.Closer.IsSet() {
+		return
+	}
+
+	log.Infof("Announcing torrent to DHT: %s", t.InfoHash())
+	t.th.ForceDhtAnnounce()
+}
+
+func replaceTrackers(th lt.TorrentHandle, urls []string) {
+	trackers := lt.NewStdVectorAnnounceEntry()
+	defer lt.DeleteStdVectorAnnounceEntry(trackers)
+
+	for _, u := range urls {
+		announceEntry := lt.NewAnnounceEntry(u)
+		defer lt.DeleteAnnounceEntry(announceEntry)
+		trackers.Add(announceEntry)
+	}
+
+	th.ReplaceTrackers(trackers)
+}
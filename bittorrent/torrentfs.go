@@ -76,21 +76,12 @@ func (tfs *TorrentFS) Open(uname string) (http.File, error) {
 	log.Infof("Opening %s", name)
 
 	for _, t := range tfs.s.q.All() {
-		for _, f := range t.files {
+		for _, f := range t.AllFiles() {
 			if name[1:] == f.Path {
 				log.Noticef("%s belongs to torrent %s", name, t.Name())
 
-				if !t.IsMemoryStorage() {
-					file, err = os.Open(filepath.Join(string(tfs.Dir), name))
-					if err != nil {
-						return nil, err
-					}
-
-					// make sure we don't open a file that's locked, as it can happen
-					// on BSD systems (darwin included)
-					if err := unlockFile(file.(*os.File)); err != nil {
-						log.Errorf("Unable to unlock file because: %s", err)
-					}
+				if file, err = tfs.openFile(t, f); err != nil {
+					return nil, err
 				}
 
 				return NewTorrentFSEntry(file, tfs, t, f, name)
@@ -101,6 +92,84 @@ func (tfs *TorrentFS) Open(uname string) (http.File, error) {
 	return file, fmt.Errorf("Could not open file: %s", name)
 }
 
+// openFile opens torrent file from disk, for memory storage it returns nil,
+// so the file is read from memory storage.
+func (tfs *TorrentFS) openFile(t *Torrent, f *File) (http.File, error) {
+	if t.IsMemoryStorage() {
+		return nil, nil
+	} else if f.IsVirtual() {
+		return NewVirtualFile(t.DownloadPath(), f), nil
+	}
+
+	file, err := os.Open(filepath.Join(t.DownloadPath(), f.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure we don't open a file that's locked, as it can happen
+	// on BSD systems (darwin included)
+	if err := unlockFile(file); err != nil {
+		log.Errorf("Unable to unlock file because: %s", err)
+	}
+
+	return file, nil
+}
+
+// NewFileReader opens internal reader for a torrent file, which is not attached to Kodi.
+// It is opened as a HEAD reader, so it does not take readahead from the player.
+func (t *Torrent) NewFileReader(f *File) (*TorrentFSEntry, error) {
+	tfs := NewTorrentFS(t.Service, "HEAD")
+
+	file, err := tfs.openFile(t, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTorrentFSEntry(file, tfs, t, f, "/"+f.Path)
+}
+
+// withFileReader runs fn with internal reader for a file. Reader is closed on timeout,
+// or when torrent is closed, so blocked reads return with an error.
+func (t *Torrent) withFileReader(f *File, timeout time.Duration, fn func(r *TorrentFSEntry) error) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// File storage creates files on first write, so we wait for the first piece of a file
+	if !t.IsMemoryStorage() {
+		ticker := time.NewTicker(piecesRefreshDuration)
+		defer ticker.Stop()
+
+		for !t.hasPiece(f.PieceStart) {
+			select {
+			case <-t.Closer.C():
+				return errors.New("Torrent was closed")
+			case <-timer.C:
+				return fmt.Errorf("Timeout waiting for %s", f.Path)
+			case <-ticker.C:
+			}
+		}
+	}
+
+	reader, err := t.NewFileReader(f)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-t.Closer.C():
+		case <-timer.C:
+			log.Warningf("Timeout reading %s", f.Path)
+		}
+		reader.Close()
+	}()
+
+	return fn(reader)
+}
+
 // NewTorrentFSEntry ...
 func NewTorrentFSEntry(file http.File, tfs *TorrentFS, t *Torrent, f *File, name string) (*TorrentFSEntry, error) {
 	if file == nil {
@@ -194,6 +263,10 @@ func (tf *TorrentFSEntry) Read(data []byte) (n int, err error) {
 		if pieceOffset+size > tf.pieceLength {
 			size = tf.pieceLength - pieceOffset
 		}
+		// Virtual file data is not continuous in the torrent, so reading segment by segment
+		if segmentLeft := tf.f.segmentLeft(currentOffset); tf.f.IsVirtual() && segmentLeft > 0 && int64(size) > segmentLeft {
+			size = int(segmentLeft)
+		}
 
 		b := data[pos : pos+size]
 		n1 := 0
@@ -284,7 +357,13 @@ func (tf *TorrentFSEntry) waitForPiece(piece int) error {
 		tf.t.muAwaitingPieces.Unlock()
 	}()
 
-	tf.t.PrioritizePiece(piece)
+	if tf.isHead {
+		// Internal readers are used while buffering, when PrioritizePiece is disabled
+		tf.t.th.PiecePriority(piece, 7)
+		tf.t.th.SetPieceDeadline(piece, 0, 0)
+	} else {
+		tf.t.PrioritizePiece(piece)
+	}
 
 	pieceRefreshTicker := time.NewTicker(piecesRefreshDuration)
 	defer pieceRefreshTicker.Stop()
@@ -315,8 +394,9 @@ func (tf *TorrentFSEntry) pieceFromOffset(offset int64) (int, int) {
 		return 0, 0
 	}
 
-	piece := (tf.f.Offset + offset) / int64(tf.pieceLength)
-	pieceOffset := (tf.f.Offset + offset) % int64(tf.pieceLength)
+	torrentOffset := tf.torrentOffset(offset)
+	piece := torrentOffset / int64(tf.pieceLength)
+	pieceOffset := torrentOffset % int64(tf.pieceLength)
 
 	if int(piece) > tf.t.pieceCount {
 		piece = int64(tf.t.pieceCount)
@@ -333,6 +413,43 @@ func (tf *TorrentFSEntry) ReaderPiecesRange() (ret PieceRange) {
 	return tf.byteRegionPieces(tf.torrentOffset(pos), ra)
 }
 
+// ReaderPieces returns pieces of the reader readahead window, in the reading order.
+// For virtual files the window follows file segments, which are not continuous in the torrent.
+func (tf *TorrentFSEntry) ReaderPieces() []int {
+	ret := []int{}
+	if !tf.f.IsVirtual() {
+		pr := tf.ReaderPiecesRange()
+		for piece := pr.Begin; piece <= pr.End; piece++ {
+			ret = append(ret, piece)
+		}
+		return ret
+	}
+
+	pos, _ := tf.Pos()
+	ra := tf.Readahead()
+	seen := map[int]bool{}
+	for ra > 0 {
+		size := tf.f.segmentLeft(pos)
+		if size <= 0 {
+			break
+		} else if size > ra {
+			size = ra
+		}
+
+		pr := tf.byteRegionPieces(tf.torrentOffset(pos), size)
+		for piece := pr.Begin; piece <= pr.End; piece++ {
+			if !seen[piece] {
+				seen[piece] = true
+				ret = append(ret, piece)
+			}
+		}
+
+		pos += size
+		ra -= size
+	}
+	return ret
+}
+
 // Readahead returns current reader readahead
 func (tf *TorrentFSEntry) Readahead() int64 {
 	ra := tf.readahead
@@ -354,7 +471,7 @@ func (tf *TorrentFSEntry) Pos() (int64, error) {
 }
 
 func (tf *TorrentFSEntry) torrentOffset(readerPos int64) int64 {
-	return tf.f.Offset + readerPos
+	return tf.f.TorrentOffset(readerPos)
 }
 
 // Returns the range of pieces [begin, end) that contains the extent of bytes.
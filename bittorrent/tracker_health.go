@@ -0,0 +1,199 @@
+package bittorrent
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/anacrolix/sync"
+	"github.com/gin-gonic/gin"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/proxy"
+)
+
+const (
+	trackerProbeInterval    = 2 * time.Hour
+	trackerProbeConcurrency = 16
+)
+
+// TrackerHealth is a result of tracker probes
+type TrackerHealth struct {
+	URL          string        `json:"url"`
+	Alive        bool          `json:"alive"`
+	ResponseTime time.Duration `json:"response_time"`
+	Checks       int           `json:"checks"`
+	Failures     int           `json:"failures"`
+	LastCheck    time.Time     `json:"last_check"`
+	LastError    string        `json:"last_error"`
+}
+
+var trackersHealth = struct {
+	sync.RWMutex
+	items map[string]*TrackerHealth
+}{
+	items: map[string]*TrackerHealth{},
+}
+
+// probeTracker checks that tracker responds: UDP trackers should accept connection request,
+// HTTP trackers should return any response to scrape or announce request.
+func probeTracker(trackerURL string) (time.Duration, error) {
+	tracker, err := NewTracker(trackerURL)
+	if err != nil {
+		return 0, err
+	}
+
+	started := time.Now()
+	if !tracker.IsHTTP() {
+		err = tracker.Connect()
+		if tracker.connection != nil {
+			tracker.connection.Close()
+		}
+		return time.Since(started), err
+	}
+
+	probeURL, err := tracker.ScrapeURL()
+	if err != nil {
+		probeURL = tracker.URL.String()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, probeURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client := *proxy.GetClient()
+	client.Timeout = defaultTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Since(started), err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return time.Since(started), fmt.Errorf("Bad status code: %d", resp.StatusCode)
+	}
+	return time.Since(started), nil
+}
+
+// ProbeTrackers checks trackers in parallel and stores results in health cache
+func ProbeTrackers(urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	started := time.Now()
+	limit := make(chan struct{}, trackerProbeConcurrency)
+	wg := sync.WaitGroup{}
+	for _, u := range urls {
+		wg.Add(1)
+		limit <- struct{}{}
+
+		go func(u string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+
+			responseTime, err := probeTracker(u)
+			updateTrackerHealth(u, responseTime, err)
+		}(u)
+	}
+	wg.Wait()
+
+	log.Infof("Checked %d trackers in %s, %d are alive", len(urls), time.Since(started), len(HealthyTrackers(urls)))
+}
+
+func updateTrackerHealth(url string, responseTime time.Duration, err error) {
+	trackersHealth.Lock()
+	defer trackersHealth.Unlock()
+
+	th, ok := trackersHealth.items[url]
+	if !ok {
+		th = &TrackerHealth{URL: url}
+		trackersHealth.items[url] = th
+	}
+
+	th.Checks++
+	th.LastCheck = time.Now()
+	th.Alive = err == nil
+	if err != nil {
+		th.Failures++
+		th.LastError = err.Error()
+	} else {
+		th.ResponseTime = responseTime
+		th.LastError = ""
+	}
+}
+
+// HealthyTrackers filters out trackers, that failed last probe.
+// Trackers, that were not checked yet, are considered healthy.
+func HealthyTrackers(urls []string) []string {
+	trackersHealth.RLock()
+	defer trackersHealth.RUnlock()
+
+	ret := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if th, ok := trackersHealth.items[u]; !ok || th.Alive {
+			ret = append(ret, u)
+		}
+	}
+	return ret
+}
+
+// GetTrackersHealth returns probe results, sorted by response time, dead trackers go last
+func GetTrackersHealth() []*TrackerHealth {
+	trackersHealth.RLock()
+	ret := make([]*TrackerHealth, 0, len(trackersHealth.items))
+	for _, th := range trackersHealth.items {
+		item := *th
+		ret = append(ret, &item)
+	}
+	trackersHealth.RUnlock()
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Alive != ret[j].Alive {
+			return ret[i].Alive
+		}
+		return ret[i].ResponseTime < ret[j].ResponseTime
+	})
+	return ret
+}
+
+// trackersProber periodically re-checks extra trackers
+func (s *Service) trackersProber() {
+	closing := s.Closer.C()
+	ticker := time.NewTicker(trackerProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			if config.Get().AddExtraTrackers != addExtraTrackersNone {
+				ProbeTrackers(getExtraTrackers())
+			}
+		}
+	}
+}
+
+// DebugTrackers shows health of extra trackers
+func DebugTrackers(s *Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer.Header().Set("Content-Type", "text/plain")
+
+		for _, th := range GetTrackersHealth() {
+			state := "alive"
+			if !th.Alive {
+				state = "dead"
+			}
+			fmt.Fprintf(ctx.Writer, "%-5s %8s  %d/%d failed  %s", state, th.ResponseTime.Round(time.Millisecond), th.Failures, th.Checks, th.URL)
+			if th.LastError != "" {
+				fmt.Fprintf(ctx.Writer, "  (%s)", th.LastError)
+			}
+			fmt.Fprintln(ctx.Writer)
+		}
+	}
+}
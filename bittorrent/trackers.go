@@ -10,10 +10,14 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/anacrolix/sync"
+	"github.com/zeebo/bencode"
+
 	"github.com/elgatito/elementum/config"
 	"github.com/elgatito/elementum/proxy"
 	"github.com/elgatito/elementum/util"
@@ -24,8 +28,13 @@ const (
 	defaultTimeout                   = 3 * time.Second
 	defaultBufferSize                = 2048 // must be bigger than MTU, which is 1500 most of the time
 	maxScrapedHashes                 = 70
+	maxScrapeResponseSize            = 1024 * 1024
+	httpTrackerTimeout               = 15 * time.Second
 )
 
+// muExtraTrackers guards extraTrackers, which are replaced by UpdateDefaultTrackers
+var muExtraTrackers sync.RWMutex
+
 const (
 	// ActionConnect ...
 	ActionConnect Action = iota
@@ -107,8 +116,8 @@ func NewTracker(trackerURL string) (tracker *Tracker, err error) {
 	if err != nil {
 		return
 	}
-	if tURL.Scheme != "udp" {
-		err = errors.New("Only UDP trackers are supported")
+	if tURL.Scheme != "udp" && tURL.Scheme != "http" && tURL.Scheme != "https" {
+		err = errors.New("Only UDP and HTTP trackers are supported")
 		return
 	}
 	tracker = &Tracker{
@@ -159,8 +168,18 @@ func (tracker *Tracker) sendRequest(action Action, request interface{}) error {
 	return nil
 }
 
+// IsHTTP tells whether tracker uses HTTP(S) protocol
+func (tracker *Tracker) IsHTTP() bool {
+	return tracker.URL.Scheme == "http" || tracker.URL.Scheme == "https"
+}
+
 // Connect ...
 func (tracker *Tracker) Connect() error {
+	if tracker.IsHTTP() {
+		// HTTP trackers do not keep connections
+		return nil
+	}
+
 	if !strings.Contains(tracker.URL.Host, ":") {
 		tracker.URL.Host += ":80"
 	}
@@ -189,6 +208,10 @@ func (tracker *Tracker) doScrape(infoHashes [][]byte) []ScrapeResponseEntry {
 
 // Scrape ...
 func (tracker *Tracker) Scrape(torrents []*TorrentFile) []ScrapeResponseEntry {
+	if tracker.IsHTTP() {
+		return tracker.scrapeHTTP(torrents)
+	}
+
 	entries := make([]ScrapeResponseEntry, 0, len(torrents))
 
 	infoHashes := make([][]byte, 0, len(torrents))
@@ -209,35 +232,165 @@ func (tracker *Tracker) Scrape(torrents []*TorrentFile) []ScrapeResponseEntry {
 	return entries
 }
 
+// ScrapeURL returns scrape url of HTTP tracker, as described in BEP 48:
+// last path element should start with "announce", which is replaced with "scrape".
+func (tracker *Tracker) ScrapeURL() (string, error) {
+	u := *tracker.URL
+
+	idx := strings.LastIndex(u.Path, "/")
+	if idx < 0 || !strings.HasPrefix(u.Path[idx+1:], "announce") {
+		return "", errors.New("Tracker does not support scrape")
+	}
+	u.Path = u.Path[:idx+1] + "scrape" + strings.TrimPrefix(u.Path[idx+1:], "announce")
+
+	return u.String(), nil
+}
+
+// httpScrapeResponse is a bencoded response of HTTP tracker, files are keyed by binary info hash
+type httpScrapeResponse struct {
+	Files map[string]struct {
+		Complete   int32 `bencode:"complete"`
+		Downloaded int32 `bencode:"downloaded"`
+		Incomplete int32 `bencode:"incomplete"`
+	} `bencode:"files"`
+	FailureReason string `bencode:"failure reason"`
+}
+
+func (tracker *Tracker) doScrapeHTTP(scrapeURL string, infoHashes [][]byte) ([]ScrapeResponseEntry, error) {
+	params := url.Values{}
+	for _, hash := range infoHashes {
+		params.Add("info_hash", string(hash))
+	}
+
+	sep := "?"
+	if strings.Contains(scrapeURL, "?") {
+		sep = "&"
+	}
+
+	client := *proxy.GetClient()
+	client.Timeout = httpTrackerTimeout
+	resp, err := client.Get(scrapeURL + sep + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Bad status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScrapeResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response := httpScrapeResponse{}
+	if err := bencode.DecodeBytes(body, &response); err != nil {
+		return nil, err
+	} else if response.FailureReason != "" {
+		return nil, errors.New(response.FailureReason)
+	}
+
+	entries := make([]ScrapeResponseEntry, len(infoHashes))
+	for i, hash := range infoHashes {
+		if f, ok := response.Files[string(hash)]; ok {
+			entries[i] = ScrapeResponseEntry{
+				Seeders:   f.Complete,
+				Completed: f.Downloaded,
+				Leechers:  f.Incomplete,
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (tracker *Tracker) scrapeHTTP(torrents []*TorrentFile) []ScrapeResponseEntry {
+	entries := make([]ScrapeResponseEntry, 0, len(torrents))
+
+	scrapeURL, err := tracker.ScrapeURL()
+	if err != nil {
+		return entries
+	}
+
+	infoHashes := make([][]byte, 0, len(torrents))
+	for _, torrent := range torrents {
+		bhash, _ := hex.DecodeString(torrent.InfoHash)
+		infoHashes = append(infoHashes, bhash)
+	}
+
+	for idx := 0; idx < len(infoHashes); idx += maxScrapedHashes {
+		max := idx + maxScrapedHashes
+		if max > len(infoHashes) {
+			max = len(infoHashes)
+		}
+
+		res, err := tracker.doScrapeHTTP(scrapeURL, infoHashes[idx:max])
+		if err != nil {
+			log.Debugf("Could not scrape %s: %s", tracker, err)
+			res = make([]ScrapeResponseEntry, max-idx)
+		}
+		entries = append(entries, res...)
+	}
+
+	return entries
+}
+
 func (tracker *Tracker) String() string {
 	return tracker.URL.String()
 }
 
 // UpdateDefaultTrackers fetches extra trackers from predefined page
 func UpdateDefaultTrackers() {
-	extraTrackers = []string{}
+	trackers := []string{}
 	if config.Get().AddExtraTrackers != addExtraTrackersNone {
 		// add Minimum set by default
-		extraTrackers = append(extraTrackers, defaultTrackers...)
+		trackers = append(trackers, defaultTrackers...)
 
 		if config.Get().AddExtraTrackers != addExtraTrackersMinimum {
-			finalExtraTrackersURL := fmt.Sprintf(extraTrackersURLTemplate, addExtraTrackersMap[config.Get().AddExtraTrackers])
-			resp, err := proxy.GetClient().Get(finalExtraTrackersURL)
-			if err != nil || resp == nil {
-				return
-			} else if err == nil && resp.StatusCode != 200 {
-				return
-			}
-			defer resp.Body.Close()
-
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			scanner := bufio.NewScanner(bytes.NewReader(bodyBytes))
-			for scanner.Scan() {
-				tracker := strings.TrimSpace(scanner.Text())
-				if !util.StringSliceContains(extraTrackers, tracker) {
-					extraTrackers = append(extraTrackers, tracker)
+			for _, tracker := range fetchExtraTrackers() {
+				if !util.StringSliceContains(trackers, tracker) {
+					trackers = append(trackers, tracker)
 				}
 			}
 		}
+
+		// Check fetched trackers in background, dead ones are skipped when trackers are added to torrents
+		go ProbeTrackers(append([]string{}, trackers...))
+	}
+
+	muExtraTrackers.Lock()
+	extraTrackers = trackers
+	muExtraTrackers.Unlock()
+}
+
+// fetchExtraTrackers downloads list of trackers for configured extra trackers set
+func fetchExtraTrackers() []string {
+	client := *proxy.GetClient()
+	client.Timeout = httpTrackerTimeout
+	resp, err := client.Get(fmt.Sprintf(extraTrackersURLTemplate, addExtraTrackersMap[config.Get().AddExtraTrackers]))
+	if err != nil {
+		return nil
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	ret := []string{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if tracker := strings.TrimSpace(scanner.Text()); tracker != "" {
+			ret = append(ret, tracker)
+		}
+	}
+	return ret
+}
+
+// getExtraTrackers returns copy of extra trackers list
+func getExtraTrackers() []string {
+	muExtraTrackers.RLock()
+	defer muExtraTrackers.RUnlock()
+
+	return append([]string{}, extraTrackers...)
 }
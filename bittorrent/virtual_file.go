@@ -0,0 +1,156 @@
+package bittorrent
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anacrolix/sync"
+)
+
+// VirtualFile reads virtual file data from torrent files on disk
+type VirtualFile struct {
+	dir   string
+	f     *File
+	files map[*File]*os.File
+
+	mu  sync.Mutex
+	pos int64
+}
+
+// NewVirtualFile ...
+func NewVirtualFile(dir string, file *File) *VirtualFile {
+	return &VirtualFile{
+		dir:   dir,
+		f:     file,
+		files: map[*File]*os.File{},
+	}
+}
+
+func (vf *VirtualFile) open(f *File) (*os.File, error) {
+	if fh, ok := vf.files[f]; ok {
+		return fh, nil
+	}
+
+	fh, err := os.Open(filepath.Join(vf.dir, f.Path))
+	if err != nil {
+		return nil, err
+	}
+	if err := unlockFile(fh); err != nil {
+		log.Errorf("Unable to unlock file because: %s", err)
+	}
+
+	vf.files[f] = fh
+	return fh, nil
+}
+
+// Close ...
+func (vf *VirtualFile) Close() (err error) {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+
+	for f, fh := range vf.files {
+		if e := fh.Close(); e != nil {
+			err = e
+		}
+		delete(vf.files, f)
+	}
+	return
+}
+
+// Read reads data from the segment at current position, so it can return less than requested
+func (vf *VirtualFile) Read(b []byte) (n int, err error) {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+
+	if vf.pos >= vf.f.Size {
+		return 0, io.EOF
+	}
+
+	pos := vf.pos
+	for _, s := range vf.f.Segments {
+		if pos >= s.Size {
+			pos -= s.Size
+			continue
+		}
+
+		fh, err := vf.open(s.File)
+		if err != nil {
+			return 0, err
+		}
+
+		if left := s.Size - pos; int64(len(b)) > left {
+			b = b[:left]
+		}
+		n, err = fh.ReadAt(b, s.Offset+pos)
+		vf.pos += int64(n)
+		if err == io.EOF && n == len(b) {
+			err = nil
+		}
+		return n, err
+	}
+
+	return 0, io.EOF
+}
+
+// Seek ...
+func (vf *VirtualFile) Seek(off int64, whence int) (ret int64, err error) {
+	vf.mu.Lock()
+	defer vf.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+		vf.pos = off
+	case io.SeekCurrent:
+		vf.pos += off
+	case io.SeekEnd:
+		vf.pos = vf.f.Size + off
+	default:
+		err = errors.New("bad whence")
+	}
+	ret = vf.pos
+
+	return
+}
+
+// Readdir ...
+func (vf *VirtualFile) Readdir(count int) (ret []os.FileInfo, err error) {
+	return
+}
+
+// Stat ...
+func (vf *VirtualFile) Stat() (ret os.FileInfo, err error) {
+	return vf, nil
+}
+
+// Name ...
+func (vf *VirtualFile) Name() string {
+	return vf.f.Name
+}
+
+// Size ...
+func (vf *VirtualFile) Size() int64 {
+	return vf.f.Size
+}
+
+// Mode ...
+func (vf *VirtualFile) Mode() os.FileMode {
+	return 0644
+}
+
+// ModTime ...
+func (vf *VirtualFile) ModTime() time.Time {
+	return time.Now()
+}
+
+// IsDir ...
+func (vf *VirtualFile) IsDir() bool {
+	return false
+}
+
+// Sys ...
+func (vf *VirtualFile) Sys() interface{} {
+	return nil
+}
@@ -13,6 +13,7 @@ const (
 	ScraperKey = "scraper."
 	LibraryKey = "library."
 	FanartKey  = "fanart."
+	AniListKey = "com.anilist."
 
 	TMDBEpisodeKey                 = TMDBKey + "episode.%d.%d.%d.%s"
 	TMDBEpisodeExpire              = GeneralExpire
@@ -52,11 +53,21 @@ const (
 	TMDBShowsTopShowsTotalExpire   = 24 * time.Hour
 	TMDBEpisodeImagesKey           = TMDBKey + "show.%d.%d.%d.images"
 	TMDBEpisodeImagesExpire        = GeneralExpire
+	TMDBListKey                    = TMDBKey + "list.%s.%s"
+	TMDBListExpire                 = 1 * time.Hour
+	TMDBCollectionKey              = TMDBKey + "collection.%d.%s"
+	TMDBCollectionExpire           = 24 * time.Hour
+	TMDBPersonKey                  = TMDBKey + "person.%d.%s"
+	TMDBPersonExpire               = GeneralExpire
+	TMDBPersonCreditsKey           = TMDBKey + "person.%d.credits.%s"
+	TMDBPersonCreditsExpire        = 24 * time.Hour
 
 	TraktActivitiesKey                     = TraktKey + "last_activities"
 	TraktActivitiesExpire                  = 30 * 24 * time.Hour
-	TraktPausedLastUpdatesKey              = TraktKey + "PausedLastUpdates.%d"
-	TraktPausedLastUpdatesExpire           = 30 * 24 * time.Hour
+	TraktPlaybackLastSyncKey               = TraktKey + "playback.last_sync.%d"
+	TraktPlaybackLastSyncExpire            = 30 * 24 * time.Hour
+	TraktPlaybackConflictsKey              = TraktKey + "playback.conflicts"
+	TraktPlaybackConflictsExpire           = 30 * 24 * time.Hour
 	TraktMovieKey                          = TraktKey + "movie.%s"
 	TraktMovieExpire                       = GeneralExpire
 	TraktMovieByTMDBKey                    = TraktKey + "movie.tmdb.%s"
@@ -138,6 +149,11 @@ const (
 	FanartShowByIDKey     = FanartKey + "show.%d"
 	FanartShowByIDExpire  = GeneralExpire
 
+	AniListTMDBKey           = AniListKey + "tmdb.%d"
+	AniListTMDBExpire        = 30 * 24 * time.Hour
+	AniListMediaByTMDBKey    = AniListKey + "media.%d.%d"
+	AniListMediaByTMDBExpire = 30 * 24 * time.Hour
+
 	LibraryWatchedPlaycountKey    = LibraryKey + "WatchedLastPlaycount.%s"
 	LibraryWatchedPlaycountExpire = 30 * 24 * time.Hour
 	LibraryShowsLastUpdatesKey    = LibraryKey + "showsLastUpdates"
@@ -146,6 +162,12 @@ const (
 	LibraryResolveFileExpire      = 60 * 24 * time.Hour
 	LibrarySyncPlaycountKey       = LibraryKey + "SyncLastPlaycount.%s"
 	LibrarySyncPlaycountExpire    = 30 * 24 * time.Hour
+	LibrarySyncPlanKey            = LibraryKey + "SyncPlan"
+	LibrarySyncPlanExpire         = 30 * 24 * time.Hour
+	LibrarySyncJournalKey         = LibraryKey + "SyncJournal"
+	LibrarySyncJournalExpire      = 30 * 24 * time.Hour
+	LibraryCollectionSetsKey      = LibraryKey + "CollectionSets"
+	LibraryCollectionSetsExpire   = 10 * 365 * 24 * time.Hour
 
 	ScraperLastExecutionKey    = ScraperKey + "last.execution"
 	ScraperLastExecutionExpire = 60 * 60 * 24 * 30
@@ -101,6 +101,7 @@ type Configuration struct {
 	LibraryEnabled              bool
 	LibrarySyncEnabled          bool
 	LibrarySyncPlaybackEnabled  bool
+	LibrarySyncDryRun           bool
 	LibraryUpdate               int
 	StrmLanguage                string
 	LibraryNFOMovies            bool
@@ -198,6 +199,7 @@ type Configuration struct {
 	TraktSyncRemovedShowsBack      bool
 	TraktSyncRemovedShowsLocation  int
 	TraktSyncRemovedShowsList      int
+	TraktRateAfterWatching         bool
 	TraktProgressUnaired           bool
 	TraktProgressSort              int
 	TraktProgressDateFormat        string
@@ -211,6 +213,16 @@ type Configuration struct {
 	TraktCalendarsColorEpisode     string
 	TraktCalendarsColorUnaired     string
 
+	SimklClientID         string
+	SimklToken            string
+	SimklUsername         string
+	SimklSyncEnabled      bool
+	AniListClientID       string
+	AniListToken          string
+	AniListUsername       string
+	AniListSyncEnabled    bool
+	SyncBackendsWatchlist bool
+
 	UpdateFrequency                int
 	UpdateDelay                    int
 	UpdateAutoScan                 bool
@@ -258,9 +270,16 @@ type Configuration struct {
 	ProxyUseTracker  bool
 	ProxyUseDownload bool
 
-	CompletedMove       bool
-	CompletedMoviesPath string
-	CompletedShowsPath  string
+	MaxActiveDownloads    int
+	MaxActiveSeeds        int
+	QueuePauseOnBuffering bool
+
+	CompletedMove           bool
+	CompletedMoveMode       int
+	CompletedMoviesPath     string
+	CompletedShowsPath      string
+	CompletedMoviesTemplate string
+	CompletedShowsTemplate  string
 
 	LocalOnlyClient bool
 	LogLevel        int
@@ -591,6 +610,7 @@ func Reload() (ret *Configuration, err error) {
 		LibraryEnabled:              settings.ToBool("library_enabled"),
 		LibrarySyncEnabled:          settings.ToBool("library_sync_enabled"),
 		LibrarySyncPlaybackEnabled:  settings.ToBool("library_sync_playback_enabled"),
+		LibrarySyncDryRun:           settings.ToBool("library_sync_dry_run"),
 		LibraryUpdate:               settings.ToInt("library_update"),
 		StrmLanguage:                settings.ToString("strm_language"),
 		LibraryNFOMovies:            settings.ToBool("library_nfo_movies"),
@@ -665,6 +685,7 @@ func Reload() (ret *Configuration, err error) {
 		TraktSyncRemovedShowsBack:      settings.ToBool("trakt_sync_removed_shows_back"),
 		TraktSyncRemovedShowsLocation:  settings.ToInt("trakt_sync_removed_shows_location"),
 		TraktSyncRemovedShowsList:      settings.ToInt("trakt_sync_removed_shows_list"),
+		TraktRateAfterWatching:         settings.ToBool("trakt_rate_after_watching"),
 		TraktProgressUnaired:           settings.ToBool("trakt_progress_unaired"),
 		TraktProgressSort:              settings.ToInt("trakt_progress_sort"),
 		TraktProgressDateFormat:        settings.ToString("trakt_progress_date_format"),
@@ -678,6 +699,16 @@ func Reload() (ret *Configuration, err error) {
 		TraktCalendarsColorEpisode:     settings.ToString("trakt_calendars_color_episode"),
 		TraktCalendarsColorUnaired:     settings.ToString("trakt_calendars_color_unaired"),
 
+		SimklClientID:         settings.ToString("simkl_client_id"),
+		SimklToken:            settings.ToString("simkl_token"),
+		SimklUsername:         settings.ToString("simkl_username"),
+		SimklSyncEnabled:      settings.ToBool("simkl_sync_enabled"),
+		AniListClientID:       settings.ToString("anilist_client_id"),
+		AniListToken:          settings.ToString("anilist_token"),
+		AniListUsername:       settings.ToString("anilist_username"),
+		AniListSyncEnabled:    settings.ToBool("anilist_sync_enabled"),
+		SyncBackendsWatchlist: settings.ToBool("sync_backends_watchlist"),
+
 		UpdateFrequency:                settings.ToInt("library_update_frequency"),
 		UpdateDelay:                    settings.ToInt("library_update_delay"),
 		UpdateAutoScan:                 settings.ToBool("library_auto_scan"),
@@ -723,9 +754,16 @@ func Reload() (ret *Configuration, err error) {
 		ProxyUseTracker:  settings.ToBool("use_proxy_tracker"),
 		ProxyUseDownload: settings.ToBool("use_proxy_download"),
 
-		CompletedMove:       settings.ToBool("completed_move"),
-		CompletedMoviesPath: settings.ToString("completed_movies_path"),
-		CompletedShowsPath:  settings.ToString("completed_shows_path"),
+		MaxActiveDownloads:    settings.ToInt("max_active_downloads"),
+		MaxActiveSeeds:        settings.ToInt("max_active_seeds"),
+		QueuePauseOnBuffering: settings.ToBool("queue_pause_on_buffering"),
+
+		CompletedMove:           settings.ToBool("completed_move"),
+		CompletedMoveMode:       settings.ToInt("completed_move_mode"),
+		CompletedMoviesPath:     settings.ToString("completed_movies_path"),
+		CompletedShowsPath:      settings.ToString("completed_shows_path"),
+		CompletedMoviesTemplate: settings.ToString("completed_movies_template"),
+		CompletedShowsTemplate:  settings.ToString("completed_shows_template"),
 
 		LocalOnlyClient: settings.ToBool("local_only_client"),
 		LogLevel:        settings.ToInt("log_level"),
@@ -24,6 +24,15 @@ const (
 	StorageMemory
 )
 
+const (
+	// CompletedMove moves files to the library and removes the torrent after seeding
+	CompletedMove int = iota
+	// CompletedCopy copies files to the library, torrent keeps seeding
+	CompletedCopy
+	// CompletedHardlink links files into the library, torrent keeps seeding without using extra space
+	CompletedHardlink
+)
+
 var (
 	// Storages ...
 	Storages = []string{
@@ -283,6 +283,33 @@ func (d *StormDatabase) UpdateTorrentMetadata(infoHash string, b []byte) {
 	}
 }
 
+// GetResolvedMetadata returns torrent file, fetched by magnet resolver
+func (d *StormDatabase) GetResolvedMetadata(infoHash string) []byte {
+	defer perf.ScopeTimer()()
+
+	var rm ResolvedMetadata
+	if err := d.db.One("InfoHash", infoHash, &rm); err != nil {
+		return nil
+	}
+	return rm.Metadata
+}
+
+// AddResolvedMetadata saves torrent file, fetched by magnet resolver, and removes the oldest ones above the limit
+func (d *StormDatabase) AddResolvedMetadata(infoHash string, b []byte, limit int) {
+	defer perf.ScopeTimer()()
+
+	if err := d.db.Save(&ResolvedMetadata{InfoHash: infoHash, Dt: time.Now(), Metadata: b}); err != nil {
+		log.Warningf("Error saving resolved metadata: %s", err)
+		return
+	}
+
+	var rms []ResolvedMetadata
+	d.db.AllByIndex("Dt", &rms, storm.Reverse(), storm.Skip(limit))
+	for _, rm := range rms {
+		d.db.DeleteStruct(&rm)
+	}
+}
+
 // Bittorrent Database handlers
 
 // GetBTItem ...
@@ -331,6 +358,13 @@ func (d *StormDatabase) UpdateBTItem(infoHash string, mediaID int, mediaType str
 
 	var oldItem BTItem
 	if err := d.db.One("InfoHash", infoHash, &oldItem); err == nil {
+		item.QueuePosition = oldItem.QueuePosition
+		item.Priority = oldItem.Priority
+		item.Queued = oldItem.Queued
+		item.Category = oldItem.Category
+		item.Tags = oldItem.Tags
+		item.Trackers = oldItem.Trackers
+		item.Organized = oldItem.Organized
 		d.db.DeleteStruct(&oldItem)
 	}
 	if err := d.db.Save(&item); err != nil {
@@ -353,6 +387,236 @@ func (d *StormDatabase) UpdateBTItemFiles(infoHash string, files []string) error
 	return d.db.Update(&item)
 }
 
+// UpdateBTItemQueue saves position and priority of the torrent in the download queue
+func (d *StormDatabase) UpdateBTItemQueue(infoHash string, position, priority int) error {
+	defer perf.ScopeTimer()()
+
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	// UpdateField is used, as Update skips zero values
+	if err := d.db.UpdateField(&item, "QueuePosition", position); err != nil {
+		return err
+	}
+	return d.db.UpdateField(&item, "Priority", priority)
+}
+
+// UpdateBTItemQueued saves whether the torrent is paused by the download queue
+func (d *StormDatabase) UpdateBTItemQueued(infoHash string, queued bool) error {
+	defer perf.ScopeTimer()()
+
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	return d.db.UpdateField(&item, "Queued", queued)
+}
+
+// UpdateBTItemTags saves category and tags of the torrent
+func (d *StormDatabase) UpdateBTItemTags(infoHash, category string, tags []string) error {
+	defer perf.ScopeTimer()()
+
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	// UpdateField is used, as Update skips zero values
+	if err := d.db.UpdateField(&item, "Category", category); err != nil {
+		return err
+	}
+	return d.db.UpdateField(&item, "Tags", tags)
+}
+
+// UpdateBTItemTrackers saves trackers list, edited by the user
+func (d *StormDatabase) UpdateBTItemTrackers(infoHash string, trackers []string) error {
+	defer perf.ScopeTimer()()
+
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	return d.db.UpdateField(&item, "Trackers", trackers)
+}
+
+// UpdateBTItemOrganized marks torrent files as placed to the library
+func (d *StormDatabase) UpdateBTItemOrganized(infoHash string, organized bool) error {
+	defer perf.ScopeTimer()()
+
+	item := BTItem{}
+	if err := d.db.One("InfoHash", infoHash, &item); err != nil {
+		return err
+	}
+
+	return d.db.UpdateField(&item, "Organized", organized)
+}
+
+// GetTorrentCategories returns all torrent categories
+func (d *StormDatabase) GetTorrentCategories() []TorrentCategory {
+	defer perf.ScopeTimer()()
+
+	ret := []TorrentCategory{}
+	if err := d.db.All(&ret); err != nil {
+		log.Debugf("Could not get torrent categories: %s", err)
+	}
+	return ret
+}
+
+// GetTorrentCategory returns category by name
+func (d *StormDatabase) GetTorrentCategory(name string) *TorrentCategory {
+	defer perf.ScopeTimer()()
+
+	if name == "" {
+		return nil
+	}
+
+	c := &TorrentCategory{}
+	if err := d.db.One("Name", name, c); err != nil {
+		return nil
+	}
+	return c
+}
+
+// SaveTorrentCategory creates or updates torrent category
+func (d *StormDatabase) SaveTorrentCategory(c *TorrentCategory) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Save(c)
+}
+
+// DeleteTorrentCategory removes torrent category
+func (d *StormDatabase) DeleteTorrentCategory(name string) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Delete(TorrentCategoryBucket, name)
+}
+
+// GetTraktOutboxItems returns queued Trakt requests, oldest first
+func (d *StormDatabase) GetTraktOutboxItems() []TraktOutboxItem {
+	defer perf.ScopeTimer()()
+
+	ret := []TraktOutboxItem{}
+	if err := d.db.AllByIndex("CreatedAt", &ret); err != nil {
+		log.Debugf("Could not get Trakt outbox items: %s", err)
+	}
+	return ret
+}
+
+// SaveTraktOutboxItem adds Trakt request to the outbox or updates its state
+func (d *StormDatabase) SaveTraktOutboxItem(item *TraktOutboxItem) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Save(item)
+}
+
+// DeleteTraktOutboxItem removes sent or dropped Trakt request
+func (d *StormDatabase) DeleteTraktOutboxItem(id int) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Delete(TraktOutboxItemBucket, id)
+}
+
+// GetAnimeOverride returns episode numbering override of the anime show
+func (d *StormDatabase) GetAnimeOverride(showID int) *AnimeOverride {
+	defer perf.ScopeTimer()()
+
+	o := &AnimeOverride{}
+	if err := d.db.One("ShowID", showID, o); err != nil {
+		return nil
+	}
+	return o
+}
+
+// SaveAnimeOverride creates or updates episode numbering override
+func (d *StormDatabase) SaveAnimeOverride(o *AnimeOverride) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Save(o)
+}
+
+// DeleteAnimeOverride removes episode numbering override
+func (d *StormDatabase) DeleteAnimeOverride(showID int) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Delete(AnimeOverrideBucket, showID)
+}
+
+// GetListSubscriptions returns all lists, attached to the library
+func (d *StormDatabase) GetListSubscriptions() []ListSubscription {
+	defer perf.ScopeTimer()()
+
+	ret := []ListSubscription{}
+	if err := d.db.All(&ret); err != nil {
+		log.Debugf("Could not get list subscriptions: %s", err)
+	}
+	return ret
+}
+
+// GetListSubscription returns list subscription by its ID
+func (d *StormDatabase) GetListSubscription(id string) *ListSubscription {
+	defer perf.ScopeTimer()()
+
+	s := &ListSubscription{}
+	if err := d.db.One("ID", id, s); err != nil {
+		return nil
+	}
+	return s
+}
+
+// SaveListSubscription creates or updates list subscription
+func (d *StormDatabase) SaveListSubscription(s *ListSubscription) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Save(s)
+}
+
+// DeleteListSubscription removes list subscription
+func (d *StormDatabase) DeleteListSubscription(id string) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Delete(ListSubscriptionBucket, id)
+}
+
+// GetFavoritePeople returns all favorite people
+func (d *StormDatabase) GetFavoritePeople() []FavoritePerson {
+	defer perf.ScopeTimer()()
+
+	ret := []FavoritePerson{}
+	if err := d.db.All(&ret); err != nil {
+		log.Debugf("Could not get favorite people: %s", err)
+	}
+	return ret
+}
+
+// GetFavoritePerson returns favorite person by TMDB ID
+func (d *StormDatabase) GetFavoritePerson(id int) *FavoritePerson {
+	defer perf.ScopeTimer()()
+
+	p := &FavoritePerson{}
+	if err := d.db.One("ID", id, p); err != nil {
+		return nil
+	}
+	return p
+}
+
+// SaveFavoritePerson adds person to favorites
+func (d *StormDatabase) SaveFavoritePerson(p *FavoritePerson) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Save(p)
+}
+
+// DeleteFavoritePerson removes person from favorites
+func (d *StormDatabase) DeleteFavoritePerson(id int) error {
+	defer perf.ScopeTimer()()
+
+	return d.db.Delete(FavoritePersonBucket, id)
+}
+
 // DeleteBTItem ...
 func (d *StormDatabase) DeleteBTItem(infoHash string) error {
 	defer perf.ScopeTimer()()
@@ -65,6 +65,80 @@ type BTItem struct {
 	Season   int      `json:"season"`
 	Episode  int      `json:"episode"`
 	Query    string   `json:"query"`
+
+	QueuePosition int  `json:"queue_position"`
+	Priority      int  `json:"priority"`
+	Queued        bool `json:"queued"`
+
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+
+	// Trackers, edited by the user, nil if trackers were not edited
+	Trackers []string `json:"trackers"`
+
+	// Organized is set, when files were copied or hardlinked to the library
+	Organized bool `json:"organized"`
+}
+
+// TorrentCategory groups torrents and defines where their files are stored
+type TorrentCategory struct {
+	Name          string `json:"name" storm:"id"`
+	DownloadPath  string `json:"download_path"`
+	CompletedPath string `json:"completed_path"`
+}
+
+// TraktOutboxItem is a Trakt write request, waiting to be sent
+type TraktOutboxItem struct {
+	ID          int       `json:"id" storm:"id,increment"`
+	Kind        string    `json:"kind"`
+	EndPoint    string    `json:"end_point"`
+	Payload     []byte    `json:"payload"`
+	CreatedAt   time.Time `json:"created_at" storm:"index"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error"`
+	Failed      bool      `json:"failed"`
+}
+
+// AnimeOverride is a user defined episode numbering of the anime show
+type AnimeOverride struct {
+	ShowID int `json:"show_id" storm:"id"`
+	// Offset is added to absolute numbers, for releases that continue numbering of the previous show
+	Offset int `json:"offset"`
+	// SeasonStarts are absolute numbers of first episodes of seasons
+	SeasonStarts map[int]int `json:"season_starts"`
+}
+
+// ListSubscription is a Trakt or TMDB list, attached to the library as a source of items
+type ListSubscription struct {
+	ID     string `json:"id" storm:"id"`
+	Source string `json:"source"`
+	User   string `json:"user"`
+	ListID string `json:"list_id"`
+	Name   string `json:"name"`
+	// AsSet writes the list into NFO files as a movie set, instead of a tag
+	AsSet bool `json:"as_set"`
+	// RemovalPolicy is applied to items, that leave the list
+	RemovalPolicy string `json:"removal_policy"`
+	// Movies and Shows are TMDB IDs of items, found in the list on the last sync
+	Movies []int `json:"movies"`
+	Shows  []int `json:"shows"`
+	// AddedMovies and AddedShows are TMDB IDs of items, that were added to the library by this list,
+	// only they are removed by the removal policy, items added by hand stay in the library.
+	AddedMovies []int     `json:"added_movies"`
+	AddedShows  []int     `json:"added_shows"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSync    time.Time `json:"last_sync"`
+	LastError   string    `json:"last_error"`
+}
+
+// FavoritePerson is an actor or a crew member, whose new releases are followed
+type FavoritePerson struct {
+	ID          int       `json:"id" storm:"id"`
+	Name        string    `json:"name"`
+	Department  string    `json:"department"`
+	ProfilePath string    `json:"profile_path"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // LibraryItem ...
@@ -89,6 +163,13 @@ type TorrentAssignMetadata struct {
 	Metadata []byte
 }
 
+// ResolvedMetadata is a torrent file, fetched by magnet resolver, only recent ones are kept
+type ResolvedMetadata struct {
+	InfoHash string    `storm:"id"`
+	Dt       time.Time `storm:"index"`
+	Metadata []byte
+}
+
 // TorrentAssignItem ...
 type TorrentAssignItem struct {
 	Pk       int    `storm:"id,increment"`
@@ -157,7 +238,24 @@ const (
 	TorrentAssignMetadataBucket = "TorrentAssignMetadata"
 	// TorrentAssignItemBucket ...
 	TorrentAssignItemBucket = "TorrentAssignItem"
+	// ResolvedMetadataBucket ...
+	ResolvedMetadataBucket = "ResolvedMetadata"
 
 	// QueryHistoryBucket ...
 	QueryHistoryBucket = "QueryHistory"
+
+	// TorrentCategoryBucket ...
+	TorrentCategoryBucket = "TorrentCategory"
+
+	// TraktOutboxItemBucket ...
+	TraktOutboxItemBucket = "TraktOutboxItem"
+
+	// AnimeOverrideBucket ...
+	AnimeOverrideBucket = "AnimeOverride"
+
+	// ListSubscriptionBucket ...
+	ListSubscriptionBucket = "ListSubscription"
+
+	// FavoritePersonBucket ...
+	FavoritePersonBucket = "FavoritePerson"
 )
@@ -0,0 +1,359 @@
+package library
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/elgatito/elementum/anilist"
+	"github.com/elgatito/elementum/cache"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/tmdb"
+)
+
+// TMDB genre of animation, the same for movies and shows
+const tmdbAnimationGenre = 16
+
+// anilistBackend syncs anime progress with AniList.
+// AniList has only the number of watched episodes per anime, and each anime season
+// is a separate media, so items are matched with TMDB shows by title and year.
+type anilistBackend struct{}
+
+// anilistMapping is a TMDB movie, or season of the TMDB show, matched with AniList media
+type anilistMapping struct {
+	TMDB   int  `json:"tmdb"`
+	Season int  `json:"season"`
+	Movie  bool `json:"movie"`
+}
+
+func (b *anilistBackend) Name() string {
+	return "AniList"
+}
+
+func (b *anilistBackend) Enabled() bool {
+	return config.Get().AniListSyncEnabled && anilist.Authorized() == nil
+}
+
+func (b *anilistBackend) Authorize() error {
+	return anilist.Authorize()
+}
+
+func (b *anilistBackend) Deauthorize() error {
+	return anilist.Deauthorize()
+}
+
+func (b *anilistBackend) Watched() ([]*SyncItem, error) {
+	entries, err := anilist.UserEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SyncItem{}
+	for _, e := range entries {
+		if e.Media == nil || e.Status == anilist.StatusPlanning {
+			continue
+		}
+
+		mapping := anilistToTMDB(e.Media)
+		if mapping == nil {
+			continue
+		}
+
+		watchedAt := time.Unix(e.UpdatedAt, 0).UTC()
+		if mapping.Movie {
+			if e.Status == anilist.StatusCompleted {
+				ret = append(ret, &SyncItem{
+					MediaType: SyncMovie,
+					TMDB:      mapping.TMDB,
+					AniList:   e.Media.ID,
+					MAL:       e.Media.IDMal,
+					Title:     anilistTitle(e.Media),
+					Year:      e.Media.StartDate.Year,
+					Watched:   true,
+					WatchedAt: watchedAt,
+				})
+			}
+			continue
+		}
+
+		for episode := 1; episode <= e.Progress; episode++ {
+			ret = append(ret, &SyncItem{
+				MediaType: SyncEpisode,
+				TMDB:      mapping.TMDB,
+				AniList:   e.Media.ID,
+				MAL:       e.Media.IDMal,
+				Title:     anilistTitle(e.Media),
+				Year:      e.Media.StartDate.Year,
+				Season:    mapping.Season,
+				Episode:   episode,
+				Watched:   true,
+				WatchedAt: watchedAt,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// Paused returns nothing, AniList does not keep playback progress
+func (b *anilistBackend) Paused() ([]*SyncItem, error) {
+	return []*SyncItem{}, nil
+}
+
+func (b *anilistBackend) Watchlist() ([]*SyncItem, error) {
+	entries, err := anilist.UserEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SyncItem{}
+	for _, e := range entries {
+		if e.Media == nil || e.Status != anilist.StatusPlanning {
+			continue
+		}
+
+		mapping := anilistToTMDB(e.Media)
+		if mapping == nil {
+			continue
+		}
+
+		item := &SyncItem{
+			MediaType: SyncShow,
+			TMDB:      mapping.TMDB,
+			AniList:   e.Media.ID,
+			MAL:       e.Media.IDMal,
+			Title:     anilistTitle(e.Media),
+			Year:      e.Media.StartDate.Year,
+		}
+		if mapping.Movie {
+			item.MediaType = SyncMovie
+		}
+		ret = append(ret, item)
+	}
+
+	return ret, nil
+}
+
+// SetWatched only moves progress forward, AniList can't unmark single episodes
+func (b *anilistBackend) SetWatched(items []*SyncItem) error {
+	for _, item := range items {
+		if !item.Watched || (item.MediaType != SyncMovie && item.MediaType != SyncEpisode) {
+			continue
+		}
+
+		if err := anilistSaveItem(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *anilistBackend) Scrobble(action string, item *SyncItem, watched, runtime float64) error {
+	if action != "stop" || runtime < 1 || watched/runtime*100 < float64(config.Get().PlaybackPercent) {
+		return nil
+	}
+
+	return anilistSaveItem(item)
+}
+
+func anilistTitle(m *anilist.Media) string {
+	if m.Title.English != "" {
+		return m.Title.English
+	}
+	return m.Title.Romaji
+}
+
+// anilistSaveItem sets progress of the anime to the episode number of the item.
+// Progress only moves forward, and completed anime are not touched.
+func anilistSaveItem(item *SyncItem) error {
+	mediaID := anilistFromTMDB(item)
+	if mediaID == 0 {
+		log.Debugf("Could not find AniList media for %s %d", item.MediaType, item.TMDB)
+		return nil
+	}
+
+	entry, err := anilist.GetEntry(mediaID)
+	if err != nil {
+		return err
+	} else if entry != nil && entry.Status == anilist.StatusCompleted {
+		return nil
+	}
+
+	if item.MediaType == SyncMovie {
+		return anilist.SaveProgress(mediaID, 1, anilist.StatusCompleted)
+	}
+	if entry != nil && entry.Progress >= item.Episode {
+		return nil
+	}
+
+	status := anilist.StatusCurrent
+	if entry != nil && entry.Status == anilist.StatusRepeating {
+		status = anilist.StatusRepeating
+	} else if entry != nil && entry.Media != nil && entry.Media.Episodes > 0 && item.Episode >= entry.Media.Episodes {
+		status = anilist.StatusCompleted
+	}
+	return anilist.SaveProgress(mediaID, item.Episode, status)
+}
+
+// anilistToTMDB finds TMDB movie, or show and season, for AniList media
+func anilistToTMDB(m *anilist.Media) *anilistMapping {
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.AniListTMDBKey, m.ID)
+
+	mapping := &anilistMapping{}
+	if err := cacheStore.Get(key, mapping); err == nil {
+		if mapping.TMDB == 0 {
+			return nil
+		}
+		return mapping
+	}
+
+	mapping = &anilistMapping{}
+	for _, title := range []string{m.Title.English, m.Title.Romaji} {
+		if title == "" {
+			continue
+		}
+
+		if m.Format == anilist.FormatMovie {
+			movies, _ := tmdb.SearchMovies(title, config.Get().Language, 1)
+			entities := make([]*tmdb.Entity, 0, len(movies))
+			for _, movie := range movies {
+				if movie != nil {
+					entities = append(entities, &movie.Entity)
+				}
+			}
+			if e := anilistMatchTMDB(entities, m.StartDate.Year, false); e != nil {
+				mapping.TMDB = e.ID
+				mapping.Movie = true
+				break
+			}
+			continue
+		}
+
+		shows, _ := tmdb.SearchShows(title, config.Get().Language, 1)
+		entities := make([]*tmdb.Entity, 0, len(shows))
+		for _, show := range shows {
+			if show != nil {
+				entities = append(entities, &show.Entity)
+			}
+		}
+		if e := anilistMatchTMDB(entities, m.StartDate.Year, true); e != nil {
+			mapping.TMDB = e.ID
+			mapping.Season = anilistSeason(mapping.TMDB, m.StartDate.Year)
+			break
+		}
+	}
+
+	// Not found items are cached as well, to avoid searching them on each sync
+	cacheStore.Set(key, mapping, cache.AniListTMDBExpire)
+	if mapping.TMDB == 0 {
+		return nil
+	}
+	return mapping
+}
+
+// anilistMatchTMDB picks TMDB search result for AniList media: it should be an animation,
+// released in the year anime has started. Show can start earlier, as each anime season is a separate media.
+// Results with exactly the same year go first.
+func anilistMatchTMDB(entities []*tmdb.Entity, year int, isShow bool) *tmdb.Entity {
+	var fallback *tmdb.Entity
+	for _, e := range entities {
+		if !anilistIsAnimation(e) {
+			continue
+		}
+
+		date := e.ReleaseDate
+		if isShow {
+			date = e.FirstAirDate
+		}
+		released := 0
+		if len(date) >= 4 {
+			released, _ = strconv.Atoi(date[:4])
+		}
+
+		switch {
+		case year == 0 || released == year:
+			return e
+		case fallback != nil || released == 0:
+			continue
+		case isShow && released < year:
+			fallback = e
+		case !isShow && (released == year-1 || released == year+1):
+			fallback = e
+		}
+	}
+	return fallback
+}
+
+func anilistIsAnimation(e *tmdb.Entity) bool {
+	for _, g := range e.Genres {
+		if g != nil && g.ID == tmdbAnimationGenre {
+			return true
+		}
+	}
+	return false
+}
+
+// anilistSeason guesses season of the show by the year anime has started
+func anilistSeason(showID, year int) int {
+	show := tmdb.GetShowByID(strconv.Itoa(showID), config.Get().Language)
+	if show == nil || year == 0 {
+		return 1
+	}
+
+	for _, s := range show.Seasons {
+		if s.Season > 0 && len(s.AirDate) >= 4 && s.AirDate[:4] == strconv.Itoa(year) {
+			return s.Season
+		}
+	}
+	return 1
+}
+
+// anilistFromTMDB finds AniList media for TMDB movie, or season of the TMDB show
+func anilistFromTMDB(item *SyncItem) int {
+	if item.AniList != 0 {
+		return item.AniList
+	}
+	if item.TMDB == 0 {
+		return 0
+	}
+
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.AniListMediaByTMDBKey, item.TMDB, item.Season)
+
+	mediaID := 0
+	if err := cacheStore.Get(key, &mediaID); err == nil {
+		return mediaID
+	}
+
+	title := ""
+	year := 0
+	if item.MediaType == SyncMovie {
+		if movie := tmdb.GetMovieByID(strconv.Itoa(item.TMDB), config.Get().Language); movie != nil {
+			title = movie.OriginalTitle
+			if len(movie.ReleaseDate) >= 4 {
+				year, _ = strconv.Atoi(movie.ReleaseDate[:4])
+			}
+		}
+	} else if show := tmdb.GetShowByID(strconv.Itoa(item.TMDB), config.Get().Language); show != nil {
+		title = show.OriginalName
+		for _, s := range show.Seasons {
+			if s.Season == item.Season && len(s.AirDate) >= 4 {
+				year, _ = strconv.Atoi(s.AirDate[:4])
+			}
+		}
+	}
+	if title == "" {
+		return 0
+	}
+
+	if media, err := anilist.SearchAnime(title, year); err != nil {
+		log.Debugf("Could not search AniList for %s: %s", title, err)
+		return 0
+	} else if len(media) > 0 {
+		mediaID = media[0].ID
+	}
+
+	cacheStore.Set(key, mediaID, cache.AniListMediaByTMDBExpire)
+	return mediaID
+}
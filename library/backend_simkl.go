@@ -0,0 +1,206 @@
+package library
+
+import (
+	"time"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/simkl"
+)
+
+// simklBackend syncs watch state with Simkl, anime is synced as shows
+type simklBackend struct{}
+
+func (b *simklBackend) Name() string {
+	return "Simkl"
+}
+
+func (b *simklBackend) Enabled() bool {
+	return config.Get().SimklSyncEnabled && simkl.Authorized() == nil
+}
+
+func (b *simklBackend) Authorize() error {
+	return simkl.Authorize()
+}
+
+func (b *simklBackend) Deauthorize() error {
+	return simkl.Deauthorize()
+}
+
+func simklSyncItem(mediaType string, o *simkl.Object) *SyncItem {
+	item := &SyncItem{
+		MediaType: mediaType,
+		Title:     o.Title,
+		Year:      o.Year,
+	}
+	if o.IDs != nil {
+		item.TMDB = int(o.IDs.TMDB)
+		item.IMDB = o.IDs.IMDB
+		item.TVDB = int(o.IDs.TVDB)
+		item.AniList = int(o.IDs.AniList)
+		item.MAL = int(o.IDs.MAL)
+	}
+	return item
+}
+
+func simklObject(item *SyncItem) *simkl.Object {
+	return &simkl.Object{
+		IDs: &simkl.IDs{
+			TMDB: simkl.ID(item.TMDB),
+			IMDB: item.IMDB,
+			TVDB: simkl.ID(item.TVDB),
+			MAL:  simkl.ID(item.MAL),
+		},
+	}
+}
+
+func (b *simklBackend) Watched() ([]*SyncItem, error) {
+	items, err := simkl.AllUserItems()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SyncItem{}
+	for _, m := range items.Movies {
+		if m.Movie == nil || m.Status != "completed" {
+			continue
+		}
+
+		item := simklSyncItem(SyncMovie, m.Movie)
+		item.Watched = true
+		item.WatchedAt = m.LastWatchedAt
+		ret = append(ret, item)
+	}
+
+	for _, s := range append(items.Shows, items.Anime...) {
+		if s.Show == nil {
+			continue
+		}
+
+		for _, season := range s.Seasons {
+			for _, episode := range season.Episodes {
+				item := simklSyncItem(SyncEpisode, s.Show)
+				item.Season = season.Number
+				item.Episode = episode.Number
+				item.Watched = true
+				if episode.WatchedAt != nil {
+					item.WatchedAt = *episode.WatchedAt
+				}
+				ret = append(ret, item)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func (b *simklBackend) Paused() ([]*SyncItem, error) {
+	paused, err := simkl.PausedItems()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SyncItem{}
+	for _, p := range paused {
+		var item *SyncItem
+		if p.Movie != nil {
+			item = simklSyncItem(SyncMovie, p.Movie)
+		} else if p.Show != nil && p.Episode != nil {
+			item = simklSyncItem(SyncEpisode, p.Show)
+			item.Season = p.Episode.Season
+			item.Episode = p.Episode.Number
+		} else {
+			continue
+		}
+
+		item.Progress = p.Progress
+		item.PausedAt = p.PausedAt
+		ret = append(ret, item)
+	}
+
+	return ret, nil
+}
+
+func (b *simklBackend) Watchlist() ([]*SyncItem, error) {
+	items, err := simkl.AllUserItems()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SyncItem{}
+	for _, m := range items.Movies {
+		if m.Movie != nil && m.Status == "plantowatch" {
+			ret = append(ret, simklSyncItem(SyncMovie, m.Movie))
+		}
+	}
+	for _, s := range append(items.Shows, items.Anime...) {
+		if s.Show != nil && s.Status == "plantowatch" {
+			ret = append(ret, simklSyncItem(SyncShow, s.Show))
+		}
+	}
+
+	return ret, nil
+}
+
+func (b *simklBackend) SetWatched(items []*SyncItem) error {
+	watched := &simkl.History{}
+	unwatched := &simkl.History{}
+
+	for _, item := range items {
+		if item.TMDB == 0 {
+			continue
+		}
+
+		history := watched
+		if !item.Watched {
+			history = unwatched
+		}
+
+		entry := &simkl.HistoryItem{Object: *simklObject(item)}
+		if item.Watched {
+			watchedAt := item.WatchedAt
+			if watchedAt.IsZero() {
+				watchedAt = time.Now().UTC()
+			}
+			entry.WatchedAt = &watchedAt
+		}
+
+		switch item.MediaType {
+		case SyncMovie:
+			history.Movies = append(history.Movies, entry)
+		case SyncShow:
+			history.Shows = append(history.Shows, entry)
+		case SyncSeason:
+			entry.Seasons = []*simkl.Season{{Number: item.Season}}
+			history.Shows = append(history.Shows, entry)
+		case SyncEpisode:
+			entry.Seasons = []*simkl.Season{{Number: item.Season, Episodes: []*simkl.Episode{{Number: item.Episode}}}}
+			history.Shows = append(history.Shows, entry)
+		}
+	}
+
+	if len(watched.Movies) > 0 || len(watched.Shows) > 0 {
+		if err := simkl.AddToHistory(watched); err != nil {
+			return err
+		}
+	}
+	if len(unwatched.Movies) > 0 || len(unwatched.Shows) > 0 {
+		if err := simkl.RemoveFromHistory(unwatched); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *simklBackend) Scrobble(action string, item *SyncItem, watched, runtime float64) error {
+	if runtime < 1 || item.TMDB == 0 {
+		return nil
+	}
+
+	progress := watched / runtime * 100
+	if item.MediaType == SyncMovie {
+		return simkl.Scrobble(action, simklObject(item), nil, nil, progress)
+	} else if item.MediaType == SyncEpisode {
+		return simkl.Scrobble(action, nil, simklObject(item), &simkl.Episode{Season: item.Season, Number: item.Episode}, progress)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package library
+
+import (
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/trakt"
+)
+
+// traktBackend syncs watch state with Trakt, pulling is done by RefreshTrakt,
+// which uses last activities to fetch only changed lists.
+type traktBackend struct{}
+
+func (b *traktBackend) Name() string {
+	return "Trakt"
+}
+
+func (b *traktBackend) Enabled() bool {
+	return config.Get().TraktToken != ""
+}
+
+func (b *traktBackend) Authorize() error {
+	return trakt.Authorize(false)
+}
+
+func (b *traktBackend) Deauthorize() error {
+	return trakt.Deauthorize(false)
+}
+
+// Refresh schedules incremental Trakt sync
+func (b *traktBackend) Refresh() error {
+	PlanTraktUpdate()
+	return nil
+}
+
+func (b *traktBackend) Watched() ([]*SyncItem, error) {
+	ret := []*SyncItem{}
+
+	movies, err := trakt.WatchedMovies(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movies {
+		if m.Movie == nil || m.Movie.IDs == nil {
+			continue
+		}
+
+		ret = append(ret, &SyncItem{
+			MediaType: SyncMovie,
+			TMDB:      m.Movie.IDs.TMDB,
+			IMDB:      m.Movie.IDs.IMDB,
+			Title:     m.Movie.Title,
+			Year:      m.Movie.Year,
+			Watched:   true,
+			WatchedAt: m.LastWatchedAt,
+		})
+	}
+
+	shows, err := trakt.WatchedShows(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shows {
+		if s.Show == nil || s.Show.IDs == nil {
+			continue
+		}
+
+		for _, season := range s.Seasons {
+			for _, episode := range season.Episodes {
+				ret = append(ret, &SyncItem{
+					MediaType: SyncEpisode,
+					TMDB:      s.Show.IDs.TMDB,
+					IMDB:      s.Show.IDs.IMDB,
+					TVDB:      s.Show.IDs.TVDB,
+					Title:     s.Show.Title,
+					Year:      s.Show.Year,
+					Season:    season.Number,
+					Episode:   episode.Number,
+					Watched:   true,
+					WatchedAt: episode.LastWatchedAt,
+				})
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func (b *traktBackend) Paused() ([]*SyncItem, error) {
+	ret := []*SyncItem{}
+
+	movies, err := trakt.PausedMovies(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movies {
+		if m.Movie == nil || m.Movie.IDs == nil {
+			continue
+		}
+
+		ret = append(ret, &SyncItem{
+			MediaType: SyncMovie,
+			TMDB:      m.Movie.IDs.TMDB,
+			IMDB:      m.Movie.IDs.IMDB,
+			Title:     m.Movie.Title,
+			Year:      m.Movie.Year,
+			Progress:  m.Progress,
+			Runtime:   m.Movie.Runtime * 60,
+			PausedAt:  m.PausedAt,
+		})
+	}
+
+	shows, err := trakt.PausedShows(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shows {
+		if s.Show == nil || s.Show.IDs == nil || s.Episode == nil {
+			continue
+		}
+
+		item := &SyncItem{
+			MediaType: SyncEpisode,
+			TMDB:      s.Show.IDs.TMDB,
+			TVDB:      s.Show.IDs.TVDB,
+			Title:     s.Show.Title,
+			Year:      s.Show.Year,
+			Season:    s.Episode.Season,
+			Episode:   s.Episode.Number,
+			Progress:  s.Progress,
+			Runtime:   s.Episode.Runtime * 60,
+			PausedAt:  s.PausedAt,
+		}
+		if s.Episode.IDs != nil {
+			item.EpisodeTMDB = s.Episode.IDs.TMDB
+		}
+		ret = append(ret, item)
+	}
+
+	return ret, nil
+}
+
+func (b *traktBackend) Watchlist() ([]*SyncItem, error) {
+	ret := []*SyncItem{}
+
+	movies, err := trakt.WatchlistMovies(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movies {
+		if m.Movie == nil || m.Movie.IDs == nil {
+			continue
+		}
+
+		ret = append(ret, &SyncItem{
+			MediaType: SyncMovie,
+			TMDB:      m.Movie.IDs.TMDB,
+			IMDB:      m.Movie.IDs.IMDB,
+			Title:     m.Movie.Title,
+			Year:      m.Movie.Year,
+		})
+	}
+
+	shows, err := trakt.WatchlistShows(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shows {
+		if s.Show == nil || s.Show.IDs == nil {
+			continue
+		}
+
+		ret = append(ret, &SyncItem{
+			MediaType: SyncShow,
+			TMDB:      s.Show.IDs.TMDB,
+			IMDB:      s.Show.IDs.IMDB,
+			TVDB:      s.Show.IDs.TVDB,
+			Title:     s.Show.Title,
+			Year:      s.Show.Year,
+		})
+	}
+
+	return ret, nil
+}
+
+func (b *traktBackend) SetWatched(items []*SyncItem) error {
+	movies := []*trakt.WatchedItem{}
+	shows := []*trakt.WatchedItem{}
+	for _, item := range items {
+		if item.TMDB == 0 {
+			continue
+		}
+
+		watched := &trakt.WatchedItem{
+			MediaType: item.MediaType,
+			Watched:   item.Watched,
+			WatchedAt: item.WatchedAt,
+		}
+		if item.MediaType == SyncMovie {
+			watched.Movie = item.TMDB
+			movies = append(movies, watched)
+			continue
+		}
+
+		watched.Show = item.TMDB
+		watched.Season = item.Season
+		watched.Episode = item.Episode
+		shows = append(shows, watched)
+	}
+
+	// Each request should contain only one media type and only watched or unwatched items
+	for _, list := range [][]*trakt.WatchedItem{movies, shows} {
+		for _, watched := range []bool{true, false} {
+			batch := []*trakt.WatchedItem{}
+			for _, item := range list {
+				if item.Watched == watched {
+					batch = append(batch, item)
+				}
+			}
+			if len(batch) == 0 {
+				continue
+			}
+
+			if _, err := trakt.SetMultipleWatched(batch); err != nil && err != trakt.ErrQueued {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddWatchlist adds movies and shows to Trakt watchlist
+func (b *traktBackend) AddWatchlist(items []*SyncItem) error {
+	movies := []int{}
+	shows := []int{}
+	for _, item := range items {
+		if item.TMDB == 0 {
+			continue
+		} else if item.MediaType == SyncMovie {
+			movies = append(movies, item.TMDB)
+		} else if item.MediaType == SyncShow {
+			shows = append(shows, item.TMDB)
+		}
+	}
+	if len(movies) == 0 && len(shows) == 0 {
+		return nil
+	}
+
+	if _, err := trakt.AddMultipleToWatchlist(movies, shows); err != nil && err != trakt.ErrQueued {
+		return err
+	}
+	return nil
+}
+
+func (b *traktBackend) Scrobble(action string, item *SyncItem, watched, runtime float64) error {
+	tmdbID := item.TMDB
+	if item.MediaType == SyncEpisode {
+		tmdbID = item.EpisodeTMDB
+	}
+
+	trakt.Scrobble(action, item.MediaType, tmdbID, watched, runtime)
+	return nil
+}
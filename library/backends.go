@@ -0,0 +1,323 @@
+package library
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Sync item media types
+const (
+	SyncMovie   = "movie"
+	SyncShow    = "show"
+	SyncSeason  = "season"
+	SyncEpisode = "episode"
+)
+
+// SyncItem is a movie, show, season or episode in the sync backend,
+// with all the IDs, that backend knows about.
+type SyncItem struct {
+	MediaType string
+
+	// TMDB ID of the movie or of the show, EpisodeTMDB is only needed for Trakt scrobbling
+	TMDB        int
+	IMDB        string
+	TVDB        int
+	AniList     int
+	MAL         int
+	EpisodeTMDB int
+
+	Title   string
+	Year    int
+	Season  int
+	Episode int
+
+	Watched   bool
+	WatchedAt time.Time
+
+	// Playback progress in percents and item duration in seconds
+	Progress float64
+	Runtime  int
+	PausedAt time.Time
+}
+
+// SyncBackend is a service, that keeps user's watch state
+type SyncBackend interface {
+	Name() string
+	Enabled() bool
+	Authorize() error
+	Deauthorize() error
+
+	// Watched returns watched movies and episodes
+	Watched() ([]*SyncItem, error)
+	// Paused returns movies and episodes with playback progress
+	Paused() ([]*SyncItem, error)
+	// Watchlist returns movies and shows, that user plans to watch
+	Watchlist() ([]*SyncItem, error)
+
+	// SetWatched adds items to, or removes them from, watched history
+	SetWatched(items []*SyncItem) error
+	// Scrobble reports playback state, action is start, pause or stop
+	Scrobble(action string, item *SyncItem, watched, runtime float64) error
+}
+
+// syncRefresher is a backend, that has own incremental sync of watch state
+type syncRefresher interface {
+	Refresh() error
+}
+
+// syncWatchlister is a backend, that accepts movies and shows to its watchlist
+type syncWatchlister interface {
+	AddWatchlist(items []*SyncItem) error
+}
+
+var (
+	syncBackends = []SyncBackend{
+		&traktBackend{},
+		&simklBackend{},
+		&anilistBackend{},
+	}
+
+	syncBackendsMu sync.Mutex
+)
+
+// SyncBackends returns all known sync backends
+func SyncBackends() []SyncBackend {
+	return syncBackends
+}
+
+// GetSyncBackend returns sync backend by name
+func GetSyncBackend(name string) SyncBackend {
+	for _, b := range syncBackends {
+		if b.Name() == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// EnabledSyncBackends returns authorized backends
+func EnabledSyncBackends() []SyncBackend {
+	ret := []SyncBackend{}
+	for _, b := range syncBackends {
+		if b.Enabled() {
+			ret = append(ret, b)
+		}
+	}
+	return ret
+}
+
+// RefreshSyncBackends pulls watch state from all enabled backends and applies it to Kodi library
+func RefreshSyncBackends() error {
+	syncBackendsMu.Lock()
+	defer syncBackendsMu.Unlock()
+
+	var lastErr error
+	for _, b := range EnabledSyncBackends() {
+		var err error
+		if r, ok := b.(syncRefresher); ok {
+			err = r.Refresh()
+		} else {
+			err = refreshSyncBackend(b)
+		}
+
+		if err != nil {
+			log.Warningf("Could not sync watch state with %s: %s", b.Name(), err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// SetWatched sends watched state to all enabled backends
+func SetWatched(items []*SyncItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	for _, b := range EnabledSyncBackends() {
+		if err := b.SetWatched(items); err != nil {
+			log.Warningf("Could not set watched state in %s: %s", b.Name(), err)
+		}
+	}
+}
+
+// Scrobble sends playback state to all enabled backends
+func Scrobble(action string, item *SyncItem, watched, runtime float64) {
+	if item == nil {
+		return
+	}
+
+	for _, b := range EnabledSyncBackends() {
+		if err := b.Scrobble(action, item, watched, runtime); err != nil {
+			log.Warningf("Could not scrobble to %s: %s", b.Name(), err)
+		}
+	}
+}
+
+// refreshSyncBackend applies watched, paused and watchlisted items of the backend
+func refreshSyncBackend(b SyncBackend) error {
+	xbmcHost, err := xbmc.GetLocalXBMCHost()
+	if xbmcHost == nil || err != nil {
+		return err
+	}
+
+	l := uid.Get()
+	if l.Running.IsOverall || (!config.Get().TraktSyncPlaybackEnabled && xbmcHost.PlayerIsPlaying()) {
+		return nil
+	}
+
+	started := time.Now()
+	defer func() {
+		log.Infof("%s sync finished in %s", b.Name(), time.Since(started))
+	}()
+
+	watched, err := b.Watched()
+	if err != nil {
+		return err
+	}
+
+	updated := 0
+	for _, item := range watched {
+		if applySyncWatched(xbmcHost, item) {
+			updated++
+		}
+	}
+	if updated > 0 {
+		log.Infof("Marked %d items as watched from %s", updated, b.Name())
+		RefreshUIDsRunner(true)
+	}
+
+	paused, err := b.Paused()
+	if err != nil {
+		return err
+	}
+	for _, item := range paused {
+		applySyncPaused(xbmcHost, item)
+	}
+
+	if !config.Get().SyncBackendsWatchlist {
+		return nil
+	}
+
+	watchlist, err := b.Watchlist()
+	if err != nil {
+		return err
+	}
+	for _, item := range watchlist {
+		applySyncWatchlist(item)
+	}
+
+	return nil
+}
+
+// applySyncWatched marks Kodi library item as watched, returns true if item was changed
+func applySyncWatched(xbmcHost *xbmc.XBMCHost, item *SyncItem) bool {
+	if item.TMDB == 0 || !item.Watched {
+		return false
+	}
+
+	switch item.MediaType {
+	case SyncMovie:
+		m, err := uid.GetMovieByTMDB(item.TMDB)
+		if err != nil || m == nil || m.IsWatched() {
+			return false
+		}
+
+		m.UIDs.Playcount = 1
+		xbmcHost.SetMovieWatchedWithDate(m.UIDs.Kodi, 1, 0, 0, item.WatchedAt)
+		return true
+	case SyncEpisode:
+		s, err := uid.GetShowByTMDB(item.TMDB)
+		if err != nil || s == nil {
+			return false
+		}
+
+		e := s.GetEpisode(item.Season, item.Episode)
+		if e == nil || e.IsWatched() {
+			return false
+		}
+
+		e.UIDs.Playcount = 1
+		xbmcHost.SetEpisodeWatchedWithDate(e.UIDs.Kodi, 1, 0, 0, item.WatchedAt)
+		return true
+	}
+
+	return false
+}
+
+// applySyncPaused sets resume point for Kodi library item
+func applySyncPaused(xbmcHost *xbmc.XBMCHost, item *SyncItem) {
+	if item.TMDB == 0 || int(item.Progress) <= 0 {
+		return
+	}
+
+	switch item.MediaType {
+	case SyncMovie:
+		m, err := uid.GetMovieByTMDB(item.TMDB)
+		if err != nil || m == nil {
+			return
+		}
+
+		runtime := item.Runtime
+		if runtime <= 0 {
+			if movie := tmdb.GetMovieByID(strconv.Itoa(item.TMDB), config.Get().Language); movie != nil {
+				runtime = movie.Runtime * 60
+			}
+		}
+		if runtime > 0 {
+			xbmcHost.SetMovieProgressWithDate(m.UIDs.Kodi, runtime/100*int(item.Progress), runtime, item.PausedAt.Local())
+		}
+	case SyncEpisode:
+		s, err := uid.GetShowByTMDB(item.TMDB)
+		if err != nil || s == nil {
+			return
+		}
+
+		e := s.GetEpisode(item.Season, item.Episode)
+		if e == nil {
+			return
+		}
+
+		runtime := item.Runtime
+		if runtime <= 0 {
+			if show := tmdb.GetShowByID(strconv.Itoa(item.TMDB), config.Get().Language); show != nil && len(show.EpisodeRunTime) > 0 {
+				runtime = show.EpisodeRunTime[0] * 60
+			}
+		}
+		if runtime > 0 {
+			xbmcHost.SetEpisodeProgressWithDate(e.UIDs.Kodi, runtime/100*int(item.Progress), runtime, item.PausedAt.Local())
+		}
+	}
+}
+
+// applySyncWatchlist adds watchlisted movie or show to the library
+func applySyncWatchlist(item *SyncItem) {
+	if item.TMDB == 0 {
+		return
+	}
+
+	var err error
+	switch item.MediaType {
+	case SyncMovie:
+		if IsInLibrary(item.TMDB, MovieType) {
+			return
+		}
+		_, err = AddMovie(strconv.Itoa(item.TMDB), false)
+	case SyncShow:
+		if IsInLibrary(item.TMDB, ShowType) {
+			return
+		}
+		_, err = AddShow(strconv.Itoa(item.TMDB), false)
+	}
+
+	if err != nil {
+		log.Debugf("Could not add watchlisted %s %d to the library: %s", item.MediaType, item.TMDB, err)
+	}
+}
@@ -0,0 +1,180 @@
+package library
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+
+	"github.com/elgatito/elementum/cache"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/util"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// collectionSetsRequestDelay is a delay between TMDB requests, while movies are checked for collections
+const collectionSetsRequestDelay = 300 * time.Millisecond
+
+// CollectionSubscriptionID returns ID of the subscription, that follows TMDB collection
+func CollectionSubscriptionID(collectionID int) string {
+	return SubscriptionID(SubscriptionTMDBCollection, "", strconv.Itoa(collectionID))
+}
+
+// IsCollectionFollowed checks whether TMDB collection is attached to the library
+func IsCollectionFollowed(collectionID int) bool {
+	return GetSubscription(CollectionSubscriptionID(collectionID)) != nil
+}
+
+// FollowCollection attaches TMDB collection to the library as a set,
+// newly released parts are added on library updates.
+func FollowCollection(collectionID int) (*tmdb.Collection, error) {
+	c := tmdb.GetCollection(collectionID, config.Get().Language)
+	if c == nil {
+		return nil, fmt.Errorf("Collection with TMDB %d not found", collectionID)
+	}
+
+	_, err := Subscribe(SubscriptionTMDBCollection, "", strconv.Itoa(collectionID), c.Name, true, RemovalKeep)
+	return c, err
+}
+
+// AddCollection adds released movies of TMDB collection to the library, without following it
+func AddCollection(collectionID int) (*tmdb.Collection, int, error) {
+	if err := checkMoviesPath(); err != nil {
+		return nil, 0, err
+	}
+
+	c := tmdb.GetCollection(collectionID, config.Get().Language)
+	if c == nil {
+		return nil, 0, fmt.Errorf("Collection with TMDB %d not found", collectionID)
+	}
+
+	ids := []int{}
+	for _, p := range releasedCollectionParts(c) {
+		tmdbID := strconv.Itoa(p.ID)
+		if IsInLibrary(p.ID, MovieType) || uid.IsDuplicateMovie(tmdbID) {
+			continue
+		}
+
+		if _, err := writeMovieStrm(tmdbID, false); err != nil {
+			log.Warningf("Could not add %s from collection %s: %s", p.Title, c.Name, err)
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+
+	if err := updateBatchDBItem(ids, StateActive, MovieType, 0); err != nil {
+		return c, 0, err
+	}
+
+	log.Noticef("%d movies of collection %s added to library", len(ids), c.Name)
+	return c, len(ids), nil
+}
+
+// releasedCollectionParts returns movies of the collection, that are already out
+func releasedCollectionParts(c *tmdb.Collection) []*tmdb.Entity {
+	ret := []*tmdb.Entity{}
+	for _, p := range c.Parts {
+		if p == nil || p.ReleaseDate == "" {
+			continue
+		} else if _, isExpired := util.AirDateWithExpireCheck(p.ReleaseDate, config.Get().ShowEpisodesOnReleaseDay); isExpired {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+// collectionNFO returns NFO set of TMDB collection, that has the movie, so Kodi groups franchises
+func collectionNFO(m *tmdb.Movie) string {
+	if m.BelongsToCollection == nil || m.BelongsToCollection.Name == "" {
+		return ""
+	}
+
+	overview := ""
+	if c := tmdb.GetCollection(m.BelongsToCollection.ID, config.Get().Language); c != nil {
+		overview = c.Overview
+	}
+
+	return fmt.Sprintf("\t<set>\n\t\t<name>%s</name>\n\t\t<overview>%s</overview>\n\t</set>\n",
+		html.EscapeString(m.BelongsToCollection.Name), html.EscapeString(overview))
+}
+
+// writeCollectionSets rewrites NFO files of library movies once, to add sets of TMDB collections.
+// Only movies, cached before collections were kept, are fetched again, with a delay between requests,
+// and Kodi re-reads NFO files of movies that are collection parts.
+func writeCollectionSets(xbmcHost *xbmc.XBMCHost) {
+	done := false
+	if err := cacheStore.Get(cache.LibraryCollectionSetsKey, &done); err == nil && done {
+		return
+	} else if !config.Get().LibraryNFOMovies {
+		return
+	}
+
+	var lis []database.LibraryItem
+	if err := database.GetStormDB().Select(q.Eq("MediaType", MovieType), q.Eq("State", StateActive)).Find(&lis); err != nil && err != storm.ErrNotFound {
+		log.Warningf("Could not get list of library movies: %s", err)
+		return
+	}
+
+	toFetch := []string{}
+	for _, i := range lis {
+		tmdbID := strconv.Itoa(i.ID)
+
+		var movie *tmdb.Movie
+		if err := cacheStore.Get(fmt.Sprintf(cache.TMDBMovieByIDKey, tmdbID, config.Get().StrmLanguage), &movie); err == nil && movie != nil && movie.BelongsToCollection != nil {
+			continue
+		}
+		toFetch = append(toFetch, tmdbID)
+	}
+
+	var dialog *xbmc.DialogProgressBG
+	if xbmcHost != nil && len(toFetch) > 0 {
+		dialog = xbmcHost.NewDialogProgressBG("Elementum", "LOCALIZE[30761]", "LOCALIZE[30761]")
+	}
+	defer func() {
+		if dialog != nil {
+			dialog.Close()
+		}
+	}()
+
+	rewritten := 0
+	for idx, tmdbID := range toFetch {
+		if dialog != nil {
+			dialog.Update(idx*100/len(toFetch), "Elementum", "LOCALIZE[30761]")
+		}
+
+		select {
+		case <-closer.C():
+			return
+		case <-time.After(collectionSetsRequestDelay):
+		}
+
+		cacheStore.Delete(fmt.Sprintf(cache.TMDBMovieByIDKey, tmdbID, config.Get().StrmLanguage))
+		m := tmdb.GetMovieByID(tmdbID, config.Get().StrmLanguage)
+		if m == nil || m.BelongsToCollection == nil {
+			continue
+		}
+
+		if _, err := writeMovieStrm(tmdbID, false); err != nil {
+			continue
+		}
+		rewritten++
+
+		if xbmcHost == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(tmdbID)
+		if kodiMovie, err := uid.GetMovieByTMDB(id); err == nil && kodiMovie != nil {
+			xbmcHost.VideoLibraryRefreshMovie(kodiMovie.UIDs.Kodi)
+		}
+	}
+
+	log.Infof("Checked collections of %d library movies, NFO files of %d movies are rewritten with collection sets", len(toFetch), rewritten)
+	cacheStore.Set(cache.LibraryCollectionSetsKey, true, cache.LibraryCollectionSetsExpire)
+}
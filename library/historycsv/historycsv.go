@@ -0,0 +1,134 @@
+// Package historycsv reads watch history CSV exports of Letterboxd and IMDb
+package historycsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types of exported rows
+const (
+	Movie   = "movie"
+	Show    = "show"
+	Episode = "episode"
+)
+
+// Row is a movie, show or episode of the export file
+type Row struct {
+	// Line of the row in the file, the header is on the first line
+	Line      int
+	MediaType string
+	IMDB      string
+	Title     string
+	Year      int
+	Watched   bool
+	WatchedAt time.Time
+}
+
+// ReadLetterboxd reads Letterboxd diary.csv or watched.csv, which contain only watched movies
+func ReadLetterboxd(r io.Reader) ([]*Row, error) {
+	records, err := read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []*Row{}
+	for i, record := range records {
+		row := &Row{
+			Line:      i + 2,
+			MediaType: Movie,
+			Title:     record["Name"],
+			Watched:   true,
+			WatchedAt: parseDate(record["Watched Date"]),
+		}
+		row.Year, _ = strconv.Atoi(record["Year"])
+		if row.WatchedAt.IsZero() {
+			row.WatchedAt = parseDate(record["Date"])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ReadIMDb reads IMDb ratings.csv, where rated titles are watched, or watchlist CSV,
+// which is told apart by the Position column.
+func ReadIMDb(r io.Reader) ([]*Row, error) {
+	records, err := read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []*Row{}
+	for i, record := range records {
+		_, isWatchlist := record["Position"]
+
+		row := &Row{
+			Line:    i + 2,
+			IMDB:    record["Const"],
+			Title:   record["Title"],
+			Watched: !isWatchlist,
+		}
+		row.Year, _ = strconv.Atoi(record["Year"])
+		if row.Watched {
+			row.WatchedAt = parseDate(record["Date Rated"])
+		}
+
+		switch record["Title Type"] {
+		case "tvSeries", "tvMiniSeries":
+			row.MediaType = Show
+		case "tvEpisode":
+			row.MediaType = Episode
+		default:
+			row.MediaType = Movie
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// read reads CSV file with a header into maps of column name to value
+func read(r io.Reader) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	records := []map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		values := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				values[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		records = append(records, values)
+	}
+	return records, nil
+}
+
+// parseDate parses dates used in CSV exports
+func parseDate(value string) time.Time {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,117 @@
+package historycsv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func TestReadLetterboxd(t *testing.T) {
+	data := "\ufeffDate,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+		"2023-05-02,Heat,1995,https://boxd.it/1,4.5,,,2023-05-01\n" +
+		"2023-06-10,\"Crouching Tiger, Hidden Dragon\",2000,https://boxd.it/2,4,,,\n"
+
+	rows, err := ReadLetterboxd(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadLetterboxd() error = %v", err)
+	}
+
+	want := []Row{
+		{Line: 2, MediaType: Movie, Title: "Heat", Year: 1995, Watched: true, WatchedAt: date("2023-05-01")},
+		{Line: 3, MediaType: Movie, Title: "Crouching Tiger, Hidden Dragon", Year: 2000, Watched: true, WatchedAt: date("2023-06-10")},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ReadLetterboxd() = %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if *row != want[i] {
+			t.Errorf("ReadLetterboxd() row %d = %+v, want %+v", i, *row, want[i])
+		}
+	}
+}
+
+func TestReadIMDb(t *testing.T) {
+	ratings := "Const,Your Rating,Date Rated,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year\n" +
+		"tt0113277,9,2022-01-15,Heat,https://www.imdb.com/title/tt0113277/,movie,8.3,170,1995\n" +
+		"tt0903747,10,2022-02-20,Breaking Bad,https://www.imdb.com/title/tt0903747/,tvSeries,9.5,49,2008\n" +
+		"tt2301451,10,2022-03-01,Breaking Bad: Ozymandias,https://www.imdb.com/title/tt2301451/,tvEpisode,10,47,2013\n" +
+		"tt1355642,7,2022-04-01,Fullmetal Alchemist: Brotherhood,https://www.imdb.com/title/tt1355642/,tvMiniSeries,9.1,24,2009\n"
+	watchlist := "Position,Const,Created,Modified,Description,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year\n" +
+		"1,tt0111161,2022-01-01,2022-01-01,,The Shawshank Redemption,https://www.imdb.com/title/tt0111161/,movie,9.3,142,1994\n"
+
+	tests := []struct {
+		name string
+		data string
+		want []Row
+	}{
+		{
+			name: "ratings",
+			data: ratings,
+			want: []Row{
+				{Line: 2, MediaType: Movie, IMDB: "tt0113277", Title: "Heat", Year: 1995, Watched: true, WatchedAt: date("2022-01-15")},
+				{Line: 3, MediaType: Show, IMDB: "tt0903747", Title: "Breaking Bad", Year: 2008, Watched: true, WatchedAt: date("2022-02-20")},
+				{Line: 4, MediaType: Episode, IMDB: "tt2301451", Title: "Breaking Bad: Ozymandias", Year: 2013, Watched: true, WatchedAt: date("2022-03-01")},
+				{Line: 5, MediaType: Show, IMDB: "tt1355642", Title: "Fullmetal Alchemist: Brotherhood", Year: 2009, Watched: true, WatchedAt: date("2022-04-01")},
+			},
+		},
+		{
+			name: "watchlist",
+			data: watchlist,
+			want: []Row{
+				{Line: 2, MediaType: Movie, IMDB: "tt0111161", Title: "The Shawshank Redemption", Year: 1994},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadIMDb(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("ReadIMDb() error = %v", err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("ReadIMDb() = %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if *row != tt.want[i] {
+					t.Errorf("ReadIMDb() row %d = %+v, want %+v", i, *row, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadEmpty(t *testing.T) {
+	if _, err := ReadIMDb(strings.NewReader("")); err == nil {
+		t.Errorf("ReadIMDb(\"\") error = nil, want error")
+	}
+
+	rows, err := ReadLetterboxd(strings.NewReader("Date,Name,Year\n"))
+	if err != nil || len(rows) != 0 {
+		t.Errorf("ReadLetterboxd(header only) = %d rows, %v, want 0 rows, nil", len(rows), err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2023-05-01", want: date("2023-05-01")},
+		{value: "2023-05-01T10:20:30+02:00", want: time.Date(2023, 5, 1, 8, 20, 30, 0, time.UTC)},
+		{value: "2023-05-01 10:20:30", want: time.Date(2023, 5, 1, 10, 20, 30, 0, time.UTC)},
+		{value: "01/05/2023", want: time.Time{}},
+		{value: "", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		if got := parseDate(tt.value); !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
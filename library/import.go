@@ -0,0 +1,393 @@
+package library
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library/historycsv"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/trakt"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Supported history export formats
+const (
+	ImportLetterboxd = "letterboxd"
+	ImportIMDb       = "imdb"
+	ImportTrakt      = "trakt"
+)
+
+// ImportRow is a row of the export file, that could not be imported
+type ImportRow struct {
+	Line   int    `json:"line"`
+	Title  string `json:"title"`
+	Year   int    `json:"year,omitempty"`
+	IMDB   string `json:"imdb,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport is a result of history import
+type ImportReport struct {
+	Format       string       `json:"format"`
+	DryRun       bool         `json:"dry_run"`
+	Total        int          `json:"total"`
+	Matched      int          `json:"matched"`
+	Watchlisted  int          `json:"watchlisted"`
+	Marked       int          `json:"marked"`
+	NotInLibrary int          `json:"not_in_library"`
+	Pushed       []string     `json:"pushed,omitempty"`
+	Unmatched    []*ImportRow `json:"unmatched"`
+}
+
+// csvMediaTypes maps media types of CSV rows to sync media types
+var csvMediaTypes = map[string]string{
+	historycsv.Movie:   SyncMovie,
+	historycsv.Show:    SyncShow,
+	historycsv.Episode: SyncEpisode,
+}
+
+// importItem is a parsed row of the export file
+type importItem struct {
+	SyncItem
+	Line int
+}
+
+// traktExportItem is an entry of Trakt history, watched or watchlist backup files
+type traktExportItem struct {
+	Type          string                 `json:"type"`
+	WatchedAt     time.Time              `json:"watched_at"`
+	LastWatchedAt time.Time              `json:"last_watched_at"`
+	ListedAt      time.Time              `json:"listed_at"`
+	Movie         *trakt.Movie           `json:"movie"`
+	Show          *trakt.Show            `json:"show"`
+	Episode       *trakt.Episode         `json:"episode"`
+	Seasons       []*trakt.WatchedSeason `json:"seasons"`
+}
+
+// ImportHistoryFile imports watch history from Letterboxd, IMDb or Trakt export file,
+// format is detected from the file contents if it is empty.
+// Kodi special:// paths are translated, network paths, like smb://, can't be read.
+func ImportHistoryFile(path, format string, dryRun bool, backend string) (*ImportReport, error) {
+	if strings.HasPrefix(path, "special://") {
+		if xbmcHost, _ := xbmc.GetLocalXBMCHost(); xbmcHost != nil {
+			path = xbmcHost.TranslatePath(path)
+		}
+	}
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("Could not read %s, copy export file to a local folder", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ImportHistory(f, format, dryRun, backend)
+}
+
+// ImportHistory resolves export rows to TMDB items and marks them as watched in Kodi library.
+// Items are pushed to the backend with the given name, or to all enabled backends if it is "all",
+// watchlisted items are only pushed to backends, that support watchlist.
+func ImportHistory(r io.Reader, format string, dryRun bool, backend string) (*ImportReport, error) {
+	br := bufio.NewReader(r)
+	if format == "" {
+		format = detectImportFormat(br)
+	}
+
+	var items []*importItem
+	var err error
+	switch format {
+	case ImportLetterboxd, ImportIMDb:
+		items, err = parseCSV(br, format)
+	case ImportTrakt:
+		items, err = parseTraktExport(br)
+	default:
+		return nil, errors.New("Unknown export format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		Format:    format,
+		DryRun:    dryRun,
+		Total:     len(items),
+		Unmatched: []*ImportRow{},
+	}
+
+	watched := []*SyncItem{}
+	watchlist := []*SyncItem{}
+	for _, item := range items {
+		if reason := resolveImportItem(item); reason != "" {
+			report.Unmatched = append(report.Unmatched, &ImportRow{
+				Line:   item.Line,
+				Title:  item.Title,
+				Year:   item.Year,
+				IMDB:   item.IMDB,
+				Reason: reason,
+			})
+			continue
+		}
+
+		report.Matched++
+		if item.Watched {
+			watched = append(watched, &item.SyncItem)
+		} else {
+			watchlist = append(watchlist, &item.SyncItem)
+		}
+	}
+	report.Watchlisted = len(watchlist)
+
+	log.Infof("Resolved %d of %d %s history items", report.Matched, report.Total, format)
+	if dryRun {
+		return report, nil
+	}
+
+	xbmcHost, _ := xbmc.GetLocalXBMCHost()
+	if xbmcHost != nil {
+		for _, item := range watched {
+			if !isImportItemInLibrary(item) {
+				report.NotInLibrary++
+			} else if applySyncWatched(xbmcHost, item) {
+				report.Marked++
+			}
+		}
+		if report.Marked > 0 {
+			RefreshUIDsRunner(true)
+		}
+	}
+
+	if backend == "" {
+		return report, nil
+	}
+
+	// Watchlisted items are not added to the library, they go only to backend watchlists
+	for _, b := range EnabledSyncBackends() {
+		if backend != "all" && b.Name() != backend {
+			continue
+		}
+
+		if len(watched) > 0 {
+			if err := b.SetWatched(watched); err != nil {
+				log.Warningf("Could not push imported history to %s: %s", b.Name(), err)
+				continue
+			}
+		}
+		if w, ok := b.(syncWatchlister); ok && len(watchlist) > 0 {
+			if err := w.AddWatchlist(watchlist); err != nil {
+				log.Warningf("Could not push imported watchlist to %s: %s", b.Name(), err)
+				continue
+			}
+		}
+		report.Pushed = append(report.Pushed, b.Name())
+	}
+
+	return report, nil
+}
+
+// detectImportFormat guesses export format by the first line of the file
+func detectImportFormat(br *bufio.Reader) string {
+	head, _ := br.Peek(512)
+	line := strings.TrimSpace(strings.TrimPrefix(string(head), "\ufeff"))
+	if idx := strings.IndexByte(line, '\n'); idx != -1 {
+		line = line[:idx]
+	}
+
+	switch {
+	case strings.HasPrefix(line, "[") || strings.HasPrefix(line, "{"):
+		return ImportTrakt
+	case strings.Contains(line, "Letterboxd URI"):
+		return ImportLetterboxd
+	case strings.Contains(line, "Const"):
+		return ImportIMDb
+	}
+	return ""
+}
+
+// parseCSV reads Letterboxd or IMDb CSV export
+func parseCSV(r io.Reader, format string) ([]*importItem, error) {
+	read := historycsv.ReadLetterboxd
+	if format == ImportIMDb {
+		read = historycsv.ReadIMDb
+	}
+
+	rows, err := read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*importItem{}
+	for _, row := range rows {
+		item := &importItem{Line: row.Line}
+		item.MediaType = csvMediaTypes[row.MediaType]
+		item.IMDB = row.IMDB
+		item.Title = row.Title
+		item.Year = row.Year
+		item.Watched = row.Watched
+		item.WatchedAt = row.WatchedAt
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseTraktExport reads Trakt history, watched movies, watched shows or watchlist JSON backup
+func parseTraktExport(r io.Reader) ([]*importItem, error) {
+	entries := []*traktExportItem{}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	items := []*importItem{}
+	for i, e := range entries {
+		watchedAt := e.WatchedAt
+		if watchedAt.IsZero() {
+			watchedAt = e.LastWatchedAt
+		}
+		isWatchlist := !e.ListedAt.IsZero()
+
+		if e.Movie != nil {
+			item := traktImportItem(i+1, SyncMovie, &e.Movie.Object)
+			item.Watched = !isWatchlist
+			item.WatchedAt = watchedAt
+			items = append(items, item)
+			continue
+		} else if e.Show == nil {
+			continue
+		}
+
+		if e.Episode != nil {
+			item := traktImportItem(i+1, SyncEpisode, &e.Show.Object)
+			item.Season = e.Episode.Season
+			item.Episode = e.Episode.Number
+			item.Watched = true
+			item.WatchedAt = watchedAt
+			items = append(items, item)
+		} else if len(e.Seasons) > 0 {
+			for _, season := range e.Seasons {
+				for _, episode := range season.Episodes {
+					item := traktImportItem(i+1, SyncEpisode, &e.Show.Object)
+					item.Season = season.Number
+					item.Episode = episode.Number
+					item.Watched = true
+					item.WatchedAt = episode.LastWatchedAt
+					items = append(items, item)
+				}
+			}
+		} else if isWatchlist {
+			items = append(items, traktImportItem(i+1, SyncShow, &e.Show.Object))
+		}
+	}
+	return items, nil
+}
+
+func traktImportItem(line int, mediaType string, o *trakt.Object) *importItem {
+	item := &importItem{Line: line}
+	item.MediaType = mediaType
+	item.Title = o.Title
+	item.Year = o.Year
+	if o.IDs != nil {
+		item.TMDB = o.IDs.TMDB
+		item.IMDB = o.IDs.IMDB
+		item.TVDB = o.IDs.TVDB
+	}
+	return item
+}
+
+// resolveImportItem finds TMDB ID of the item, returns the reason if it can't be found
+func resolveImportItem(item *importItem) string {
+	if item.MediaType == SyncEpisode && item.Season == 0 && item.Episode == 0 {
+		return resolveImportEpisode(item)
+	} else if item.MediaType == SyncShow && item.Watched {
+		return "watched shows without episodes are not supported"
+	} else if item.TMDB != 0 {
+		return ""
+	}
+
+	if item.IMDB != "" {
+		if find := tmdb.Find(item.IMDB, "imdb_id"); find != nil {
+			if item.MediaType == SyncMovie && len(find.MovieResults) > 0 {
+				item.TMDB = find.MovieResults[0].ID
+			} else if item.MediaType != SyncMovie && len(find.TVResults) > 0 {
+				item.TMDB = find.TVResults[0].ID
+			}
+		}
+	}
+	if item.TMDB == 0 && item.TVDB != 0 {
+		if find := tmdb.Find(strconv.Itoa(item.TVDB), "tvdb_id"); find != nil && len(find.TVResults) > 0 {
+			item.TMDB = find.TVResults[0].ID
+		}
+	}
+	if item.TMDB == 0 && item.Title != "" {
+		item.TMDB = searchImportItem(item)
+	}
+
+	if item.TMDB == 0 {
+		return "not found on TMDB"
+	}
+	return ""
+}
+
+// resolveImportEpisode finds show, season and episode number of IMDb episode
+func resolveImportEpisode(item *importItem) string {
+	if item.IMDB == "" {
+		return "single episodes without show are not supported"
+	}
+
+	find := tmdb.Find(item.IMDB, "imdb_id")
+	if find == nil || len(find.TVEpisodeResults) == 0 || find.TVEpisodeResults[0] == nil {
+		return "not found on TMDB"
+	}
+
+	episode := find.TVEpisodeResults[0]
+	if episode.ShowID == 0 || episode.EpisodeNumber == 0 {
+		return "not found on TMDB"
+	}
+	item.TMDB = episode.ShowID
+	item.EpisodeTMDB = episode.ID
+	item.Season = episode.SeasonNumber
+	item.Episode = episode.EpisodeNumber
+	return ""
+}
+
+// searchImportItem looks for TMDB movie or show by title and year
+func searchImportItem(item *importItem) int {
+	language := config.Get().Language
+	if item.MediaType == SyncMovie {
+		movies, _ := tmdb.SearchMovies(item.Title, language, 1)
+		for _, m := range movies {
+			if item.Year == 0 || strings.HasPrefix(m.ReleaseDate, strconv.Itoa(item.Year)) {
+				return m.ID
+			}
+		}
+		return 0
+	}
+
+	shows, _ := tmdb.SearchShows(item.Title, language, 1)
+	for _, s := range shows {
+		if item.Year == 0 || strings.HasPrefix(s.FirstAirDate, strconv.Itoa(item.Year)) {
+			return s.ID
+		}
+	}
+	return 0
+}
+
+// isImportItemInLibrary checks whether movie or show of the episode is in Kodi library
+func isImportItemInLibrary(item *SyncItem) bool {
+	if item.MediaType == SyncMovie {
+		m, err := uid.GetMovieByTMDB(item.TMDB)
+		return err == nil && m != nil
+	}
+
+	s, err := uid.GetShowByTMDB(item.TMDB)
+	return err == nil && s != nil
+}
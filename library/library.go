@@ -161,6 +161,8 @@ func Init() {
 		RefreshLocal()
 		Refresh()
 		initialized = true
+
+		writeCollectionSets(xbmcHost)
 	}()
 
 	// Removed episodes debouncer
@@ -263,7 +265,7 @@ func Init() {
 			case <-closing:
 				return
 			default:
-				PlanTraktUpdate()
+				go RefreshSyncBackends()
 				PlanKodiShowsUpdate()
 			}
 		}()
@@ -309,8 +311,11 @@ func Init() {
 			if config.Get().UpdateFrequency > 0 && config.Get().LibraryEnabled && config.Get().LibrarySyncEnabled && (config.Get().LibrarySyncPlaybackEnabled) {
 				PlanKodiShowsUpdate()
 			}
+			if config.Get().UpdateFrequency > 0 && config.Get().LibraryEnabled {
+				go SyncSubscriptions()
+			}
 		case <-traktSyncTicker.C:
-			PlanTraktUpdate()
+			go RefreshSyncBackends()
 		case <-markedForRemovalTicker.C:
 			var items []database.BTItem
 			database.GetStormDB().Select(q.Eq("State", database.StateDeleted)).Find(&items)
@@ -512,7 +517,7 @@ func writeMovieNFO(m *tmdb.Movie, p string) error {
 	<uniqueid type="tmdb" default="true">%v</uniqueid>
 	<uniqueid type="imdb" default="false">%v</uniqueid>
 	<uniqueid type="tvdb" default="false">%v</uniqueid>
-</movie>
+%s</movie>
 https://www.themoviedb.org/movie/%v
 `
 
@@ -520,12 +525,16 @@ https://www.themoviedb.org/movie/%v
 		m.ExternalIDs = &tmdb.ExternalIDs{}
 	}
 
+	nfo := collectionNFO(m)
+	nfo += subscriptionNFO(MovieType, m.ID, nfo == "")
+
 	out = fmt.Sprintf(out,
 		m.ID,
 		m.ID,
 		m.ID,
 		m.ExternalIDs.IMDBId,
 		m.ExternalIDs.TVDBID,
+		nfo,
 		m.ID,
 	)
 
@@ -642,7 +651,7 @@ func writeShowNFO(s *tmdb.Show, p string) error {
 	<uniqueid type="tmdb" default="true">%v</uniqueid>
 	<uniqueid type="imdb" default="false">%v</uniqueid>
 	<uniqueid type="tvdb" default="false">%v</uniqueid>
-</tvshow>
+%s</tvshow>
 https://www.themoviedb.org/tv/%v
 `
 
@@ -656,6 +665,7 @@ https://www.themoviedb.org/tv/%v
 		s.ID,
 		s.ExternalIDs.IMDBId,
 		s.ExternalIDs.TVDBID,
+		subscriptionNFO(ShowType, s.ID, false),
 		s.ID,
 	)
 
@@ -1055,6 +1065,7 @@ func SyncMoviesList(listID string, updating bool, isUpdateNeeded bool) (err erro
 	}
 
 	var movieIDs []int
+	var planned, applied []*PlanAction
 	for _, movie := range addedMovies {
 		title := movie.Movie.Title
 		// Try to resolve TMDB id through IMDB id, if provided
@@ -1083,12 +1094,26 @@ func SyncMoviesList(listID string, updating bool, isUpdateNeeded bool) (err erro
 			continue
 		}
 
+		action := &PlanAction{Action: PlanWriteStrm, MediaType: SyncMovie, TMDB: movie.Movie.IDs.TMDB, Title: title, List: listID}
+		if IsSyncPlanning() {
+			if !wasRemoved(movie.Movie.IDs.TMDB, MovieType) {
+				planned = append(planned, action)
+			}
+			continue
+		}
+
+		action.Existed = len(getMoviePathsByTMDB(movie.Movie.IDs.TMDB)) > 0
 		if _, err := writeMovieStrm(tmdbID, false); err != nil {
 			continue
 		}
 
 		movieIDs = append(movieIDs, movie.Movie.IDs.TMDB)
+		if !action.Existed {
+			applied = append(applied, action)
+		}
 	}
+	planActions(planned...)
+	journalActions(applied...)
 
 	if err := updateBatchDBItem(movieIDs, StateActive, MovieType, 0); err != nil {
 		return err
@@ -1176,6 +1201,7 @@ func SyncShowsList(listID string, updating bool, isUpdateNeeded bool) (err error
 	}()
 
 	var showIDs []int
+	var planned, applied []*PlanAction
 	for _, show := range addedShows {
 		title := show.Show.Title
 		// Try to resolve TMDB id through IMDB id, if provided
@@ -1209,12 +1235,26 @@ func SyncShowsList(listID string, updating bool, isUpdateNeeded bool) (err error
 			continue
 		}
 
+		action := &PlanAction{Action: PlanWriteStrm, MediaType: SyncShow, TMDB: show.Show.IDs.TMDB, Title: title, List: listID}
+		if IsSyncPlanning() {
+			if !wasRemoved(show.Show.IDs.TMDB, ShowType) {
+				planned = append(planned, action)
+			}
+			continue
+		}
+
+		action.Existed = len(getShowPathsByTMDB(show.Show.IDs.TMDB)) > 0
 		if _, err := writeShowStrm(show.Show.IDs.TMDB, false, false); err != nil {
 			continue
 		}
+		if !action.Existed {
+			applied = append(applied, action)
+		}
 
 		showIDs = append(showIDs, show.Show.IDs.TMDB)
 	}
+	planActions(planned...)
+	journalActions(applied...)
 
 	// Cleanup unused map items
 	found := false
@@ -0,0 +1,466 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	"github.com/elgatito/elementum/cache"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Sync plan actions
+const (
+	PlanWriteStrm = "write_strm"
+	PlanRemove    = "remove"
+	PlanWatched   = "watched"
+	PlanUnwatched = "unwatched"
+)
+
+// PlanAction is a single library change, computed by Trakt sync
+type PlanAction struct {
+	Action    string `json:"action"`
+	MediaType string `json:"media_type"`
+	TMDB      int    `json:"tmdb"`
+	Title     string `json:"title"`
+	Season    int    `json:"season,omitempty"`
+	Episode   int    `json:"episode,omitempty"`
+	List      string `json:"list,omitempty"`
+
+	// Subscription is ID of the list subscription, that adds the item, to keep it as added by the list
+	Subscription string `json:"subscription,omitempty"`
+
+	// Paths are strm folders, that are removed with the item
+	Paths []string `json:"paths,omitempty"`
+
+	// Playcount is Kodi playcount before the change, used to undo watched changes
+	Playcount int       `json:"playcount,omitempty"`
+	WatchedAt time.Time `json:"watched_at,omitempty"`
+
+	// Existed is set on apply, if strm files were already in the library before writing
+	Existed bool `json:"existed,omitempty"`
+}
+
+// SyncPlan is a list of library changes, waiting for confirmation, or applied in the last run.
+// Kodi library IDs are not stored, as Kodi could rescan the library before the plan is applied,
+// they are looked up by TMDB ID when the change is applied or reverted.
+type SyncPlan struct {
+	Created time.Time     `json:"created"`
+	Applied time.Time     `json:"applied,omitempty"`
+	Actions []*PlanAction `json:"actions"`
+}
+
+var (
+	syncPlanMu sync.Mutex
+
+	// syncRunStarted is the start of the current sync run, journal of older runs is replaced
+	syncRunStarted time.Time
+
+	// ErrEmptyPlan is returned when there are no library changes to apply or undo
+	ErrEmptyPlan = errors.New("No library changes")
+)
+
+// IsSyncPlanning returns true if library changes, made by Trakt sync, should only be planned
+func IsSyncPlanning() bool {
+	return config.Get().LibrarySyncDryRun
+}
+
+// key identifies changes of the same kind for the same item, files and watched state are separate
+func (a *PlanAction) key() string {
+	kind := "files"
+	if a.Action == PlanWatched || a.Action == PlanUnwatched {
+		kind = "watched"
+	}
+	return fmt.Sprintf("%s_%s_%d_%d_%d", kind, a.MediaType, a.TMDB, a.Season, a.Episode)
+}
+
+// String returns a diff line for the action
+func (a *PlanAction) String() string {
+	sign := "~"
+	switch a.Action {
+	case PlanWriteStrm:
+		sign = "+"
+	case PlanRemove:
+		sign = "-"
+	}
+
+	title := a.Title
+	if a.MediaType == SyncEpisode {
+		title = fmt.Sprintf("%s S%02dE%02d", a.Title, a.Season, a.Episode)
+	}
+
+	ret := fmt.Sprintf("%s %s %s: %s", sign, a.Action, a.MediaType, title)
+	if a.List != "" {
+		ret += fmt.Sprintf(" [%s]", a.List)
+	}
+	for _, path := range a.Paths {
+		ret += "\n    " + path
+	}
+	return ret
+}
+
+// Diff returns the plan as text, one change per line
+func (p *SyncPlan) Diff() string {
+	lines := make([]string, 0, len(p.Actions))
+	for _, a := range p.Actions {
+		lines = append(lines, a.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetSyncPlan returns library changes, waiting for confirmation
+func GetSyncPlan() *SyncPlan {
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	return getSyncPlan(cache.LibrarySyncPlanKey)
+}
+
+// GetSyncJournal returns library changes, applied in the last run
+func GetSyncJournal() *SyncPlan {
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	return getSyncPlan(cache.LibrarySyncJournalKey)
+}
+
+func getSyncPlan(key string) *SyncPlan {
+	plan := &SyncPlan{Actions: []*PlanAction{}}
+	cache.NewDBStore().Get(key, plan)
+	return plan
+}
+
+// DiscardSyncPlan removes library changes, waiting for confirmation
+func DiscardSyncPlan() {
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	cache.NewDBStore().Delete(cache.LibrarySyncPlanKey)
+}
+
+// BeginSyncRun starts new sync run, changes applied after it replace the journal of the previous run
+func BeginSyncRun() {
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	syncRunStarted = time.Now().UTC()
+}
+
+// journalActions adds changes, applied by sync without planning, to the journal of the current run
+func journalActions(actions ...*PlanAction) {
+	if len(actions) == 0 {
+		return
+	}
+
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	journal := getSyncPlan(cache.LibrarySyncJournalKey)
+	if journal.Created.IsZero() || journal.Created.Before(syncRunStarted) {
+		journal = &SyncPlan{Created: syncRunStarted, Actions: []*PlanAction{}}
+		if journal.Created.IsZero() {
+			journal.Created = time.Now().UTC()
+		}
+	}
+	journal.Applied = time.Now().UTC()
+	journal.Actions = append(journal.Actions, actions...)
+
+	cache.NewDBStore().Set(cache.LibrarySyncJournalKey, journal, cache.LibrarySyncJournalExpire)
+}
+
+// planActions adds changes to the pending plan, newer change of the same item replaces older one
+func planActions(actions ...*PlanAction) {
+	if len(actions) == 0 {
+		return
+	}
+
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	plan := getSyncPlan(cache.LibrarySyncPlanKey)
+	if plan.Created.IsZero() {
+		plan.Created = time.Now().UTC()
+	}
+
+	for _, a := range actions {
+		log.Debugf("Planning library change: %s", a)
+
+		replaced := false
+		for i, p := range plan.Actions {
+			if p.key() == a.key() {
+				plan.Actions[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			plan.Actions = append(plan.Actions, a)
+		}
+	}
+
+	cache.NewDBStore().Set(cache.LibrarySyncPlanKey, plan, cache.LibrarySyncPlanExpire)
+}
+
+// ApplySyncPlan applies pending library changes and keeps them as undo journal
+func ApplySyncPlan() (*SyncPlan, error) {
+	xbmcHost, err := xbmc.GetLocalXBMCHost()
+	if xbmcHost == nil || err != nil {
+		return nil, errors.New("No Kodi instance found")
+	}
+
+	// Subscriptions are updated after the plan lock is released, as subscription sync plans under its lock
+	added := []*PlanAction{}
+	defer func() {
+		markSubscriptionsAdded(added)
+	}()
+
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	plan := getSyncPlan(cache.LibrarySyncPlanKey)
+	if len(plan.Actions) == 0 {
+		return plan, ErrEmptyPlan
+	}
+
+	log.Infof("Applying %d planned library changes", len(plan.Actions))
+
+	journal := &SyncPlan{Created: plan.Created, Applied: time.Now().UTC(), Actions: []*PlanAction{}}
+	movieIDs := []int{}
+	showIDs := []int{}
+	for _, a := range plan.Actions {
+		switch a.Action {
+		case PlanWriteStrm:
+			if a.MediaType == SyncMovie {
+				a.Existed = len(getMoviePathsByTMDB(a.TMDB)) > 0
+				if _, err := writeMovieStrm(strconv.Itoa(a.TMDB), false); err != nil {
+					log.Warningf("Could not write strm for movie '%s': %s", a.Title, err)
+					continue
+				}
+				movieIDs = append(movieIDs, a.TMDB)
+			} else {
+				a.Existed = len(getShowPathsByTMDB(a.TMDB)) > 0
+				if _, err := writeShowStrm(a.TMDB, false, false); err != nil {
+					log.Warningf("Could not write strm for show '%s': %s", a.Title, err)
+					continue
+				}
+				showIDs = append(showIDs, a.TMDB)
+			}
+			if a.Subscription != "" && !a.Existed {
+				added = append(added, a)
+			}
+		case PlanRemove:
+			if a.MediaType == SyncMovie {
+				removeMovieFromKodi(xbmcHost, a.TMDB)
+			} else {
+				removeShowFromKodi(xbmcHost, a.TMDB)
+			}
+		case PlanWatched, PlanUnwatched:
+			if !setKodiWatched(xbmcHost, a, a.Action == PlanWatched) {
+				log.Warningf("Could not find '%s' in Kodi library to change watched state", a)
+				continue
+			}
+		}
+		journal.Actions = append(journal.Actions, a)
+	}
+
+	if err := updateBatchDBItem(movieIDs, StateActive, MovieType, 0); err != nil {
+		log.Warningf("Could not update library movies: %s", err)
+	}
+	if err := updateBatchDBItem(showIDs, StateActive, ShowType, 0); err != nil {
+		log.Warningf("Could not update library shows: %s", err)
+	}
+
+	cacheStore := cache.NewDBStore()
+	cacheStore.Delete(cache.LibrarySyncPlanKey)
+	cacheStore.Set(cache.LibrarySyncJournalKey, journal, cache.LibrarySyncJournalExpire)
+
+	refreshAfterPlan(xbmcHost, journal)
+	return journal, nil
+}
+
+// UndoSyncRun reverts library changes, applied in the last run
+func UndoSyncRun() (*SyncPlan, error) {
+	xbmcHost, err := xbmc.GetLocalXBMCHost()
+	if xbmcHost == nil || err != nil {
+		return nil, errors.New("No Kodi instance found")
+	}
+
+	syncPlanMu.Lock()
+	defer syncPlanMu.Unlock()
+
+	journal := getSyncPlan(cache.LibrarySyncJournalKey)
+	if len(journal.Actions) == 0 {
+		return journal, ErrEmptyPlan
+	}
+
+	log.Infof("Reverting %d library changes of the last sync run", len(journal.Actions))
+
+	// Revert in reverse order, to get back to the state before the run
+	for i := len(journal.Actions) - 1; i >= 0; i-- {
+		a := journal.Actions[i]
+		switch a.Action {
+		case PlanWriteStrm:
+			// Items, that were only updated, are kept in the library
+			if a.Existed {
+				continue
+			}
+			if a.MediaType == SyncMovie {
+				removeMovieFromKodi(xbmcHost, a.TMDB)
+			} else {
+				removeShowFromKodi(xbmcHost, a.TMDB)
+			}
+		case PlanRemove:
+			if a.MediaType == SyncMovie {
+				if _, err := AddMovie(strconv.Itoa(a.TMDB), true); err != nil {
+					log.Warningf("Could not restore movie '%s': %s", a.Title, err)
+				}
+			} else {
+				if _, err := AddShow(strconv.Itoa(a.TMDB), true); err != nil {
+					log.Warningf("Could not restore show '%s': %s", a.Title, err)
+				}
+			}
+		case PlanWatched, PlanUnwatched:
+			if !restoreKodiPlaycount(xbmcHost, a) {
+				log.Warningf("Could not find '%s' in Kodi library to restore watched state", a)
+			}
+		}
+	}
+
+	cache.NewDBStore().Delete(cache.LibrarySyncJournalKey)
+
+	refreshAfterPlan(xbmcHost, journal)
+	return journal, nil
+}
+
+// pathsList returns sorted paths of the set
+func pathsList(paths map[string]bool) []string {
+	ret := make([]string, 0, len(paths))
+	for path := range paths {
+		ret = append(ret, path)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// refreshAfterPlan rescans Kodi library if strm files were changed, or reloads library items otherwise
+func refreshAfterPlan(xbmcHost *xbmc.XBMCHost, plan *SyncPlan) {
+	for _, a := range plan.Actions {
+		if a.Action == PlanWriteStrm || a.Action == PlanRemove {
+			xbmcHost.VideoLibraryScan()
+			return
+		}
+	}
+
+	uid.Get().Pending.IsOverall = true
+}
+
+// removeMovieFromKodi removes strm files of the movie and the movie from Kodi library
+func removeMovieFromKodi(xbmcHost *xbmc.XBMCHost, tmdbID int) {
+	kodiMovie, _ := uid.GetMovieByTMDB(tmdbID)
+
+	movie, paths, err := RemoveMovie(tmdbID, true)
+	if err != nil {
+		log.Warningf("Could not remove movie from Kodi library: %s", err)
+	} else if movie != nil && paths != nil {
+		for _, path := range paths {
+			xbmcHost.VideoLibraryCleanDirectory(path, "movies", false)
+		}
+		if kodiMovie != nil {
+			xbmcHost.VideoLibraryRemoveMovie(kodiMovie.XbmcUIDs.Kodi)
+		}
+	}
+}
+
+// removeShowFromKodi removes strm files of the show and the show from Kodi library
+func removeShowFromKodi(xbmcHost *xbmc.XBMCHost, tmdbID int) {
+	kodiShow, _ := uid.FindShowByTMDB(tmdbID)
+
+	show, paths, err := RemoveShow(strconv.Itoa(tmdbID), true)
+	if err != nil {
+		log.Warningf("Could not remove show from Kodi library: %s", err)
+	} else if show != nil && paths != nil {
+		for _, path := range paths {
+			xbmcHost.VideoLibraryCleanDirectory(path, "tvshows", false)
+		}
+		if kodiShow != nil {
+			xbmcHost.VideoLibraryRemoveTVShow(kodiShow.XbmcUIDs.Kodi)
+		}
+	}
+}
+
+// kodiLibraryID returns current Kodi library ID of the planned item, or 0 if it is not in the library
+func kodiLibraryID(a *PlanAction) int {
+	switch a.MediaType {
+	case SyncMovie:
+		if m, err := uid.GetMovieByTMDB(a.TMDB); err == nil && m != nil {
+			return m.UIDs.Kodi
+		}
+	case SyncShow:
+		if s, err := uid.GetShowByTMDB(a.TMDB); err == nil && s != nil {
+			return s.UIDs.Kodi
+		}
+	case SyncEpisode:
+		if s, err := uid.GetShowByTMDB(a.TMDB); err == nil && s != nil {
+			if e := s.GetEpisode(a.Season, a.Episode); e != nil {
+				return e.UIDs.Kodi
+			}
+		}
+	}
+	return 0
+}
+
+// setKodiWatched applies planned watched change to Kodi library item, returns false if the item is not found
+func setKodiWatched(xbmcHost *xbmc.XBMCHost, a *PlanAction, watched bool) bool {
+	kodiID := kodiLibraryID(a)
+	if kodiID == 0 {
+		return false
+	}
+
+	switch a.MediaType {
+	case SyncMovie:
+		if watched {
+			xbmcHost.SetMovieWatchedWithDate(kodiID, a.Playcount+1, 0, 0, a.WatchedAt)
+		} else {
+			xbmcHost.SetMoviePlaycount(kodiID, 0)
+		}
+	case SyncShow:
+		if watched {
+			xbmcHost.SetShowWatchedWithDate(kodiID, 1, a.WatchedAt)
+		} else {
+			xbmcHost.SetShowWatched(kodiID, 0)
+		}
+	case SyncEpisode:
+		if watched {
+			xbmcHost.SetEpisodeWatchedWithDate(kodiID, 1, 0, 0, a.WatchedAt)
+		} else {
+			xbmcHost.SetEpisodePlaycount(kodiID, 0)
+		}
+	}
+	return true
+}
+
+// restoreKodiPlaycount sets Kodi library item playcount back to the value before the change,
+// returns false if the item is not found
+func restoreKodiPlaycount(xbmcHost *xbmc.XBMCHost, a *PlanAction) bool {
+	kodiID := kodiLibraryID(a)
+	if kodiID == 0 {
+		return false
+	}
+
+	switch a.MediaType {
+	case SyncMovie:
+		xbmcHost.SetMoviePlaycount(kodiID, a.Playcount)
+	case SyncShow:
+		xbmcHost.SetShowWatched(kodiID, a.Playcount)
+	case SyncEpisode:
+		xbmcHost.SetEpisodePlaycount(kodiID, a.Playcount)
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+package library
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	"github.com/elgatito/elementum/cache"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/trakt"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+const (
+	// Progress difference in percents, below which Kodi and Trakt positions are the same
+	playbackTolerance = 1.0
+	// How old Kodi resume points are pushed to Trakt on the first sync
+	playbackFirstSyncWindow = 7 * 24 * time.Hour
+	// Maximum number of kept conflicts
+	playbackConflictsLimit = 50
+)
+
+// Sides of playback conflict
+const (
+	PlaybackKodi  = "kodi"
+	PlaybackTrakt = "trakt"
+)
+
+// PlaybackConflict is a resume point, that was different in Kodi and Trakt
+type PlaybackConflict struct {
+	Time          time.Time `json:"time"`
+	MediaType     string    `json:"media_type"`
+	Title         string    `json:"title"`
+	Season        int       `json:"season,omitempty"`
+	Episode       int       `json:"episode,omitempty"`
+	KodiProgress  float64   `json:"kodi_progress"`
+	KodiPlayedAt  time.Time `json:"kodi_played_at"`
+	TraktProgress float64   `json:"trakt_progress"`
+	TraktPausedAt time.Time `json:"trakt_paused_at"`
+	Winner        string    `json:"winner"`
+}
+
+// playbackState is a resume point of Kodi library item with matching Trakt playback entry
+type playbackState struct {
+	MediaType string
+	KodiID    int
+	TMDB      int
+	Title     string
+	Season    int
+	Episode   int
+
+	Watched bool
+	Resume  *uid.Resume
+
+	TraktID       int
+	TraktProgress float64
+	TraktPausedAt time.Time
+	// Runtime in seconds, as Trakt knows it
+	TraktRuntime int
+}
+
+var playbackConflictsMu sync.Mutex
+
+// kodiResumeHashes keeps hashes of Kodi resume points, that were reconciled last time, by item type
+var kodiResumeHashes = map[int]uint64{}
+
+// kodiLocalTime converts time, that Kodi stores in local timezone, to UTC
+func kodiLocalTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local).UTC()
+}
+
+// PlaybackConflicts returns recent resume point conflicts between Kodi and Trakt, newest first
+func PlaybackConflicts() []*PlaybackConflict {
+	playbackConflictsMu.Lock()
+	defer playbackConflictsMu.Unlock()
+
+	conflicts := []*PlaybackConflict{}
+	cache.NewDBStore().Get(cache.TraktPlaybackConflictsKey, &conflicts)
+	return conflicts
+}
+
+// ClearPlaybackConflicts removes the conflict log
+func ClearPlaybackConflicts() {
+	playbackConflictsMu.Lock()
+	defer playbackConflictsMu.Unlock()
+
+	cache.NewDBStore().Delete(cache.TraktPlaybackConflictsKey)
+}
+
+func addPlaybackConflict(c *PlaybackConflict) {
+	log.Infof("Playback conflict for %s '%s' S%02dE%02d: Kodi %.1f%% at %s, Trakt %.1f%% at %s, using %s",
+		c.MediaType, c.Title, c.Season, c.Episode, c.KodiProgress, c.KodiPlayedAt, c.TraktProgress, c.TraktPausedAt, c.Winner)
+
+	playbackConflictsMu.Lock()
+	defer playbackConflictsMu.Unlock()
+
+	cacheStore := cache.NewDBStore()
+	conflicts := []*PlaybackConflict{}
+	cacheStore.Get(cache.TraktPlaybackConflictsKey, &conflicts)
+
+	conflicts = append([]*PlaybackConflict{c}, conflicts...)
+	if len(conflicts) > playbackConflictsLimit {
+		conflicts = conflicts[:playbackConflictsLimit]
+	}
+	cacheStore.Set(cache.TraktPlaybackConflictsKey, conflicts, cache.TraktPlaybackConflictsExpire)
+}
+
+// reconcilePlayback keeps the newest of Kodi and Trakt resume points for the item,
+// kodiSince limits which Kodi resume points, unknown to Trakt, are pushed.
+func reconcilePlayback(xbmcHost *xbmc.XBMCHost, s *playbackState, kodiSince time.Time) {
+	kodiProgress := 0.0
+	kodiPlayedAt := time.Time{}
+	if s.Resume != nil {
+		kodiPlayedAt = s.Resume.LastPlayed
+		if s.Resume.Position > 0 && s.Resume.Total > 0 {
+			kodiProgress = s.Resume.Position / s.Resume.Total * 100
+		}
+	}
+
+	hasKodi := kodiProgress > 0
+	hasTrakt := s.TraktID != 0 && s.TraktProgress > 0
+
+	// Item was finished in Kodi after it was paused on Trakt, so Trakt entry is obsolete
+	if s.Watched && !hasKodi && hasTrakt && kodiPlayedAt.After(s.TraktPausedAt) {
+		if err := trakt.RemovePlayback(s.TraktID); err != nil {
+			log.Warningf("Could not remove Trakt playback for %s '%s': %s", s.MediaType, s.Title, err)
+		}
+		return
+	}
+
+	if hasKodi && hasTrakt && math.Abs(kodiProgress-s.TraktProgress) < playbackTolerance {
+		return
+	}
+
+	if hasTrakt && (!hasKodi || s.TraktPausedAt.After(kodiPlayedAt)) {
+		runtime := s.TraktRuntime
+		if runtime <= 0 && s.Resume != nil {
+			runtime = int(s.Resume.Total)
+		}
+		if runtime <= 0 {
+			return
+		}
+
+		if hasKodi {
+			addPlaybackConflict(s.conflict(kodiProgress, kodiPlayedAt, PlaybackTrakt))
+		}
+
+		position := int(float64(runtime) * s.TraktProgress / 100)
+		if s.MediaType == SyncMovie {
+			xbmcHost.SetMovieProgressWithDate(s.KodiID, position, runtime, s.TraktPausedAt.Local())
+		} else {
+			xbmcHost.SetEpisodeProgressWithDate(s.KodiID, position, runtime, s.TraktPausedAt.Local())
+		}
+		if s.Resume != nil {
+			s.Resume.Position = float64(position)
+			s.Resume.Total = float64(runtime)
+			s.Resume.LastPlayed = s.TraktPausedAt
+		}
+		return
+	}
+
+	if !hasKodi || (!hasTrakt && !kodiPlayedAt.After(kodiSince)) {
+		return
+	}
+
+	if hasTrakt {
+		addPlaybackConflict(s.conflict(kodiProgress, kodiPlayedAt, PlaybackKodi))
+	}
+
+	item := &trakt.RatedItem{MediaType: trakt.RatedMovie, Movie: s.TMDB}
+	if s.MediaType == SyncEpisode {
+		item = &trakt.RatedItem{MediaType: trakt.RatedEpisode, Show: s.TMDB, Season: s.Season, Episode: s.Episode}
+	}
+	if err := trakt.SetPlaybackProgress(item, kodiProgress); err != nil {
+		log.Warningf("Could not push playback progress for %s '%s' to Trakt: %s", s.MediaType, s.Title, err)
+	}
+}
+
+func (s *playbackState) conflict(kodiProgress float64, kodiPlayedAt time.Time, winner string) *PlaybackConflict {
+	return &PlaybackConflict{
+		Time:          time.Now().UTC(),
+		MediaType:     s.MediaType,
+		Title:         s.Title,
+		Season:        s.Season,
+		Episode:       s.Episode,
+		KodiProgress:  kodiProgress,
+		KodiPlayedAt:  kodiPlayedAt,
+		TraktProgress: s.TraktProgress,
+		TraktPausedAt: s.TraktPausedAt,
+		Winner:        winner,
+	}
+}
+
+// kodiPausedMovies returns library movies with resume points, that are not in the handled set
+func kodiPausedMovies(handled map[int]bool) []*playbackState {
+	l := uid.Get()
+	l.Mu.Movies.RLock()
+	defer l.Mu.Movies.RUnlock()
+
+	ret := []*playbackState{}
+	for _, m := range l.Movies {
+		if m == nil || m.UIDs == nil || m.UIDs.TMDB == 0 || m.Resume == nil || m.Resume.Position <= 0 || handled[m.UIDs.Kodi] {
+			continue
+		}
+
+		ret = append(ret, &playbackState{
+			MediaType: SyncMovie,
+			KodiID:    m.UIDs.Kodi,
+			TMDB:      m.UIDs.TMDB,
+			Title:     m.Title,
+			Watched:   m.IsWatched(),
+			Resume:    m.Resume,
+		})
+	}
+	return ret
+}
+
+// kodiResumeHash returns hash of Kodi resume points of movies, or of episodes
+func kodiResumeHash(itemType int) uint64 {
+	var states []*playbackState
+	if itemType == MovieType {
+		states = kodiPausedMovies(nil)
+	} else {
+		states = kodiPausedEpisodes(nil)
+	}
+
+	h := fnv.New64a()
+	for _, s := range states {
+		fmt.Fprintf(h, "%d:%v:%v:%d;", s.KodiID, s.Resume.Position, s.Resume.Total, s.Resume.LastPlayed.Unix())
+	}
+	return h.Sum64()
+}
+
+// isKodiResumeChanged returns true, if Kodi resume points changed since they were reconciled last time
+func isKodiResumeChanged(itemType int) bool {
+	hash, ok := kodiResumeHashes[itemType]
+	return !ok || hash != kodiResumeHash(itemType)
+}
+
+// kodiPausedEpisodes returns library episodes with resume points, that are not in the handled set
+func kodiPausedEpisodes(handled map[int]bool) []*playbackState {
+	l := uid.Get()
+	l.Mu.Shows.RLock()
+	defer l.Mu.Shows.RUnlock()
+
+	ret := []*playbackState{}
+	for _, s := range l.Shows {
+		if s == nil || s.UIDs == nil || s.UIDs.TMDB == 0 {
+			continue
+		}
+
+		for _, e := range s.Episodes {
+			if e == nil || e.Resume == nil || e.Resume.Position <= 0 || handled[e.UIDs.Kodi] {
+				continue
+			}
+
+			ret = append(ret, &playbackState{
+				MediaType: SyncEpisode,
+				KodiID:    e.UIDs.Kodi,
+				TMDB:      s.UIDs.TMDB,
+				Title:     s.Title,
+				Season:    e.Season,
+				Episode:   e.Episode,
+				Watched:   e.IsWatched(),
+				Resume:    e.Resume,
+			})
+		}
+	}
+	return ret
+}
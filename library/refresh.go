@@ -141,6 +141,7 @@ func RefreshMovies() error {
 			lm.Resume.Position = m.Resume.Position
 			lm.Resume.Total = m.Resume.Total
 		}
+		lm.Resume.LastPlayed = kodiLocalTime(m.LastPlayed.Time)
 
 		l.Movies = append(l.Movies, lm)
 	}
@@ -408,6 +409,7 @@ func RefreshEpisodes() error {
 			c.Episodes[len(c.Episodes)-1].Resume.Position = e.Resume.Position
 			c.Episodes[len(c.Episodes)-1].Resume.Total = e.Resume.Total
 		}
+		c.Episodes[len(c.Episodes)-1].Resume.LastPlayed = kodiLocalTime(e.LastPlayed.Time)
 	}
 	l.Mu.Shows.Unlock()
 
@@ -0,0 +1,526 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/sync"
+
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/library/uid"
+	"github.com/elgatito/elementum/tmdb"
+	"github.com/elgatito/elementum/trakt"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Sources of list subscriptions
+const (
+	SubscriptionTrakt          = "trakt"
+	SubscriptionTMDBList       = "tmdb_list"
+	SubscriptionTMDBCollection = "tmdb_collection"
+)
+
+// Removal policies of list subscriptions
+const (
+	// RemovalKeep leaves items, that left the list, in the library
+	RemovalKeep = "keep"
+	// RemovalRemove removes items from the library, unless another subscription has them
+	RemovalRemove = "remove"
+	// RemovalConfirm adds removals to the library sync plan, to be confirmed by user
+	RemovalConfirm = "confirm"
+)
+
+// subscriptionItem is a movie or a show of the subscribed list
+type subscriptionItem struct {
+	TMDB  int
+	Title string
+}
+
+var subscriptionsMu sync.Mutex
+
+// SubscriptionID returns ID of the list subscription
+func SubscriptionID(source, user, listID string) string {
+	if source == SubscriptionTrakt {
+		return fmt.Sprintf("%s:%s/%s", source, user, listID)
+	}
+	return fmt.Sprintf("%s:%s", source, listID)
+}
+
+// GetSubscriptions returns lists, attached to the library
+func GetSubscriptions() []database.ListSubscription {
+	return database.GetStorm().GetListSubscriptions()
+}
+
+// GetSubscription returns list, attached to the library, by its ID
+func GetSubscription(id string) *database.ListSubscription {
+	return database.GetStorm().GetListSubscription(id)
+}
+
+// Subscribe attaches the list to the library and runs the first sync
+func Subscribe(source, user, listID, name string, asSet bool, policy string) (*database.ListSubscription, error) {
+	switch source {
+	case SubscriptionTrakt, SubscriptionTMDBList, SubscriptionTMDBCollection:
+	default:
+		return nil, fmt.Errorf("Unknown list source: %s", source)
+	}
+	switch policy {
+	case RemovalKeep, RemovalRemove, RemovalConfirm:
+	case "":
+		policy = RemovalKeep
+	default:
+		return nil, fmt.Errorf("Unknown removal policy: %s", policy)
+	}
+	if listID == "" {
+		return nil, errors.New("List ID is required")
+	}
+	if source == SubscriptionTrakt && (user == "" || user == "id") {
+		user = config.Get().TraktUsername
+	}
+
+	id := SubscriptionID(source, user, listID)
+	s := database.GetStorm().GetListSubscription(id)
+	if s == nil {
+		s = &database.ListSubscription{
+			ID:        id,
+			Source:    source,
+			User:      user,
+			ListID:    listID,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+	if name != "" {
+		s.Name = name
+	}
+	s.AsSet = asSet
+	s.RemovalPolicy = policy
+
+	if err := database.GetStorm().SaveListSubscription(s); err != nil {
+		return s, err
+	}
+
+	log.Infof("Subscribed to list %s with removal policy '%s'", id, policy)
+	_, _, err := SyncSubscription(id)
+	return database.GetStorm().GetListSubscription(id), err
+}
+
+// Unsubscribe detaches the list from the library, its items are handled by the removal policy
+func Unsubscribe(id string) error {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	s := database.GetStorm().GetListSubscription(id)
+	if s == nil {
+		return fmt.Errorf("List subscription %s not found", id)
+	}
+
+	if err := database.GetStorm().DeleteListSubscription(id); err != nil {
+		return err
+	}
+
+	log.Infof("Unsubscribed from list %s", id)
+	removeSubscriptionItems(s, s.AddedMovies, s.AddedShows)
+	return nil
+}
+
+// SyncSubscriptions syncs all lists, attached to the library
+func SyncSubscriptions() error {
+	subscriptions := GetSubscriptions()
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	started := time.Now()
+	defer func() {
+		log.Debugf("List subscriptions sync finished in %s", time.Since(started))
+	}()
+
+	total := 0
+	for i := range subscriptions {
+		added, _, err := syncSubscription(&subscriptions[i])
+		if err != nil {
+			log.Warningf("Could not sync list %s: %s", subscriptions[i].ID, err)
+		}
+		total += added
+	}
+
+	scanAfterSubscription(total, "LOCALIZE[30263]")
+	return nil
+}
+
+// SyncSubscription syncs single list, attached to the library
+func SyncSubscription(id string) (added, removed int, err error) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	s := database.GetStorm().GetListSubscription(id)
+	if s == nil {
+		return 0, 0, fmt.Errorf("List subscription %s not found", id)
+	}
+
+	added, removed, err = syncSubscription(s)
+	scanAfterSubscription(added, s.Name)
+	return
+}
+
+func syncSubscription(s *database.ListSubscription) (added, removed int, err error) {
+	name, movies, shows, unresolved, err := fetchSubscription(s)
+	if err != nil {
+		s.LastError = err.Error()
+		database.GetStorm().SaveListSubscription(s)
+		return
+	}
+
+	previousMovies := s.Movies
+	previousShows := s.Shows
+
+	if s.Name == "" {
+		s.Name = name
+	}
+	s.Movies = subscriptionIDs(movies)
+	s.Shows = subscriptionIDs(shows)
+	// Empty list, or items that could not be resolved, do not mean that items left the list,
+	// so previous items are kept, to keep their tags and not to remove them from the library.
+	if unresolved > 0 || (len(movies) == 0 && len(shows) == 0) {
+		log.Warningf("List %s returned %d items with %d unresolved, keeping previous items", s.ID, len(movies)+len(shows), unresolved)
+		s.Movies = mergeIDs(s.Movies, previousMovies)
+		s.Shows = mergeIDs(s.Shows, previousShows)
+	}
+	s.LastSync = time.Now().UTC()
+	s.LastError = ""
+
+	// Subscription is saved before writing strm files, to have its tag in NFO files
+	if err = database.GetStorm().SaveListSubscription(s); err != nil {
+		return
+	}
+
+	planned := []*PlanAction{}
+	movieIDs := []int{}
+	for _, m := range movies {
+		inLibrary := IsInLibrary(m.TMDB, MovieType)
+		if (inLibrary && containsID(previousMovies, m.TMDB)) || wasRemoved(m.TMDB, MovieType) {
+			continue
+		} else if !inLibrary && uid.IsDuplicateMovie(strconv.Itoa(m.TMDB)) {
+			// Movie is in Kodi library, but not from Elementum
+			continue
+		}
+
+		if IsSyncPlanning() {
+			planned = append(planned, &PlanAction{Action: PlanWriteStrm, MediaType: SyncMovie, TMDB: m.TMDB, Title: m.Title, List: s.Name, Subscription: s.ID})
+			continue
+		}
+
+		// Movies, that are already in the library, are re-written to get the tag into NFO
+		if _, err := writeMovieStrm(strconv.Itoa(m.TMDB), false); err != nil {
+			continue
+		}
+		if !inLibrary {
+			movieIDs = append(movieIDs, m.TMDB)
+			s.AddedMovies = append(s.AddedMovies, m.TMDB)
+		}
+	}
+
+	showIDs := []int{}
+	for _, sh := range shows {
+		inLibrary := IsInLibrary(sh.TMDB, ShowType)
+		if (inLibrary && containsID(previousShows, sh.TMDB)) || wasRemoved(sh.TMDB, ShowType) {
+			continue
+		} else if !inLibrary && uid.IsDuplicateShow(strconv.Itoa(sh.TMDB)) {
+			continue
+		}
+
+		if IsSyncPlanning() {
+			planned = append(planned, &PlanAction{Action: PlanWriteStrm, MediaType: SyncShow, TMDB: sh.TMDB, Title: sh.Title, List: s.Name, Subscription: s.ID})
+			continue
+		}
+
+		if _, err := writeShowStrm(sh.TMDB, false, false); err != nil {
+			continue
+		}
+		if !inLibrary {
+			showIDs = append(showIDs, sh.TMDB)
+			s.AddedShows = append(s.AddedShows, sh.TMDB)
+		}
+	}
+	planActions(planned...)
+
+	if err = updateBatchDBItem(movieIDs, StateActive, MovieType, 0); err != nil {
+		return
+	}
+	if err = updateBatchDBItem(showIDs, StateActive, ShowType, 0); err != nil {
+		return
+	}
+
+	added = len(movieIDs) + len(showIDs)
+	removed = removeSubscriptionItems(s, missingIDs(previousMovies, s.Movies), missingIDs(previousShows, s.Shows))
+	if err = database.GetStorm().SaveListSubscription(s); err != nil {
+		return
+	}
+
+	log.Infof("List %s synced: %d movies, %d shows, %d added, %d removed", s.ID, len(s.Movies), len(s.Shows), added, removed)
+	return
+}
+
+// fetchSubscription returns list name, its movies and shows, and number of items not found on TMDB
+func fetchSubscription(s *database.ListSubscription) (name string, movies, shows []*subscriptionItem, unresolved int, err error) {
+	language := config.Get().Language
+
+	switch s.Source {
+	case SubscriptionTrakt:
+		if list, err := trakt.GetList(s.User, s.ListID); err == nil && list != nil {
+			name = list.Name
+		}
+
+		var items []*trakt.ListItem
+		if items, err = trakt.ListItems(s.User, s.ListID); err != nil {
+			return
+		}
+		for _, i := range items {
+			if i.Movie != nil {
+				item := traktImportItem(0, SyncMovie, &i.Movie.Object)
+				if resolveImportItem(item) == "" {
+					movies = append(movies, &subscriptionItem{TMDB: item.TMDB, Title: item.Title})
+				} else {
+					unresolved++
+				}
+			} else if i.Show != nil {
+				item := traktImportItem(0, SyncShow, &i.Show.Object)
+				if resolveImportItem(item) == "" {
+					shows = append(shows, &subscriptionItem{TMDB: item.TMDB, Title: item.Title})
+				} else {
+					unresolved++
+				}
+			}
+		}
+	case SubscriptionTMDBList:
+		list := tmdb.GetUserList(s.ListID, language)
+		if list == nil {
+			return "", nil, nil, 0, fmt.Errorf("Could not get TMDB list %s", s.ListID)
+		}
+
+		name = list.Name
+		for _, i := range list.Items {
+			if i == nil {
+				continue
+			} else if i.MediaType == "movie" {
+				movies = append(movies, &subscriptionItem{TMDB: i.ID, Title: i.Title})
+			} else if i.MediaType == "tv" {
+				shows = append(shows, &subscriptionItem{TMDB: i.ID, Title: i.Name})
+			}
+		}
+	case SubscriptionTMDBCollection:
+		collectionID, _ := strconv.Atoi(s.ListID)
+		collection := tmdb.GetCollection(collectionID, language)
+		if collection == nil {
+			return "", nil, nil, 0, fmt.Errorf("Could not get TMDB collection %s", s.ListID)
+		}
+
+		name = collection.Name
+		// Unreleased parts are added, when they are out
+		for _, p := range releasedCollectionParts(collection) {
+			movies = append(movies, &subscriptionItem{TMDB: p.ID, Title: p.Title})
+		}
+	}
+
+	return
+}
+
+// removeSubscriptionItems handles items, that left the list, with the removal policy of the subscription.
+// Only items, added by the list, are removed. If another list has the item, it is kept as added by that list.
+func removeSubscriptionItems(s *database.ListSubscription, movies, shows []int) int {
+	if s.RemovalPolicy == RemovalKeep || s.RemovalPolicy == "" || (len(movies) == 0 && len(shows) == 0) {
+		return 0
+	}
+
+	xbmcHost, _ := xbmc.GetLocalXBMCHost()
+	toPlan := s.RemovalPolicy == RemovalConfirm || IsSyncPlanning()
+
+	removed := 0
+	planned := []*PlanAction{}
+	for _, id := range movies {
+		if !containsID(s.AddedMovies, id) {
+			continue
+		}
+		s.AddedMovies = withoutID(s.AddedMovies, id)
+		if !IsInLibrary(id, MovieType) {
+			continue
+		} else if other := subscriptionWith(MovieType, id); other != nil {
+			other.AddedMovies = append(other.AddedMovies, id)
+			database.GetStorm().SaveListSubscription(other)
+			continue
+		}
+
+		removed++
+		if toPlan {
+			title := strconv.Itoa(id)
+			if m, _ := uid.GetMovieByTMDB(id); m != nil {
+				title = m.Title
+			}
+			planned = append(planned, &PlanAction{Action: PlanRemove, MediaType: SyncMovie, TMDB: id, Title: title, List: s.Name, Paths: pathsList(getMoviePathsByTMDB(id))})
+		} else if xbmcHost != nil {
+			removeMovieFromKodi(xbmcHost, id)
+		}
+	}
+	for _, id := range shows {
+		if !containsID(s.AddedShows, id) {
+			continue
+		}
+		s.AddedShows = withoutID(s.AddedShows, id)
+		if !IsInLibrary(id, ShowType) {
+			continue
+		} else if other := subscriptionWith(ShowType, id); other != nil {
+			other.AddedShows = append(other.AddedShows, id)
+			database.GetStorm().SaveListSubscription(other)
+			continue
+		}
+
+		removed++
+		if toPlan {
+			title := strconv.Itoa(id)
+			if sh, _ := uid.FindShowByTMDB(id); sh != nil {
+				title = sh.Title
+			}
+			planned = append(planned, &PlanAction{Action: PlanRemove, MediaType: SyncShow, TMDB: id, Title: title, List: s.Name, Paths: pathsList(getShowPathsByTMDB(id))})
+		} else if xbmcHost != nil {
+			removeShowFromKodi(xbmcHost, id)
+		}
+	}
+	planActions(planned...)
+
+	return removed
+}
+
+// scanAfterSubscription runs Kodi library scan, if subscriptions added new items
+func scanAfterSubscription(added int, label string) {
+	if added == 0 {
+		return
+	}
+
+	xbmcHost, _ := xbmc.GetLocalXBMCHost()
+	if xbmcHost == nil {
+		return
+	}
+	if config.Get().LibraryUpdate == 0 || (config.Get().LibraryUpdate == 1 && xbmcHost.DialogConfirmFocused("Elementum", fmt.Sprintf("LOCALIZE[30277];;%s", label))) {
+		xbmcHost.VideoLibraryScan()
+	}
+}
+
+// subscriptionWith returns subscribed list, that has the item, or nil
+func subscriptionWith(mediaType int, tmdbID int) *database.ListSubscription {
+	for _, s := range GetSubscriptions() {
+		if (mediaType == MovieType && containsID(s.Movies, tmdbID)) || (mediaType == ShowType && containsID(s.Shows, tmdbID)) {
+			return &s
+		}
+	}
+	return nil
+}
+
+// markSubscriptionsAdded keeps items of applied plan actions as added by their subscriptions
+func markSubscriptionsAdded(actions []*PlanAction) {
+	if len(actions) == 0 {
+		return
+	}
+
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+
+	for _, a := range actions {
+		s := database.GetStorm().GetListSubscription(a.Subscription)
+		if s == nil {
+			continue
+		}
+
+		if a.MediaType == SyncMovie && !containsID(s.AddedMovies, a.TMDB) {
+			s.AddedMovies = append(s.AddedMovies, a.TMDB)
+		} else if a.MediaType == SyncShow && !containsID(s.AddedShows, a.TMDB) {
+			s.AddedShows = append(s.AddedShows, a.TMDB)
+		}
+		database.GetStorm().SaveListSubscription(s)
+	}
+}
+
+// subscriptionNFO returns NFO tags of subscribed lists, that have the item.
+// Lists are written as tags, movie lists could be written as a set, Kodi allows only one set per movie,
+// so withSet is false, when the movie already has a set of its TMDB collection.
+func subscriptionNFO(mediaType int, tmdbID int, withSet bool) string {
+	var b strings.Builder
+	set := ""
+	for _, s := range GetSubscriptions() {
+		if (mediaType == MovieType && !containsID(s.Movies, tmdbID)) || (mediaType == ShowType && !containsID(s.Shows, tmdbID)) {
+			continue
+		}
+
+		name := s.Name
+		if name == "" {
+			name = s.ListID
+		}
+		if withSet && s.AsSet && mediaType == MovieType && set == "" {
+			set = name
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\t<tag>%s</tag>\n", html.EscapeString(name)))
+	}
+
+	if set != "" {
+		b.WriteString(fmt.Sprintf("\t<set>\n\t\t<name>%s</name>\n\t</set>\n", html.EscapeString(set)))
+	}
+	return b.String()
+}
+
+func subscriptionIDs(items []*subscriptionItem) []int {
+	ret := make([]int, 0, len(items))
+	for _, i := range items {
+		if !containsID(ret, i.TMDB) {
+			ret = append(ret, i.TMDB)
+		}
+	}
+	return ret
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutID returns copy of IDs without the ID
+func withoutID(ids []int, id int) []int {
+	ret := make([]int, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// mergeIDs returns IDs with IDs of more, that are not in IDs yet
+func mergeIDs(ids, more []int) []int {
+	for _, id := range more {
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// missingIDs returns IDs of previous, that are not in current
+func missingIDs(previous, current []int) []int {
+	ret := []int{}
+	for _, id := range previous {
+		if !containsID(current, id) {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
@@ -75,6 +75,17 @@ func RefreshTrakt() error {
 	isFirstRun := !IsTraktInitialized || isKodiUpdated
 	if !lastActivities.All.After(previousActivities.All) && !isFirstRun {
 		log.Debugf("Skipping Trakt sync due to stale activities")
+
+		// Kodi resume points could change without Trakt activity, so they are reconciled, when they change.
+		// Trakt playback is taken from the cache, it is requested again only when PausedAt activity changes.
+		if config.Get().TraktSyncPlaybackProgress {
+			if isKodiResumeChanged(MovieType) {
+				RefreshTraktPaused(xbmcHost, MovieType, false)
+			}
+			if isKodiResumeChanged(EpisodeType) {
+				RefreshTraktPaused(xbmcHost, EpisodeType, false)
+			}
+		}
 		return nil
 	}
 
@@ -96,6 +107,19 @@ func RefreshTrakt() error {
 		isKodiAdded = false
 	}
 
+	// Changes are collected into the plan, and user is notified, if the plan got new changes,
+	// otherwise applied changes are kept as the journal of this run, to be able to undo them.
+	BeginSyncRun()
+	if IsSyncPlanning() {
+		plannedBefore := len(GetSyncPlan().Actions)
+		defer func() {
+			if planned := len(GetSyncPlan().Actions); planned > plannedBefore {
+				log.Infof("Library sync plan has %d changes waiting for confirmation", planned)
+				xbmcHost.Notify("Elementum", fmt.Sprintf("LOCALIZE[30705];;%d", planned), config.AddonIcon())
+			}
+		}()
+	}
+
 	// Movies
 	if isFirstRun || isKodiAdded || lastActivities.Movies.WatchedAt.After(previousActivities.Movies.WatchedAt) {
 		if err := RefreshTraktWatched(xbmcHost, MovieType, lastActivities.Movies.WatchedAt.After(previousActivities.Movies.WatchedAt)); err != nil {
@@ -112,7 +136,7 @@ func RefreshTrakt() error {
 			isErrored = true
 		}
 	}
-	if isFirstRun || isKodiAdded || lastActivities.Movies.PausedAt.After(previousActivities.Movies.PausedAt) {
+	if isFirstRun || isKodiAdded || config.Get().TraktSyncPlaybackProgress || lastActivities.Movies.PausedAt.After(previousActivities.Movies.PausedAt) {
 		if err := RefreshTraktPaused(xbmcHost, MovieType, lastActivities.Movies.PausedAt.After(previousActivities.Movies.PausedAt)); err != nil {
 			isErrored = true
 		}
@@ -139,7 +163,7 @@ func RefreshTrakt() error {
 			isErrored = true
 		}
 	}
-	if isFirstRun || isKodiAdded || lastActivities.Episodes.PausedAt.After(previousActivities.Episodes.PausedAt) {
+	if isFirstRun || isKodiAdded || config.Get().TraktSyncPlaybackProgress || lastActivities.Episodes.PausedAt.After(previousActivities.Episodes.PausedAt) {
 		if err := RefreshTraktPaused(xbmcHost, EpisodeType, lastActivities.Episodes.PausedAt.After(previousActivities.Episodes.PausedAt)); err != nil {
 			isErrored = true
 		}
@@ -306,7 +330,7 @@ func refreshTraktMoviesWatched(xbmcHost *xbmc.XBMCHost, isRefreshNeeded bool) er
 	l.Mu.Movies.Unlock()
 
 	if len(syncUnwatchMovies) > 0 {
-		if _, err := trakt.SetMultipleWatched(syncUnwatchMovies); err == nil {
+		if _, err := trakt.SetMultipleWatched(syncUnwatchMovies); err == nil || err == trakt.ErrQueued {
 			// Set cached entry to avoid running same item again
 			for _, i := range syncUnwatchMovies {
 				delete(lastPlaycount, i.KodiKey)
@@ -315,7 +339,7 @@ func refreshTraktMoviesWatched(xbmcHost *xbmc.XBMCHost, isRefreshNeeded bool) er
 		}
 	}
 	if len(syncWatchMovies) > 0 {
-		if _, err := trakt.SetMultipleWatched(syncWatchMovies); err == nil {
+		if _, err := trakt.SetMultipleWatched(syncWatchMovies); err == nil || err == trakt.ErrQueued {
 			// Set cached entry to avoid running same item again
 			for _, i := range syncWatchMovies {
 				syncPlaycount[i.KodiKey] = i.Watched
@@ -472,7 +496,7 @@ func refreshTraktShowsWatched(xbmcHost *xbmc.XBMCHost, isRefreshNeeded bool) err
 	l.Mu.Shows.Unlock()
 
 	if len(syncUnwatchShows) > 0 {
-		if _, err := trakt.SetMultipleWatched(syncUnwatchShows); err == nil {
+		if _, err := trakt.SetMultipleWatched(syncUnwatchShows); err == nil || err == trakt.ErrQueued {
 			// Set cached entry to avoid running same item again
 			for _, i := range syncUnwatchShows {
 				delete(lastPlaycount, i.KodiKey)
@@ -481,7 +505,7 @@ func refreshTraktShowsWatched(xbmcHost *xbmc.XBMCHost, isRefreshNeeded bool) err
 		}
 	}
 	if len(syncWatchShows) > 0 {
-		if _, err := trakt.SetMultipleWatched(syncWatchShows); err == nil {
+		if _, err := trakt.SetMultipleWatched(syncWatchShows); err == nil || err == trakt.ErrQueued {
 			// Set cached entry to avoid running same item again
 			for _, i := range syncWatchShows {
 				syncPlaycount[i.KodiKey] = i.Watched
@@ -599,14 +623,28 @@ func updateMovieWatched(xbmcHost *xbmc.XBMCHost, m *trakt.WatchedMovie, watched
 			return
 		}
 
+		action := &PlanAction{Action: PlanWatched, MediaType: SyncMovie, TMDB: r.UIDs.TMDB, Title: r.Title, Playcount: r.UIDs.Playcount, WatchedAt: m.LastWatchedAt}
+		if IsSyncPlanning() {
+			planActions(action)
+			return
+		}
+
 		r.UIDs.Playcount++
 		xbmcHost.SetMovieWatchedWithDate(r.UIDs.Kodi, r.UIDs.Playcount, 0, 0, m.LastWatchedAt)
+		journalActions(action)
 		// TODO: There should be a check for allowing resume state, otherwise we always reset it for already searched items
 		// } else if watched && r.IsWatched() && r.Resume != nil && r.Resume.Position > 0 {
 		// 	xbmc.SetMovieWatchedWithDate(r.UIDs.Kodi, 1, 0, 0, m.LastWatchedAt)
 	} else if !watched && r.IsWatched() {
+		action := &PlanAction{Action: PlanUnwatched, MediaType: SyncMovie, TMDB: r.UIDs.TMDB, Title: r.Title, Playcount: r.UIDs.Playcount}
+		if IsSyncPlanning() {
+			planActions(action)
+			return
+		}
+
 		r.UIDs.Playcount = 0
 		xbmcHost.SetMoviePlaycount(r.UIDs.Kodi, 0)
+		journalActions(action)
 	}
 }
 
@@ -616,9 +654,22 @@ func updateShowWatched(xbmcHost *xbmc.XBMCHost, s *trakt.WatchedShow, watched bo
 		return
 	}
 
+	planned := []*PlanAction{}
+	applied := []*PlanAction{}
+	defer func() {
+		planActions(planned...)
+		journalActions(applied...)
+	}()
+
 	if watched && s.Watched && !r.IsWatched() {
-		r.UIDs.Playcount = 1
-		xbmcHost.SetShowWatchedWithDate(r.UIDs.Kodi, 1, s.LastWatchedAt)
+		action := &PlanAction{Action: PlanWatched, MediaType: SyncShow, TMDB: r.UIDs.TMDB, Title: r.Title, Playcount: r.UIDs.Playcount, WatchedAt: s.LastWatchedAt}
+		if IsSyncPlanning() {
+			planned = append(planned, action)
+		} else {
+			r.UIDs.Playcount = 1
+			xbmcHost.SetShowWatchedWithDate(r.UIDs.Kodi, 1, s.LastWatchedAt)
+			applied = append(applied, action)
+		}
 	}
 
 	for _, season := range s.Seasons {
@@ -632,14 +683,28 @@ func updateShowWatched(xbmcHost *xbmc.XBMCHost, s *trakt.WatchedShow, watched bo
 				// Resetting Resume state to avoid having old resume states,
 				// when item is watched on another device
 				if watched && !e.IsWatched() {
+					action := &PlanAction{Action: PlanWatched, MediaType: SyncEpisode, TMDB: r.UIDs.TMDB, Title: r.Title, Season: e.Season, Episode: e.Episode, Playcount: e.UIDs.Playcount, WatchedAt: episode.LastWatchedAt}
+					if IsSyncPlanning() {
+						planned = append(planned, action)
+						continue
+					}
+
 					e.UIDs.Playcount = 1
 					xbmcHost.SetEpisodeWatchedWithDate(e.UIDs.Kodi, 1, 0, 0, episode.LastWatchedAt)
+					applied = append(applied, action)
 					// TODO: There should be a check for allowing resume state, otherwise we always reset it for already searched items
 					// } else if watched && e.IsWatched() && e.Resume != nil && e.Resume.Position > 0 {
 					//   xbmc.SetEpisodeWatchedWithDate(e.UIDs.Kodi, 1, 0, 0, episode.LastWatchedAt)
 				} else if !watched && e.IsWatched() {
+					action := &PlanAction{Action: PlanUnwatched, MediaType: SyncEpisode, TMDB: r.UIDs.TMDB, Title: r.Title, Season: e.Season, Episode: e.Episode, Playcount: e.UIDs.Playcount}
+					if IsSyncPlanning() {
+						planned = append(planned, action)
+						continue
+					}
+
 					e.UIDs.Playcount = 0
 					xbmcHost.SetEpisodePlaycount(e.UIDs.Kodi, 0)
+					applied = append(applied, action)
 				}
 			}
 		}
@@ -688,20 +753,20 @@ func RefreshTraktWatchlisted(xbmcHost *xbmc.XBMCHost, itemType int, isRefreshNee
 	return nil
 }
 
-// RefreshTraktPaused ...
+// RefreshTraktPaused reconciles Kodi resume points with Trakt playback progress,
+// the newest position wins, and Kodi positions, newer than last sync, are pushed to Trakt.
 func RefreshTraktPaused(xbmcHost *xbmc.XBMCHost, itemType int, isRefreshNeeded bool) error {
 	if config.Get().TraktToken == "" || !config.Get().TraktSyncPlaybackProgress {
 		return nil
 	}
 
 	cacheStore := cache.NewDBStore()
-	lastUpdates := map[int]time.Time{}
+	cacheKey := fmt.Sprintf(cache.TraktPlaybackLastSyncKey, itemType)
 
-	cacheKey := fmt.Sprintf(cache.TraktPausedLastUpdatesKey, itemType)
-	cacheStore.Get(cacheKey, &lastUpdates)
-	defer func() {
-		cacheStore.Set(cacheKey, &lastUpdates, cache.TraktPausedLastUpdatesExpire)
-	}()
+	var lastSync time.Time
+	if err := cacheStore.Get(cacheKey, &lastSync); err != nil || lastSync.IsZero() {
+		lastSync = time.Now().Add(-playbackFirstSyncWindow)
+	}
 
 	started := time.Now()
 	defer func() {
@@ -709,6 +774,13 @@ func RefreshTraktPaused(xbmcHost *xbmc.XBMCHost, itemType int, isRefreshNeeded b
 	}()
 
 	l := uid.Get()
+	handled := map[int]bool{}
+
+	resumeType := EpisodeType
+	if itemType == MovieType {
+		resumeType = MovieType
+	}
+	resumeHash := kodiResumeHash(resumeType)
 
 	if itemType == MovieType {
 		l.Running.IsMovies = true
@@ -723,20 +795,32 @@ func RefreshTraktPaused(xbmcHost *xbmc.XBMCHost, itemType int, isRefreshNeeded b
 		}
 
 		for _, m := range movies {
-			if m.Movie.IDs.TMDB == 0 || int(m.Progress) <= 0 || m.Movie.Runtime <= 0 {
+			if m.Movie == nil || m.Movie.IDs == nil || m.Movie.IDs.TMDB == 0 {
 				continue
 			}
 
-			if lm, err := uid.GetMovieByTMDB(m.Movie.IDs.TMDB); err == nil {
-				if t, ok := lastUpdates[m.Movie.IDs.Trakt]; ok && !t.Before(m.PausedAt) {
-					continue
-				}
+			lm, err := uid.GetMovieByTMDB(m.Movie.IDs.TMDB)
+			if err != nil || lm == nil {
+				continue
+			}
 
-				lastUpdates[m.Movie.IDs.Trakt] = m.PausedAt
-				runtime := m.Movie.Runtime * 60
+			handled[lm.UIDs.Kodi] = true
+			reconcilePlayback(xbmcHost, &playbackState{
+				MediaType:     SyncMovie,
+				KodiID:        lm.UIDs.Kodi,
+				TMDB:          m.Movie.IDs.TMDB,
+				Title:         lm.Title,
+				Watched:       lm.IsWatched(),
+				Resume:        lm.Resume,
+				TraktID:       m.ID,
+				TraktProgress: m.Progress,
+				TraktPausedAt: m.PausedAt,
+				TraktRuntime:  m.Movie.Runtime * 60,
+			}, lastSync)
+		}
 
-				xbmcHost.SetMovieProgressWithDate(lm.UIDs.Kodi, runtime/100*int(m.Progress), runtime, m.PausedAt)
-			}
+		for _, s := range kodiPausedMovies(handled) {
+			reconcilePlayback(xbmcHost, s, lastSync)
 		}
 	} else if itemType == EpisodeType || itemType == SeasonType || itemType == ShowType {
 		l.Running.IsShows = true
@@ -751,26 +835,43 @@ func RefreshTraktPaused(xbmcHost *xbmc.XBMCHost, itemType int, isRefreshNeeded b
 		}
 
 		for _, s := range shows {
-			if s.Show.IDs.TMDB == 0 || int(s.Progress) <= 0 || s.Episode.Runtime <= 0 {
+			if s.Show == nil || s.Show.IDs == nil || s.Show.IDs.TMDB == 0 || s.Episode == nil {
 				continue
 			}
 
-			if ls, err := uid.GetShowByTMDB(s.Show.IDs.TMDB); err == nil {
-				e := ls.GetEpisode(s.Episode.Season, s.Episode.Number)
-				if e == nil {
-					continue
-				} else if t, ok := lastUpdates[s.Episode.IDs.Trakt]; ok && !t.Before(s.PausedAt) {
-					continue
-				}
+			ls, err := uid.GetShowByTMDB(s.Show.IDs.TMDB)
+			if err != nil || ls == nil {
+				continue
+			}
+			e := ls.GetEpisode(s.Episode.Season, s.Episode.Number)
+			if e == nil {
+				continue
+			}
 
-				lastUpdates[s.Episode.IDs.Trakt] = s.PausedAt
-				runtime := s.Episode.Runtime * 60
+			handled[e.UIDs.Kodi] = true
+			reconcilePlayback(xbmcHost, &playbackState{
+				MediaType:     SyncEpisode,
+				KodiID:        e.UIDs.Kodi,
+				TMDB:          s.Show.IDs.TMDB,
+				Title:         ls.Title,
+				Season:        e.Season,
+				Episode:       e.Episode,
+				Watched:       e.IsWatched(),
+				Resume:        e.Resume,
+				TraktID:       s.ID,
+				TraktProgress: s.Progress,
+				TraktPausedAt: s.PausedAt,
+				TraktRuntime:  s.Episode.Runtime * 60,
+			}, lastSync)
+		}
 
-				xbmcHost.SetEpisodeProgressWithDate(e.UIDs.Kodi, runtime/100*int(s.Progress), runtime, s.PausedAt)
-			}
+		for _, s := range kodiPausedEpisodes(handled) {
+			reconcilePlayback(xbmcHost, s, lastSync)
 		}
 	}
 
+	cacheStore.Set(cacheKey, started, cache.TraktPlaybackLastSyncExpire)
+	kodiResumeHashes[resumeType] = resumeHash
 	return nil
 }
 
@@ -821,23 +922,32 @@ func syncMoviesRemovedBack(movies []*trakt.Movies) error {
 		return errors.New("No Kodi instance found")
 	}
 
+	planned := []*PlanAction{}
+	applied := []*PlanAction{}
 	for _, m := range movies {
 		if m == nil || m.Movie == nil || m.Movie.IDs == nil {
 			continue
 		}
 
 		if kodiMovie, err := uid.GetMovieByTMDB(m.Movie.IDs.TMDB); err == nil && kodiMovie != nil {
-			movie, paths, err := RemoveMovie(m.Movie.IDs.TMDB, true)
-			if err != nil {
-				log.Warningf("Could not remove movie from Kodi library: %s", err)
-			} else if movie != nil && paths != nil {
-				for _, path := range paths {
-					xbmcHost.VideoLibraryCleanDirectory(path, "movies", false)
-				}
-				xbmcHost.VideoLibraryRemoveMovie(kodiMovie.XbmcUIDs.Kodi)
+			action := &PlanAction{
+				Action:    PlanRemove,
+				MediaType: SyncMovie,
+				TMDB:      m.Movie.IDs.TMDB,
+				Title:     kodiMovie.Title,
+				Paths:     pathsList(getMoviePathsByTMDB(m.Movie.IDs.TMDB)),
+			}
+			if IsSyncPlanning() {
+				planned = append(planned, action)
+				continue
 			}
+
+			removeMovieFromKodi(xbmcHost, m.Movie.IDs.TMDB)
+			applied = append(applied, action)
 		}
 	}
+	planActions(planned...)
+	journalActions(applied...)
 
 	return nil
 }
@@ -848,23 +958,32 @@ func syncShowsRemovedBack(shows []*trakt.Shows) error {
 		return errors.New("No Kodi instance found")
 	}
 
+	planned := []*PlanAction{}
+	applied := []*PlanAction{}
 	for _, s := range shows {
 		if s == nil || s.Show == nil || s.Show.IDs == nil {
 			continue
 		}
 
 		if kodiShow, err := uid.FindShowByTMDB(s.Show.IDs.TMDB); err == nil && kodiShow != nil {
-			show, paths, err := RemoveShow(strconv.Itoa(s.Show.IDs.TMDB), true)
-			if err != nil {
-				log.Warningf("Could not remove show from Kodi library: %s", err)
-			} else if show != nil && paths != nil {
-				for _, path := range paths {
-					xbmcHost.VideoLibraryCleanDirectory(path, "tvshows", false)
-				}
-				xbmcHost.VideoLibraryRemoveTVShow(kodiShow.XbmcUIDs.Kodi)
+			action := &PlanAction{
+				Action:    PlanRemove,
+				MediaType: SyncShow,
+				TMDB:      s.Show.IDs.TMDB,
+				Title:     kodiShow.Title,
+				Paths:     pathsList(getShowPathsByTMDB(s.Show.IDs.TMDB)),
 			}
+			if IsSyncPlanning() {
+				planned = append(planned, action)
+				continue
+			}
+
+			removeShowFromKodi(xbmcHost, s.Show.IDs.TMDB)
+			applied = append(applied, action)
 		}
 	}
+	planActions(planned...)
+	journalActions(applied...)
 
 	return nil
 }
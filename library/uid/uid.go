@@ -93,6 +93,9 @@ type Episode struct {
 type Resume struct {
 	Position float64 `json:"position"`
 	Total    float64 `json:"total"`
+
+	// LastPlayed is a time, when Kodi item was played last time, in UTC
+	LastPlayed time.Time `json:"-"`
 }
 
 // Library represents library
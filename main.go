@@ -298,6 +298,7 @@ func main() {
 
 	go library.Init()
 	go trakt.TokenRefreshHandler()
+	go trakt.OutboxHandler()
 	go db.MaintenanceRefreshHandler()
 	go cacheDB.MaintenanceRefreshHandler()
 	go scrape.Start()
@@ -0,0 +1,402 @@
+package simkl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmcvetta/napping"
+	"github.com/op/go-logging"
+
+	"github.com/elgatito/elementum/broadcast"
+	"github.com/elgatito/elementum/config"
+	"github.com/elgatito/elementum/util/ident"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+const (
+	// APIURL ...
+	APIURL = "https://api.simkl.com"
+
+	pinAttempts = 60
+)
+
+var log = logging.MustGetLogger("simkl")
+
+var (
+	// ErrNotAuthorized is returned when there is no Simkl token
+	ErrNotAuthorized = errors.New("Simkl is not authorized")
+	// ErrNoClientID is returned when Simkl application ID is not set in settings
+	ErrNoClientID = errors.New("Simkl client ID is not set")
+)
+
+// ID is an identifier, that Simkl returns either as a number or as a string
+type ID int
+
+// UnmarshalJSON ...
+func (id *ID) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*id = 0
+		return nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		*id = 0
+		return nil
+	}
+	*id = ID(i)
+	return nil
+}
+
+// IDs ...
+type IDs struct {
+	Simkl   ID     `json:"simkl,omitempty"`
+	IMDB    string `json:"imdb,omitempty"`
+	TMDB    ID     `json:"tmdb,omitempty"`
+	TVDB    ID     `json:"tvdb,omitempty"`
+	MAL     ID     `json:"mal,omitempty"`
+	AniList ID     `json:"anilist,omitempty"`
+}
+
+// Object is a movie or a show
+type Object struct {
+	Title string `json:"title,omitempty"`
+	Year  int    `json:"year,omitempty"`
+	IDs   *IDs   `json:"ids"`
+}
+
+// Episode ...
+type Episode struct {
+	Season    int        `json:"season,omitempty"`
+	Number    int        `json:"number"`
+	WatchedAt *time.Time `json:"watched_at,omitempty"`
+}
+
+// Season ...
+type Season struct {
+	Number   int        `json:"number"`
+	Episodes []*Episode `json:"episodes,omitempty"`
+}
+
+// Item is an entry of user's lists
+type Item struct {
+	Status        string    `json:"status"`
+	LastWatchedAt time.Time `json:"last_watched_at"`
+	Movie         *Object   `json:"movie,omitempty"`
+	Show          *Object   `json:"show,omitempty"`
+	Seasons       []*Season `json:"seasons,omitempty"`
+}
+
+// AllItems is a response for user's lists
+type AllItems struct {
+	Movies []*Item `json:"movies"`
+	Shows  []*Item `json:"shows"`
+	Anime  []*Item `json:"anime"`
+}
+
+// Playback is a paused item
+type Playback struct {
+	Progress float64   `json:"progress"`
+	PausedAt time.Time `json:"paused_at"`
+	Type     string    `json:"type"`
+	Movie    *Object   `json:"movie,omitempty"`
+	Show     *Object   `json:"show,omitempty"`
+	Episode  *Episode  `json:"episode,omitempty"`
+}
+
+// HistoryItem is a movie or a show with episodes, used to change watched history
+type HistoryItem struct {
+	Object
+	WatchedAt *time.Time `json:"watched_at,omitempty"`
+	Seasons   []*Season  `json:"seasons,omitempty"`
+}
+
+// History is a request to add or remove items from watched history
+type History struct {
+	Movies []*HistoryItem `json:"movies,omitempty"`
+	Shows  []*HistoryItem `json:"shows,omitempty"`
+}
+
+// Code is a PIN code for device authorization
+type Code struct {
+	Result          string `json:"result"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// Token is a result of PIN code polling
+type Token struct {
+	Result      string `json:"result"`
+	Message     string `json:"message"`
+	AccessToken string `json:"access_token"`
+}
+
+// UserSettings ...
+type UserSettings struct {
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+func header(withAuth bool) *http.Header {
+	h := http.Header{
+		"Content-type":  []string{"application/json"},
+		"simkl-api-key": []string{config.Get().SimklClientID},
+		"User-Agent":    []string{ident.DefaultUserAgent()},
+	}
+	if withAuth {
+		h.Set("Authorization", fmt.Sprintf("Bearer %s", config.Get().SimklToken))
+	}
+	return &h
+}
+
+// Authorized checks that Simkl token and client ID are set
+func Authorized() error {
+	if config.Get().SimklClientID == "" {
+		return ErrNoClientID
+	} else if config.Get().SimklToken == "" {
+		return ErrNotAuthorized
+	}
+	return nil
+}
+
+// Get ...
+func Get(endPoint string, params url.Values, withAuth bool) (*napping.Response, error) {
+	req := napping.Request{
+		Url:    fmt.Sprintf("%s/%s", APIURL, endPoint),
+		Method: "GET",
+		Params: &params,
+		Header: header(withAuth),
+	}
+	return napping.Send(&req)
+}
+
+// Post ...
+func Post(endPoint string, obj interface{}) (*napping.Response, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	req := napping.Request{
+		Url:        fmt.Sprintf("%s/%s", APIURL, endPoint),
+		Method:     "POST",
+		RawPayload: true,
+		Payload:    bytes.NewBuffer(b),
+		Header:     header(true),
+	}
+	return napping.Send(&req)
+}
+
+// request sends authorized GET request and decodes response
+func request(endPoint string, params napping.Params, ret interface{}) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	resp, err := Get(endPoint, params.AsUrlValues(), true)
+	if err != nil {
+		return err
+	} else if resp.Status() != 200 {
+		return fmt.Errorf("Bad status getting Simkl %s: %d", endPoint, resp.Status())
+	}
+	return resp.Unmarshal(ret)
+}
+
+// AllUserItems returns user's movies, shows and anime with watched episodes
+func AllUserItems() (*AllItems, error) {
+	ret := &AllItems{}
+	for _, itemType := range []string{"movies", "shows", "anime"} {
+		items := &AllItems{}
+		if err := request("sync/all-items/"+itemType, napping.Params{"episode_watched_at": "yes"}, items); err != nil {
+			return nil, err
+		}
+
+		ret.Movies = append(ret.Movies, items.Movies...)
+		ret.Shows = append(ret.Shows, items.Shows...)
+		ret.Anime = append(ret.Anime, items.Anime...)
+	}
+	return ret, nil
+}
+
+// PausedItems returns movies and episodes with saved playback progress
+func PausedItems() (ret []*Playback, err error) {
+	err = request("sync/playback", napping.Params{}, &ret)
+	return
+}
+
+// AddToHistory marks items as watched
+func AddToHistory(history *History) error {
+	return postHistory("sync/history", history)
+}
+
+// RemoveFromHistory marks items as not watched
+func RemoveFromHistory(history *History) error {
+	return postHistory("sync/history/remove", history)
+}
+
+func postHistory(endPoint string, history *History) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	resp, err := Post(endPoint, history)
+	if err != nil {
+		return err
+	} else if resp.Status() != 200 && resp.Status() != 201 {
+		return fmt.Errorf("Bad status sending Simkl %s: %d", endPoint, resp.Status())
+	}
+	return nil
+}
+
+// Scrobble reports playback of the movie or episode of the show, action is start, pause or stop
+func Scrobble(action string, movie, show *Object, episode *Episode, progress float64) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"progress":    progress,
+		"app_name":    "elementum",
+		"app_version": ident.GetVersion(),
+	}
+	if movie != nil {
+		payload["movie"] = movie
+	} else {
+		payload["show"] = show
+		payload["episode"] = episode
+	}
+
+	resp, err := Post("scrobble/"+action, payload)
+	if err != nil {
+		return err
+	} else if resp.Status() >= 300 && resp.Status() != 409 {
+		return fmt.Errorf("Bad status scrobbling to Simkl: %d", resp.Status())
+	}
+	return nil
+}
+
+// GetCode requests PIN code for device authorization
+func GetCode() (*Code, error) {
+	if config.Get().SimklClientID == "" {
+		return nil, ErrNoClientID
+	}
+
+	resp, err := Get("oauth/pin", napping.Params{"client_id": config.Get().SimklClientID}.AsUrlValues(), false)
+	if err != nil {
+		return nil, err
+	} else if resp.Status() != 200 {
+		return nil, fmt.Errorf("Bad status getting Simkl code: %d", resp.Status())
+	}
+
+	code := &Code{}
+	if err := resp.Unmarshal(code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// PollToken checks whether user has entered PIN code
+func PollToken(code *Code) (*Token, error) {
+	resp, err := Get("oauth/pin/"+code.UserCode, napping.Params{"client_id": config.Get().SimklClientID}.AsUrlValues(), false)
+	if err != nil {
+		return nil, err
+	} else if resp.Status() != 200 {
+		return nil, fmt.Errorf("Bad status polling Simkl token: %d", resp.Status())
+	}
+
+	token := &Token{}
+	if err := resp.Unmarshal(token); err != nil {
+		return nil, err
+	} else if token.AccessToken == "" {
+		return nil, fmt.Errorf("Token is not ready: %s", token.Message)
+	}
+	return token, nil
+}
+
+// Authorize starts device authorization with PIN code
+func Authorize() error {
+	code, err := GetCode()
+	if err != nil {
+		log.Errorf("Could not get authorization code from Simkl: %s", err)
+		return err
+	}
+	log.Noticef("Got code for %s: %s", code.VerificationURL, code.UserCode)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func(code *Code) {
+		closing := broadcast.Closer.C()
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for attempts := 0; attempts < pinAttempts; attempts++ {
+			select {
+			case <-closing:
+				return
+			case <-tick.C:
+				token, err := PollToken(code)
+				if err != nil {
+					log.Debugf("Simkl authorization is pending: %s", err)
+					continue
+				}
+
+				config.Get().SimklToken = token.AccessToken
+				xbmcHost, _ := xbmc.GetLocalXBMCHost()
+				if xbmcHost != nil {
+					xbmcHost.SetSetting("simkl_token", token.AccessToken)
+				}
+
+				user := &UserSettings{}
+				if resp, err := Post("users/settings", struct{}{}); err == nil && resp.Status() == 200 && resp.Unmarshal(user) == nil && xbmcHost != nil {
+					xbmcHost.SetSetting("simkl_username", user.User.Name)
+				}
+
+				config.Reload()
+				if xbmcHost != nil {
+					xbmcHost.Notify("Elementum", "LOCALIZE[30753]", config.AddonIcon())
+				}
+				return
+			}
+		}
+
+		if xbmcHost, err := xbmc.GetLocalXBMCHost(); err == nil && xbmcHost != nil {
+			xbmcHost.Notify("Elementum", "LOCALIZE[30754]", config.AddonIcon())
+		}
+	}(code)
+
+	if xbmcHost, err := xbmc.GetLocalXBMCHost(); err == nil && xbmcHost != nil {
+		if !xbmcHost.Dialog("Simkl", fmt.Sprintf(xbmcHost.GetLocalizedString(30752), code.VerificationURL, code.UserCode)) {
+			return errors.New("Authentication canceled")
+		}
+	}
+	return nil
+}
+
+// Deauthorize removes Simkl token
+func Deauthorize() error {
+	if config.Get().SimklToken == "" {
+		return ErrNotAuthorized
+	}
+
+	if xbmcHost, err := xbmc.GetLocalXBMCHost(); err == nil && xbmcHost != nil {
+		xbmcHost.SetSetting("simkl_token", "")
+		xbmcHost.SetSetting("simkl_username", "")
+		xbmcHost.Notify("Elementum", "LOCALIZE[30755]", config.AddonIcon())
+	}
+	config.Reload()
+	return nil
+}
@@ -0,0 +1,85 @@
+package tmdb
+
+import (
+	"fmt"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/jmcvetta/napping"
+
+	"github.com/elgatito/elementum/cache"
+)
+
+// ListEntity is a movie or a show inside of TMDB list
+type ListEntity struct {
+	Entity
+
+	MediaType string `json:"media_type"`
+}
+
+// UserList is a user created TMDB list, that could have both movies and shows
+type UserList struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	ItemCount   int           `json:"item_count"`
+	Items       []*ListEntity `json:"items"`
+}
+
+// Collection is a TMDB collection of movies, like a franchise
+type Collection struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Overview     string    `json:"overview"`
+	PosterPath   string    `json:"poster_path"`
+	BackdropPath string    `json:"backdrop_path"`
+	Parts        []*Entity `json:"parts"`
+}
+
+// GetUserList returns TMDB list with movies and shows by its ID
+func GetUserList(listID string, language string) *UserList {
+	defer perf.ScopeTimer()()
+
+	var list *UserList
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.TMDBListKey, listID, language)
+	if err := cacheStore.Get(key, &list); err != nil {
+		err = MakeRequest(APIRequest{
+			URL: fmt.Sprintf("%s/list/%s", tmdbEndpoint, listID),
+			Params: napping.Params{
+				"api_key":  apiKey,
+				"language": language,
+			}.AsUrlValues(),
+			Result:      &list,
+			Description: "list",
+		})
+
+		if err == nil && list != nil {
+			cacheStore.Set(key, list, cache.TMDBListExpire)
+		}
+	}
+	return list
+}
+
+// GetCollection returns TMDB collection with all its movies
+func GetCollection(collectionID int, language string) *Collection {
+	defer perf.ScopeTimer()()
+
+	var collection *Collection
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.TMDBCollectionKey, collectionID, language)
+	if err := cacheStore.Get(key, &collection); err != nil {
+		err = MakeRequest(APIRequest{
+			URL: fmt.Sprintf("%s/collection/%d", tmdbEndpoint, collectionID),
+			Params: napping.Params{
+				"api_key":  apiKey,
+				"language": language,
+			}.AsUrlValues(),
+			Result:      &collection,
+			Description: "collection",
+		})
+
+		if err == nil && collection != nil {
+			cacheStore.Set(key, collection, cache.TMDBCollectionExpire)
+		}
+	}
+	return collection
+}
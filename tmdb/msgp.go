@@ -287,6 +287,85 @@ func (z *Cast) Msgsize() (s int) {
 	return
 }
 
+// MarshalMsg implements msgp.Marshaler
+func (z *CollectionInfo) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "ID"
+	o = append(o, 0x84, 0xa2, 0x49, 0x44)
+	o = msgp.AppendInt(o, z.ID)
+	// string "Name"
+	o = append(o, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Name)
+	// string "PosterPath"
+	o = append(o, 0xaa, 0x50, 0x6f, 0x73, 0x74, 0x65, 0x72, 0x50, 0x61, 0x74, 0x68)
+	o = msgp.AppendString(o, z.PosterPath)
+	// string "BackdropPath"
+	o = append(o, 0xac, 0x42, 0x61, 0x63, 0x6b, 0x64, 0x72, 0x6f, 0x70, 0x50, 0x61, 0x74, 0x68)
+	o = msgp.AppendString(o, z.BackdropPath)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *CollectionInfo) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ID":
+			z.ID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ID")
+				return
+			}
+		case "Name":
+			z.Name, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Name")
+				return
+			}
+		case "PosterPath":
+			z.PosterPath, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PosterPath")
+				return
+			}
+		case "BackdropPath":
+			z.BackdropPath, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BackdropPath")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *CollectionInfo) Msgsize() (s int) {
+	s = 1 + 3 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.Name) + 11 + msgp.StringPrefixSize + len(z.PosterPath) + 13 + msgp.StringPrefixSize + len(z.BackdropPath)
+	return
+}
+
 // MarshalMsg implements msgp.Marshaler
 func (z ContentRating) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
@@ -868,9 +947,9 @@ func (z DiscoverFilters) Msgsize() (s int) {
 // MarshalMsg implements msgp.Marshaler
 func (z *Entity) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 14
+	// map header, size 17
 	// string "IsAdult"
-	o = append(o, 0x8e, 0xa7, 0x49, 0x73, 0x41, 0x64, 0x75, 0x6c, 0x74)
+	o = append(o, 0xde, 0x0, 0x11, 0xa7, 0x49, 0x73, 0x41, 0x64, 0x75, 0x6c, 0x74)
 	o = msgp.AppendBool(o, z.IsAdult)
 	// string "BackdropPath"
 	o = append(o, 0xac, 0x42, 0x61, 0x63, 0x6b, 0x64, 0x72, 0x6f, 0x70, 0x50, 0x61, 0x74, 0x68)
@@ -924,6 +1003,15 @@ func (z *Entity) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Name"
 	o = append(o, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
 	o = msgp.AppendString(o, z.Name)
+	// string "ShowID"
+	o = append(o, 0xa6, 0x53, 0x68, 0x6f, 0x77, 0x49, 0x44)
+	o = msgp.AppendInt(o, z.ShowID)
+	// string "SeasonNumber"
+	o = append(o, 0xac, 0x53, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72)
+	o = msgp.AppendInt(o, z.SeasonNumber)
+	// string "EpisodeNumber"
+	o = append(o, 0xad, 0x45, 0x70, 0x69, 0x73, 0x6f, 0x64, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72)
+	o = msgp.AppendInt(o, z.EpisodeNumber)
 	return
 }
 
@@ -1082,6 +1170,24 @@ func (z *Entity) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "Name")
 				return
 			}
+		case "ShowID":
+			z.ShowID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ShowID")
+				return
+			}
+		case "SeasonNumber":
+			z.SeasonNumber, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SeasonNumber")
+				return
+			}
+		case "EpisodeNumber":
+			z.EpisodeNumber, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "EpisodeNumber")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -1096,7 +1202,7 @@ func (z *Entity) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *Entity) Msgsize() (s int) {
-	s = 1 + 8 + msgp.BoolSize + 13 + msgp.StringPrefixSize + len(z.BackdropPath) + 3 + msgp.IntSize + 7 + msgp.ArrayHeaderSize
+	s = 3 + 8 + msgp.BoolSize + 13 + msgp.StringPrefixSize + len(z.BackdropPath) + 3 + msgp.IntSize + 7 + msgp.ArrayHeaderSize
 	for za0001 := range z.Genres {
 		if z.Genres[za0001] == nil {
 			s += msgp.NilSize
@@ -1104,7 +1210,7 @@ func (z *Entity) Msgsize() (s int) {
 			s += 1 + 3 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.Genres[za0001].Name)
 		}
 	}
-	s += 14 + msgp.StringPrefixSize + len(z.OriginalTitle) + 17 + msgp.StringPrefixSize + len(z.OriginalLanguage) + 12 + msgp.StringPrefixSize + len(z.ReleaseDate) + 13 + msgp.StringPrefixSize + len(z.FirstAirDate) + 11 + msgp.StringPrefixSize + len(z.PosterPath) + 6 + msgp.StringPrefixSize + len(z.Title) + 12 + msgp.Float32Size + 10 + msgp.IntSize + 13 + msgp.StringPrefixSize + len(z.OriginalName) + 5 + msgp.StringPrefixSize + len(z.Name)
+	s += 14 + msgp.StringPrefixSize + len(z.OriginalTitle) + 17 + msgp.StringPrefixSize + len(z.OriginalLanguage) + 12 + msgp.StringPrefixSize + len(z.ReleaseDate) + 13 + msgp.StringPrefixSize + len(z.FirstAirDate) + 11 + msgp.StringPrefixSize + len(z.PosterPath) + 6 + msgp.StringPrefixSize + len(z.Title) + 12 + msgp.Float32Size + 10 + msgp.IntSize + 13 + msgp.StringPrefixSize + len(z.OriginalName) + 5 + msgp.StringPrefixSize + len(z.Name) + 7 + msgp.IntSize + 13 + msgp.IntSize + 14 + msgp.IntSize
 	return
 }
 
@@ -3213,9 +3319,9 @@ func (z *List) Msgsize() (s int) {
 // MarshalMsg implements msgp.Marshaler
 func (z *Movie) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 18
+	// map header, size 19
 	// string "Entity"
-	o = append(o, 0xde, 0x0, 0x12, 0xa6, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79)
+	o = append(o, 0xde, 0x0, 0x13, 0xa6, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79)
 	o, err = z.Entity.MarshalMsg(o)
 	if err != nil {
 		err = msgp.WrapError(err, "Entity")
@@ -3425,6 +3531,17 @@ func (z *Movie) MarshalMsg(b []byte) (o []byte, err error) {
 			}
 		}
 	}
+	// string "BelongsToCollection"
+	o = append(o, 0xb3, 0x42, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x73, 0x54, 0x6f, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e)
+	if z.BelongsToCollection == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.BelongsToCollection.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "BelongsToCollection")
+			return
+		}
+	}
 	return
 }
 
@@ -4007,6 +4124,23 @@ func (z *Movie) UnmarshalMsg(bts []byte) (o []byte, err error) {
 					}
 				}
 			}
+		case "BelongsToCollection":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.BelongsToCollection = nil
+			} else {
+				if z.BelongsToCollection == nil {
+					z.BelongsToCollection = new(CollectionInfo)
+				}
+				bts, err = z.BelongsToCollection.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "BelongsToCollection")
+					return
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -4121,6 +4255,12 @@ func (z *Movie) Msgsize() (s int) {
 			}
 		}
 	}
+	s += 20
+	if z.BelongsToCollection == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.BelongsToCollection.Msgsize()
+	}
 	return
 }
 
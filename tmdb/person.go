@@ -0,0 +1,214 @@
+package tmdb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/anacrolix/missinggo/perf"
+	"github.com/jmcvetta/napping"
+
+	"github.com/elgatito/elementum/cache"
+)
+
+// Departments of person credits, cast credits are in the acting department
+const (
+	DepartmentActing    = "Acting"
+	DepartmentDirecting = "Directing"
+)
+
+// Sort orders of person credits
+const (
+	PersonSortPopularity = "popularity"
+	PersonSortDate       = "date"
+)
+
+// Person is an actor or a crew member
+type Person struct {
+	ID                 int     `json:"id"`
+	Name               string  `json:"name"`
+	Biography          string  `json:"biography"`
+	Birthday           string  `json:"birthday"`
+	Deathday           string  `json:"deathday"`
+	PlaceOfBirth       string  `json:"place_of_birth"`
+	KnownForDepartment string  `json:"known_for_department"`
+	ProfilePath        string  `json:"profile_path"`
+	Popularity         float64 `json:"popularity"`
+	IMDBId             string  `json:"imdb_id"`
+}
+
+// PersonList is a page of people search
+type PersonList struct {
+	Page         int       `json:"page"`
+	Results      []*Person `json:"results"`
+	TotalPages   int       `json:"total_pages"`
+	TotalResults int       `json:"total_results"`
+}
+
+// PersonCredit is a movie or a show, the person took part in
+type PersonCredit struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	Popularity   float64 `json:"popularity"`
+	Character    string  `json:"character"`
+	Department   string  `json:"department"`
+	Job          string  `json:"job"`
+}
+
+// PersonCredits are combined movie and show credits of the person
+type PersonCredits struct {
+	Cast []*PersonCredit `json:"cast"`
+	Crew []*PersonCredit `json:"crew"`
+}
+
+// Date returns release date of the movie or first air date of the show
+func (c *PersonCredit) Date() string {
+	if c.MediaType == "tv" {
+		return c.FirstAirDate
+	}
+	return c.ReleaseDate
+}
+
+// GetPerson returns person details by TMDB ID
+func GetPerson(personID int, language string) *Person {
+	defer perf.ScopeTimer()()
+
+	var person *Person
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.TMDBPersonKey, personID, language)
+	if err := cacheStore.Get(key, &person); err != nil {
+		err = MakeRequest(APIRequest{
+			URL: fmt.Sprintf("%s/person/%d", tmdbEndpoint, personID),
+			Params: napping.Params{
+				"api_key":  apiKey,
+				"language": language,
+			}.AsUrlValues(),
+			Result:      &person,
+			Description: "person",
+		})
+
+		if err == nil && person != nil {
+			cacheStore.Set(key, person, cache.TMDBPersonExpire)
+		}
+	}
+	return person
+}
+
+// GetPersonCredits returns combined movie and show credits of the person
+func GetPersonCredits(personID int, language string) *PersonCredits {
+	defer perf.ScopeTimer()()
+
+	var credits *PersonCredits
+	cacheStore := cache.NewDBStore()
+	key := fmt.Sprintf(cache.TMDBPersonCreditsKey, personID, language)
+	if err := cacheStore.Get(key, &credits); err != nil {
+		err = MakeRequest(APIRequest{
+			URL: fmt.Sprintf("%s/person/%d/combined_credits", tmdbEndpoint, personID),
+			Params: napping.Params{
+				"api_key":  apiKey,
+				"language": language,
+			}.AsUrlValues(),
+			Result:      &credits,
+			Description: "person credits",
+		})
+
+		if err == nil && credits != nil {
+			cacheStore.Set(key, credits, cache.TMDBPersonCreditsExpire)
+		}
+	}
+	return credits
+}
+
+// SearchPeople ...
+func SearchPeople(query string, language string, page int) ([]*Person, int) {
+	defer perf.ScopeTimer()()
+
+	var results PersonList
+	MakeRequest(APIRequest{
+		URL: fmt.Sprintf("%s/search/person", tmdbEndpoint),
+		Params: napping.Params{
+			"api_key":  apiKey,
+			"query":    query,
+			"page":     strconv.Itoa(page),
+			"language": language,
+		}.AsUrlValues(),
+		Result:      &results,
+		Description: "search person",
+	})
+
+	return results.Results, results.TotalResults
+}
+
+// Departments returns departments of person credits of the media type ("movie" or "tv"), by number of credits
+func (credits *PersonCredits) Departments(mediaType string) []string {
+	counts := map[string]int{}
+	for _, c := range credits.all() {
+		if c.MediaType == mediaType {
+			counts[c.Department]++
+		}
+	}
+
+	ret := make([]string, 0, len(counts))
+	for d := range counts {
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if counts[ret[i]] == counts[ret[j]] {
+			return ret[i] < ret[j]
+		}
+		return counts[ret[i]] > counts[ret[j]]
+	})
+	return ret
+}
+
+// Filter returns credits of the media type ("movie" or "tv") in the department, or in any department if it is empty.
+// Every movie or show is returned once, credits are sorted by popularity or by date, newest first.
+func (credits *PersonCredits) Filter(mediaType, department, sortBy string) []*PersonCredit {
+	ret := []*PersonCredit{}
+	seen := map[int]bool{}
+	for _, c := range credits.all() {
+		if c.MediaType != mediaType || (department != "" && c.Department != department) || seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		ret = append(ret, c)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if sortBy == PersonSortDate {
+			return ret[i].Date() > ret[j].Date()
+		}
+		return ret[i].Popularity > ret[j].Popularity
+	})
+	return ret
+}
+
+// all returns cast and crew credits, cast credits get acting department
+func (credits *PersonCredits) all() []*PersonCredit {
+	ret := make([]*PersonCredit, 0, len(credits.Cast)+len(credits.Crew))
+	for _, c := range credits.Cast {
+		if c != nil {
+			c.Department = DepartmentActing
+			ret = append(ret, c)
+		}
+	}
+	for _, c := range credits.Crew {
+		if c != nil {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// PersonCreditIDs returns TMDB IDs of credits
+func PersonCreditIDs(credits []*PersonCredit) []int {
+	ret := make([]int, 0, len(credits))
+	for _, c := range credits {
+		ret = append(ret, c.ID)
+	}
+	return ret
+}
@@ -75,6 +75,16 @@ type Movie struct {
 	Images  *Images  `json:"images,omitempty"`
 
 	ReleaseDates *ReleaseDatesResults `json:"release_dates"`
+
+	BelongsToCollection *CollectionInfo `json:"belongs_to_collection"`
+}
+
+// CollectionInfo is a short info of TMDB collection, that has the movie
+type CollectionInfo struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
 }
 
 // Show ...
@@ -189,6 +199,11 @@ type Entity struct {
 	VoteCount        int       `json:"vote_count"`
 	OriginalName     string    `json:"original_name,omitempty"`
 	Name             string    `json:"name,omitempty"`
+
+	// Set for episodes in find results
+	ShowID        int `json:"show_id,omitempty"`
+	SeasonNumber  int `json:"season_number,omitempty"`
+	EpisodeNumber int `json:"episode_number,omitempty"`
 }
 
 // EntityList ...
@@ -832,6 +847,16 @@ func (credits *Credits) GetDirectors() []string {
 	return directors
 }
 
+// GetDirector returns first director
+func (credits *Credits) GetDirector() *Crew {
+	for _, crew := range credits.Crew {
+		if crew != nil && crew.Job == "Director" {
+			return crew
+		}
+	}
+	return nil
+}
+
 // GetWriters returns list of writers
 func (credits *Credits) GetWriters() []string {
 	writers := make([]string, 0)
@@ -548,6 +548,62 @@ func TopLists(page string) (lists []*ListContainer, hasNext bool) {
 	return lists, hasNext
 }
 
+// GetList returns list of the user, for own lists user could be empty
+func GetList(user string, listID string) (list *List, err error) {
+	defer perf.ScopeTimer()()
+
+	if user == "" || user == "id" {
+		user = config.Get().TraktUsername
+	}
+
+	endPoint := fmt.Sprintf("users/%s/lists/%s", user, listID)
+	params := napping.Params{}.AsUrlValues()
+
+	var resp *napping.Response
+	if !config.Get().TraktAuthorized {
+		resp, err = Get(endPoint, params)
+	} else {
+		resp, err = GetWithAuth(endPoint, params)
+	}
+
+	if err != nil {
+		return nil, err
+	} else if resp.Status() != 200 {
+		return nil, fmt.Errorf("Bad status getting list %s of %s: %d", listID, user, resp.Status())
+	}
+
+	err = resp.Unmarshal(&list)
+	return
+}
+
+// ListItems returns movies and shows of the list, it is not cached, to keep previous list state of library sync intact
+func ListItems(user string, listID string) (items []*ListItem, err error) {
+	defer perf.ScopeTimer()()
+
+	if user == "" || user == "id" {
+		user = config.Get().TraktUsername
+	}
+
+	endPoint := fmt.Sprintf("users/%s/lists/%s/items/movie,show", user, listID)
+	params := napping.Params{}.AsUrlValues()
+
+	var resp *napping.Response
+	if !config.Get().TraktAuthorized {
+		resp, err = Get(endPoint, params)
+	} else {
+		resp, err = GetWithAuth(endPoint, params)
+	}
+
+	if err != nil {
+		return nil, err
+	} else if resp.Status() != 200 {
+		return nil, fmt.Errorf("Bad status getting items of list %s of %s: %d", listID, user, resp.Status())
+	}
+
+	err = resp.Unmarshal(&items)
+	return
+}
+
 // PreviousListItemsMovies ...
 func PreviousListItemsMovies(listID string) (movies []*Movies, err error) {
 	cacheStore := cache.NewDBStore()
@@ -0,0 +1,244 @@
+package trakt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anacrolix/sync"
+	"github.com/jmcvetta/napping"
+
+	"github.com/elgatito/elementum/broadcast"
+	"github.com/elgatito/elementum/database"
+)
+
+const (
+	outboxCheckInterval = time.Minute
+	outboxMinBackoff    = time.Minute
+	outboxMaxBackoff    = 6 * time.Hour
+	outboxMaxAttempts   = 12
+
+	// Trakt marks item as watched, when scrobble is stopped after 80%
+	scrobbleWatchedProgress = 80
+)
+
+// Outbox item kinds
+const (
+	OutboxHistory    = "history"
+	OutboxWatchlist  = "watchlist"
+	OutboxCollection = "collection"
+	OutboxUserlist   = "userlist"
+	OutboxScrobble   = "scrobble"
+)
+
+// ErrQueued is returned when Trakt is not available and request is saved to be sent later
+var ErrQueued = errors.New("Trakt is not available, request is queued")
+
+var (
+	outboxMu     sync.Mutex
+	outboxWakeup = make(chan struct{}, 1)
+)
+
+// isRetryable tells whether request failed because of network or Trakt availability
+func isRetryable(resp *napping.Response, err error) bool {
+	if err != nil || resp == nil {
+		return true
+	}
+	return resp.Status() == 429 || resp.Status() >= 500
+}
+
+func isSuccess(resp *napping.Response) bool {
+	return resp != nil && resp.Status() >= 200 && resp.Status() < 300
+}
+
+// hasPendingOutbox tells whether there are requests, waiting to be sent
+func hasPendingOutbox() bool {
+	for _, item := range database.GetStorm().GetTraktOutboxItems() {
+		if !item.Failed {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue saves request to the outbox and wakes up outbox handler
+func enqueue(kind, endPoint string, payload []byte, lastError string) error {
+	item := &database.TraktOutboxItem{
+		Kind:      kind,
+		EndPoint:  endPoint,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+		LastError: lastError,
+	}
+	if err := database.GetStorm().SaveTraktOutboxItem(item); err != nil {
+		log.Errorf("Could not queue Trakt request to %s: %s", endPoint, err)
+		return err
+	}
+
+	log.Infof("Queued Trakt request to %s", endPoint)
+	WakeupOutbox()
+	return nil
+}
+
+// postOrQueue sends write request to Trakt, or saves it to the outbox, if Trakt is not available.
+// While outbox is not empty, new requests are queued as well, to keep the order of changes.
+func postOrQueue(kind, endPoint string, payload []byte) (*napping.Response, error) {
+	if hasPendingOutbox() {
+		if err := enqueue(kind, endPoint, payload, ""); err != nil {
+			return nil, err
+		}
+		return nil, ErrQueued
+	}
+
+	resp, err := Post(endPoint, bytes.NewBuffer(payload))
+	if !isRetryable(resp, err) {
+		return resp, err
+	}
+
+	if errQueue := enqueue(kind, endPoint, payload, requestError(resp, err)); errQueue != nil {
+		return resp, err
+	}
+	return nil, ErrQueued
+}
+
+// requestError describes failed request for outbox status
+func requestError(resp *napping.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	} else if resp == nil {
+		return "Empty response"
+	}
+	return fmt.Sprintf("Bad status code: %d", resp.Status())
+}
+
+// outboxBackoff returns delay before the next attempt: one minute after the first failure,
+// doubled after each following one, up to six hours
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxMinBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+// WakeupOutbox asks outbox handler to send queued requests
+func WakeupOutbox() {
+	select {
+	case outboxWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// OutboxHandler sends queued requests, failed ones are retried with growing intervals
+func OutboxHandler() {
+	ticker := time.NewTicker(outboxCheckInterval)
+	closer := broadcast.Closer.C()
+	defer ticker.Stop()
+
+	ProcessOutbox()
+	for {
+		select {
+		case <-closer:
+			return
+		case <-ticker.C:
+			ProcessOutbox()
+		case <-outboxWakeup:
+			ProcessOutbox()
+		}
+	}
+}
+
+// ProcessOutbox sends queued requests, which are due, in the order they were made
+func ProcessOutbox() {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	if err := Authorized(); err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, item := range database.GetStorm().GetTraktOutboxItems() {
+		if item.Failed {
+			continue
+		} else if item.NextAttempt.After(now) {
+			// Keep the order of changes, following requests wait for this one
+			return
+		}
+
+		resp, err := Post(item.EndPoint, bytes.NewBuffer(item.Payload))
+		if isSuccess(resp) && err == nil {
+			log.Infof("Sent queued Trakt request to %s, made at %s", item.EndPoint, item.CreatedAt.Format(time.RFC3339))
+			if err := database.GetStorm().DeleteTraktOutboxItem(item.ID); err != nil {
+				log.Warningf("Could not remove Trakt outbox item: %s", err)
+			}
+			continue
+		}
+
+		item.Attempts++
+		item.LastError = requestError(resp, err)
+		if !isRetryable(resp, err) || item.Attempts >= outboxMaxAttempts {
+			log.Warningf("Dropping Trakt request to %s after %d attempts: %s", item.EndPoint, item.Attempts, item.LastError)
+			item.Failed = true
+		} else {
+			item.NextAttempt = time.Now().Add(outboxBackoff(item.Attempts))
+			log.Infof("Could not send queued Trakt request to %s: %s. Next attempt at %s", item.EndPoint, item.LastError, item.NextAttempt.Format(time.RFC3339))
+		}
+
+		if err := database.GetStorm().SaveTraktOutboxItem(&item); err != nil {
+			log.Warningf("Could not update Trakt outbox item: %s", err)
+		}
+		if !item.Failed {
+			return
+		}
+	}
+}
+
+// OutboxStats returns number of pending and failed requests
+func OutboxStats() (pending, failed int) {
+	for _, item := range database.GetStorm().GetTraktOutboxItems() {
+		if item.Failed {
+			failed++
+		} else {
+			pending++
+		}
+	}
+	return
+}
+
+// GetOutbox returns all queued requests
+func GetOutbox() []database.TraktOutboxItem {
+	return database.GetStorm().GetTraktOutboxItems()
+}
+
+// RetryOutbox returns failed requests to the queue
+func RetryOutbox() {
+	for _, item := range database.GetStorm().GetTraktOutboxItems() {
+		if !item.Failed {
+			continue
+		}
+
+		item.Failed = false
+		item.Attempts = 0
+		item.NextAttempt = time.Time{}
+		if err := database.GetStorm().SaveTraktOutboxItem(&item); err != nil {
+			log.Warningf("Could not update Trakt outbox item: %s", err)
+		}
+	}
+	WakeupOutbox()
+}
+
+// ClearOutbox removes failed requests, or all requests if "all" is set
+func ClearOutbox(all bool) {
+	for _, item := range database.GetStorm().GetTraktOutboxItems() {
+		if item.Failed || all {
+			if err := database.GetStorm().DeleteTraktOutboxItem(item.ID); err != nil {
+				log.Warningf("Could not remove Trakt outbox item: %s", err)
+			}
+		}
+	}
+}
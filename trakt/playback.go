@@ -0,0 +1,54 @@
+package trakt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/elgatito/elementum/util/ident"
+)
+
+var errNoPlayback = errors.New("Only movies and episodes can have playback progress")
+
+// SetPlaybackProgress saves playback progress of the movie, or of the episode of the show.
+// Trakt has no endpoint to set progress, so it is done with scrobble pause.
+func SetPlaybackProgress(item *RatedItem, progress float64) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	var payload string
+	switch {
+	case item.MediaType == RatedMovie && item.Movie != 0:
+		payload = fmt.Sprintf(`{"movie": {"ids": {"tmdb": %d}}, "progress": %f, "app_version": "%s"}`, item.Movie, progress, ident.GetVersion())
+	case item.MediaType == RatedEpisode && item.Show != 0:
+		payload = fmt.Sprintf(`{"show": {"ids": {"tmdb": %d}}, "episode": {"season": %d, "number": %d}, "progress": %f, "app_version": "%s"}`, item.Show, item.Season, item.Episode, progress, ident.GetVersion())
+	default:
+		return errNoPlayback
+	}
+
+	log.Debugf("Setting Trakt playback progress for %s: %f%%", item.MediaType, progress)
+	resp, err := Post("scrobble/pause", bytes.NewBufferString(payload))
+	if err != nil {
+		return err
+	} else if resp.Status() != 201 {
+		return fmt.Errorf("Bad status setting Trakt playback progress: %d", resp.Status())
+	}
+	return nil
+}
+
+// RemovePlayback removes playback progress entry by its ID
+func RemovePlayback(id int) error {
+	if err := Authorized(); err != nil {
+		return err
+	}
+
+	log.Debugf("Removing Trakt playback entry %d", id)
+	resp, err := Delete(fmt.Sprintf("sync/playback/%d", id))
+	if err != nil {
+		return err
+	} else if resp.Status() != 204 && resp.Status() != 404 {
+		return fmt.Errorf("Bad status removing Trakt playback entry: %d", resp.Status())
+	}
+	return nil
+}
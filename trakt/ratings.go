@@ -0,0 +1,225 @@
+package trakt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jmcvetta/napping"
+
+	"github.com/elgatito/elementum/database"
+	"github.com/elgatito/elementum/util/ident"
+	"github.com/elgatito/elementum/xbmc"
+)
+
+// Rated item types
+const (
+	RatedMovie   = "movie"
+	RatedShow    = "show"
+	RatedSeason  = "season"
+	RatedEpisode = "episode"
+)
+
+// OutboxRating is a kind of queued rating requests
+const OutboxRating = "rating"
+
+var (
+	errBadRating   = errors.New("Rating should be between 1 and 10")
+	errBadItem     = errors.New("Item can not be rated")
+	errNoCheckin   = errors.New("Only movies and episodes can be checked in")
+	errCheckinBusy = errors.New("Another item is already checked in")
+)
+
+// ratingLabels are Trakt names for ratings, from 10 to 1
+var ratingLabels = []string{
+	"LOCALIZE[30737]",
+	"LOCALIZE[30738]",
+	"LOCALIZE[30739]",
+	"LOCALIZE[30740]",
+	"LOCALIZE[30741]",
+	"LOCALIZE[30742]",
+	"LOCALIZE[30743]",
+	"LOCALIZE[30744]",
+	"LOCALIZE[30745]",
+	"LOCALIZE[30746]",
+}
+
+// RatedItem is a movie, show, season or episode to rate or check in
+type RatedItem struct {
+	MediaType string
+	Movie     int
+	Show      int
+	Season    int
+	Episode   int
+}
+
+type ratingIDs struct {
+	TMDB int `json:"tmdb"`
+}
+
+type ratingEpisode struct {
+	Number  int    `json:"number"`
+	Rating  int    `json:"rating,omitempty"`
+	RatedAt string `json:"rated_at,omitempty"`
+}
+
+type ratingSeason struct {
+	Number   int              `json:"number"`
+	Rating   int              `json:"rating,omitempty"`
+	RatedAt  string           `json:"rated_at,omitempty"`
+	Episodes []*ratingEpisode `json:"episodes,omitempty"`
+}
+
+type ratingObject struct {
+	IDs     ratingIDs       `json:"ids"`
+	Rating  int             `json:"rating,omitempty"`
+	RatedAt string          `json:"rated_at,omitempty"`
+	Seasons []*ratingSeason `json:"seasons,omitempty"`
+}
+
+type ratingPayload struct {
+	Movies []*ratingObject `json:"movies,omitempty"`
+	Shows  []*ratingObject `json:"shows,omitempty"`
+}
+
+// payload creates sync/ratings request body, rating is omitted for removal
+func (item *RatedItem) payload(rating int) ([]byte, error) {
+	ratedAt := ""
+	if rating > 0 {
+		ratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	payload := ratingPayload{}
+	switch {
+	case item.MediaType == RatedMovie && item.Movie != 0:
+		payload.Movies = []*ratingObject{{IDs: ratingIDs{TMDB: item.Movie}, Rating: rating, RatedAt: ratedAt}}
+	case item.MediaType == RatedShow && item.Show != 0:
+		payload.Shows = []*ratingObject{{IDs: ratingIDs{TMDB: item.Show}, Rating: rating, RatedAt: ratedAt}}
+	case item.MediaType == RatedSeason && item.Show != 0:
+		payload.Shows = []*ratingObject{{
+			IDs:     ratingIDs{TMDB: item.Show},
+			Seasons: []*ratingSeason{{Number: item.Season, Rating: rating, RatedAt: ratedAt}},
+		}}
+	case item.MediaType == RatedEpisode && item.Show != 0:
+		payload.Shows = []*ratingObject{{
+			IDs: ratingIDs{TMDB: item.Show},
+			Seasons: []*ratingSeason{{
+				Number:   item.Season,
+				Episodes: []*ratingEpisode{{Number: item.Episode, Rating: rating, RatedAt: ratedAt}},
+			}},
+		}}
+	default:
+		return nil, errBadItem
+	}
+
+	return json.Marshal(payload)
+}
+
+// SelectRating asks user to choose rating, returns 0 if dialog is cancelled
+func SelectRating(xbmcHost *xbmc.XBMCHost, title string) int {
+	if xbmcHost == nil {
+		return 0
+	}
+
+	choice := xbmcHost.ListDialog(title, ratingLabels...)
+	if choice < 0 || choice >= len(ratingLabels) {
+		return 0
+	}
+	return len(ratingLabels) - choice
+}
+
+// RateItem sets user rating (1-10) for a movie, show, season or episode
+func RateItem(item *RatedItem, rating int) (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	} else if rating < 1 || rating > 10 {
+		return nil, errBadRating
+	}
+
+	b, err := item.payload(rating)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Setting Trakt rating %d for %s: %+v", rating, item.MediaType, *item)
+	return postOrQueue(OutboxRating, "sync/ratings", b)
+}
+
+// RemoveRating removes user rating for a movie, show, season or episode
+func RemoveRating(item *RatedItem) (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	b, err := item.payload(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return postOrQueue(OutboxRating, "sync/ratings/remove", b)
+}
+
+// Checkin marks movie or episode as being watched right now.
+// Check-ins are not queued, as they make no sense when sent later.
+func Checkin(item *RatedItem) (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	var payload string
+	switch {
+	case item.MediaType == RatedMovie && item.Movie != 0:
+		payload = fmt.Sprintf(`{"movie": {"ids": {"tmdb": %d}}, "app_version": "%s"}`, item.Movie, ident.GetVersion())
+	case item.MediaType == RatedEpisode && item.Show != 0:
+		payload = fmt.Sprintf(`{"show": {"ids": {"tmdb": %d}}, "episode": {"season": %d, "number": %d}, "app_version": "%s"}`, item.Show, item.Season, item.Episode, ident.GetVersion())
+	default:
+		return nil, errNoCheckin
+	}
+
+	resp, err = Post("checkin", bytes.NewBufferString(payload))
+	if err != nil {
+		return
+	} else if resp.Status() == 409 {
+		return resp, errCheckinBusy
+	}
+	return
+}
+
+// CancelCheckin removes active check-in
+func CancelCheckin() (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	return Delete("checkin")
+}
+
+// HideRecommendation hides movie or show from personal recommendations
+func HideRecommendation(itemType string, tmdbID string) (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	traktID := 0
+	if itemType == "movies" {
+		if movie := GetMovieByTMDB(tmdbID); movie != nil && movie.IDs != nil {
+			traktID = movie.IDs.Trakt
+		}
+	} else if itemType == "shows" {
+		if show := GetShowByTMDB(tmdbID); show != nil && show.IDs != nil {
+			traktID = show.IDs.Trakt
+		}
+	}
+	if traktID == 0 {
+		return nil, fmt.Errorf("Could not find Trakt %s with TMDB ID %s", itemType, tmdbID)
+	}
+
+	resp, err = Delete(fmt.Sprintf("recommendations/%s/%s", itemType, strconv.Itoa(traktID)))
+	if err == nil && isSuccess(resp) {
+		database.GetCache().DeleteWithPrefix(database.CommonBucket, []byte(fmt.Sprintf("com.trakt.%s.recommendations", itemType)))
+	}
+	return
+}
@@ -717,6 +717,41 @@ func Post(endPoint string, payload *bytes.Buffer) (resp *napping.Response, err e
 	return
 }
 
+// Delete ...
+func Delete(endPoint string) (resp *napping.Response, err error) {
+	header := http.Header{
+		"Content-type":      []string{"application/json"},
+		"Authorization":     []string{fmt.Sprintf("Bearer %s", config.Get().TraktToken)},
+		"trakt-api-key":     []string{config.TraktWriteClientID},
+		"trakt-api-version": []string{APIVersion},
+		"User-Agent":        []string{UserAgent},
+		"Cookie":            []string{Cookies},
+	}
+
+	req := napping.Request{
+		Url:    fmt.Sprintf("%s/%s", APIURL, endPoint),
+		Method: "DELETE",
+		Header: &header,
+	}
+
+	rl.Call(func() error {
+		resp, err = napping.Send(&req)
+		if err != nil {
+			return err
+		} else if resp.Status() == 429 {
+			log.Warningf("Rate limit exceeded getting %s, cooling down...", endPoint)
+			rl.CoolDown(resp.HttpResponse().Header)
+			return util.ErrExceeded
+		} else if resp.Status() == 403 && retriesLeft > 0 {
+			retriesLeft--
+			resp, err = Delete(endPoint)
+		}
+
+		return nil
+	})
+	return
+}
+
 // GetCode ...
 func GetCode() (code *Code, err error) {
 	endPoint := "oauth/device/code"
@@ -1138,7 +1173,32 @@ func AddToWatchlist(itemType string, tmdbID string) (resp *napping.Response, err
 	}
 
 	endPoint := "sync/watchlist"
-	return Post(endPoint, bytes.NewBufferString(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
+	return postOrQueue(OutboxWatchlist, endPoint, []byte(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
+}
+
+// AddMultipleToWatchlist adds movies and shows with given TMDB IDs to the watchlist in one request
+func AddMultipleToWatchlist(movies, shows []int) (resp *napping.Response, err error) {
+	if err := Authorized(); err != nil {
+		return nil, err
+	}
+
+	payload := ListItemsPayload{}
+	for _, id := range movies {
+		i := &Movie{}
+		i.IDs = &IDs{TMDB: id}
+		payload.Movies = append(payload.Movies, i)
+	}
+	for _, id := range shows {
+		i := &Show{}
+		i.IDs = &IDs{TMDB: id}
+		payload.Shows = append(payload.Shows, i)
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return postOrQueue(OutboxWatchlist, "sync/watchlist", b)
 }
 
 // AddToUserlist ...
@@ -1160,7 +1220,11 @@ func AddToUserlist(listID int, itemType string, tmdbID string) (resp *napping.Re
 		payload.Shows = append(payload.Shows, i)
 	}
 
-	return PostJSON(endPoint, payload)
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return postOrQueue(OutboxUserlist, endPoint, b)
 }
 
 // RemoveFromUserlist ...
@@ -1182,7 +1246,11 @@ func RemoveFromUserlist(listID int, itemType string, tmdbID string) (resp *nappi
 		payload.Shows = append(payload.Shows, i)
 	}
 
-	return PostJSON(endPoint, payload)
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return postOrQueue(OutboxUserlist, endPoint, b)
 }
 
 // RemoveFromWatchlist ...
@@ -1192,7 +1260,7 @@ func RemoveFromWatchlist(itemType string, tmdbID string) (resp *napping.Response
 	}
 
 	endPoint := "sync/watchlist/remove"
-	return Post(endPoint, bytes.NewBufferString(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
+	return postOrQueue(OutboxWatchlist, endPoint, []byte(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
 }
 
 // AddToCollection ...
@@ -1202,7 +1270,7 @@ func AddToCollection(itemType string, tmdbID string) (resp *napping.Response, er
 	}
 
 	endPoint := "sync/collection"
-	return Post(endPoint, bytes.NewBufferString(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
+	return postOrQueue(OutboxCollection, endPoint, []byte(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
 }
 
 // RemoveFromCollection ...
@@ -1212,7 +1280,7 @@ func RemoveFromCollection(itemType string, tmdbID string) (resp *napping.Respons
 	}
 
 	endPoint := "sync/collection/remove"
-	return Post(endPoint, bytes.NewBufferString(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
+	return postOrQueue(OutboxCollection, endPoint, []byte(fmt.Sprintf(`{"%s": [{"ids": {"tmdb": %s}}]}`, itemType, tmdbID)))
 }
 
 // SetWatched addes and removes from watched history
@@ -1233,7 +1301,7 @@ func SetWatched(item *WatchedItem) (resp *napping.Response, err error) {
 		endPoint = "sync/history/remove"
 	}
 
-	return Post(endPoint, bytes.NewBufferString(pre+query+post))
+	return postOrQueue(OutboxHistory, endPoint, []byte(pre+query+post))
 }
 
 // SetMultipleWatched adds and removes from watched history
@@ -1266,9 +1334,12 @@ func SetMultipleWatched(items []*WatchedItem) (*HistoryResponse, error) {
 
 	log.Debugf("Setting watch state at %s for %d %s items", endPoint, len(items), items[0].MediaType)
 
-	resp, err := Post(endPoint, bytes.NewBufferString(pre+query+post))
+	resp, err := postOrQueue(OutboxHistory, endPoint, []byte(pre+query+post))
 
-	if err != nil {
+	if err == ErrQueued {
+		log.Infof("Watch state for %d %s items will be sent later", len(items), items[0].MediaType)
+		return nil, err
+	} else if err != nil {
 		log.Warningf("Error getting watched items: %s", err)
 		return nil, err
 	} else if resp.Status() != 200 && resp.Status() != 201 {
@@ -1344,7 +1415,12 @@ func Scrobble(action string, contentType string, tmdbID int, watched float64, ru
 	payload := fmt.Sprintf(`{"%s": {"ids": {"tmdb": %d}}, "progress": %f, "app_version": "%s"}`,
 		contentType, tmdbID, progress, ident.GetVersion())
 	resp, err := Post(endPoint, bytes.NewBufferString(payload))
-	if err != nil {
+	if isRetryable(resp, err) && action == "stop" && progress >= scrobbleWatchedProgress {
+		// Scrobble can't be replayed later, so we keep the fact of watching with original time
+		log.Warningf("Could not scrobble %s #%d: %s. Adding it to history later", contentType, tmdbID, requestError(resp, err))
+		history := fmt.Sprintf(`{"%ss": [{"watched_at": "%s", "ids": {"tmdb": %d}}]}`, contentType, time.Now().UTC().Format(time.RFC3339), tmdbID)
+		enqueue(OutboxScrobble, "sync/history", []byte(history), requestError(resp, err))
+	} else if err != nil {
 		log.Error(err.Error())
 		if xbmcHost, err := xbmc.GetLocalXBMCHost(); err == nil && xbmcHost != nil {
 			xbmcHost.Notify("Elementum", "Scrobble failed, check your logs.", config.AddonIcon())
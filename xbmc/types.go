@@ -36,6 +36,9 @@ const (
 	IconOverlayHD
 )
 
+// VideoPlaylistID is the id of Kodi video playlist
+const VideoPlaylistID = 1
+
 var (
 	// KodiVersion saves Kodi platform
 	KodiVersion = 0
@@ -230,6 +233,7 @@ type VideoLibraryMovieItem struct {
 	File       string    `json:"file"`
 	Year       int       `json:"year"`
 	DateAdded  KodiTime  `json:"dateadded"`
+	LastPlayed KodiTime  `json:"lastplayed" msg:"-"`
 	UniqueIDs  UniqueIDs `json:"uniqueid"`
 	Resume     *Resume
 }
@@ -285,16 +289,17 @@ type VideoLibraryEpisode struct {
 
 // VideoLibraryEpisodeItem ...
 type VideoLibraryEpisodeItem struct {
-	ID        int       `json:"episodeid"`
-	Title     string    `json:"label"`
-	Season    int       `json:"season"`
-	Episode   int       `json:"episode"`
-	TVShowID  int       `json:"tvshowid"`
-	PlayCount int       `json:"playcount"`
-	File      string    `json:"file"`
-	DateAdded KodiTime  `json:"dateadded"`
-	UniqueIDs UniqueIDs `json:"uniqueid"`
-	Resume    *Resume
+	ID         int       `json:"episodeid"`
+	Title      string    `json:"label"`
+	Season     int       `json:"season"`
+	Episode    int       `json:"episode"`
+	TVShowID   int       `json:"tvshowid"`
+	PlayCount  int       `json:"playcount"`
+	File       string    `json:"file"`
+	DateAdded  KodiTime  `json:"dateadded"`
+	LastPlayed KodiTime  `json:"lastplayed" msg:"-"`
+	UniqueIDs  UniqueIDs `json:"uniqueid"`
+	Resume     *Resume
 }
 
 // UniqueIDs ...
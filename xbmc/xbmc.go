@@ -67,6 +67,7 @@ func (h *XBMCHost) VideoLibraryGetMovies() (movies *VideoLibraryMovies, err erro
 		"playcount",
 		"file",
 		"dateadded",
+		"lastplayed",
 		"resume",
 	}
 	if KodiVersion > 16 {
@@ -141,6 +142,12 @@ func (h *XBMCHost) VideoLibraryRemoveMovie(id int) (retVal string) {
 	return
 }
 
+// VideoLibraryRefreshMovie rescrapes the movie, reading its NFO file again
+func (h *XBMCHost) VideoLibraryRefreshMovie(id int) (retVal string) {
+	h.executeJSONRPC("VideoLibrary.RefreshMovie", &retVal, Args{id, false})
+	return
+}
+
 // VideoLibraryRemoveTVShow ...
 func (h *XBMCHost) VideoLibraryRemoveTVShow(id int) (retVal string) {
 	h.executeJSONRPC("VideoLibrary.RemoveTVShow", &retVal, Args{id})
@@ -314,6 +321,7 @@ func (h *XBMCHost) VideoLibraryGetEpisodes(tvshowID int) (episodes *VideoLibrary
 		"playcount",
 		"file",
 		"dateadded",
+		"lastplayed",
 		"resume",
 	}}
 	err = h.executeJSONRPCO("VideoLibrary.GetEpisodes", &episodes, params)
@@ -568,6 +576,72 @@ func (h *XBMCHost) PlaylistClear() (retVal int) {
 	return
 }
 
+// VideoPlaylistClear removes all items from Kodi video playlist
+func (h *XBMCHost) VideoPlaylistClear() (retVal string) {
+	params := map[string]interface{}{
+		"playlistid": VideoPlaylistID,
+	}
+	h.executeJSONRPCO("Playlist.Clear", &retVal, params)
+	return
+}
+
+// VideoPlaylistAdd appends file to Kodi video playlist
+func (h *XBMCHost) VideoPlaylistAdd(file string) (retVal string) {
+	params := map[string]interface{}{
+		"playlistid": VideoPlaylistID,
+		"item": map[string]interface{}{
+			"file": file,
+		},
+	}
+	h.executeJSONRPCO("Playlist.Add", &retVal, params)
+	return
+}
+
+// VideoPlaylistInsert inserts file into Kodi video playlist at the position
+func (h *XBMCHost) VideoPlaylistInsert(position int, file string) (retVal string) {
+	params := map[string]interface{}{
+		"playlistid": VideoPlaylistID,
+		"position":   position,
+		"item": map[string]interface{}{
+			"file": file,
+		},
+	}
+	h.executeJSONRPCO("Playlist.Insert", &retVal, params)
+	return
+}
+
+// VideoPlaylistRemove removes item at the position from Kodi video playlist
+func (h *XBMCHost) VideoPlaylistRemove(position int) (retVal string) {
+	params := map[string]interface{}{
+		"playlistid": VideoPlaylistID,
+		"position":   position,
+	}
+	h.executeJSONRPCO("Playlist.Remove", &retVal, params)
+	return
+}
+
+// VideoPlaylistPlay starts playback of Kodi video playlist from the position
+func (h *XBMCHost) VideoPlaylistPlay(position int) (retVal string) {
+	params := map[string]interface{}{
+		"item": map[string]interface{}{
+			"playlistid": VideoPlaylistID,
+			"position":   position,
+		},
+	}
+	h.executeJSONRPCO("Player.Open", &retVal, params)
+	return
+}
+
+// PlayerGoTo switches active player to the next or previous playlist item
+func (h *XBMCHost) PlayerGoTo(playerid int, to string) (retVal string) {
+	params := map[string]interface{}{
+		"playerid": playerid,
+		"to":       to,
+	}
+	h.executeJSONRPCO("Player.GoTo", &retVal, params)
+	return
+}
+
 // PlayURL ...
 func (h *XBMCHost) PlayURL(url string) {
 	retVal := ""